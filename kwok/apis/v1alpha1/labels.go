@@ -33,6 +33,11 @@ const (
 	KwokLabelValue        = "fake"
 	NodeViewerLabelKey    = "eks-node-viewer/instance-price"
 	KwokPartitionLabelKey = "kwok-partition"
+
+	// Annotations that inject an interruption notice for the node. The deadline is either an RFC3339 timestamp or a
+	// duration that starts when the annotation is observed.
+	InterruptionDeadlineAnnotationKey = apis.Group + "/interruption-deadline"
+	InterruptionReasonAnnotationKey   = apis.Group + "/interruption-reason"
 )
 
 func init() {
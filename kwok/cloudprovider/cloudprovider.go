@@ -26,10 +26,14 @@ import (
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/samber/lo"
+	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/dcoppa/karpenter/kwok/apis/v1alpha1"
@@ -38,14 +42,16 @@ import (
 	"github.com/dcoppa/karpenter/pkg/scheduling"
 )
 
-func NewCloudProvider(ctx context.Context, kubeClient client.Client, instanceTypes []*cloudprovider.InstanceType) *CloudProvider {
+func NewCloudProvider(ctx context.Context, clk clock.Clock, kubeClient client.Client, instanceTypes []*cloudprovider.InstanceType) *CloudProvider {
 	return &CloudProvider{
+		clock:         clk,
 		kubeClient:    kubeClient,
 		instanceTypes: instanceTypes,
 	}
 }
 
 type CloudProvider struct {
+	clock         clock.Clock
 	kubeClient    client.Client
 	instanceTypes []*cloudprovider.InstanceType
 }
@@ -59,6 +65,19 @@ func (c CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1
 	if err := c.kubeClient.Create(ctx, node); err != nil {
 		return nil, fmt.Errorf("creating node, %w", err)
 	}
+	// Publish the devices of the node since KwoK nodes don't have DRA drivers that would normally publish them
+	slices, err := c.toResourceSlices(node)
+	if err != nil {
+		// Delete the node so that it doesn't leak
+		return nil, multierr.Combine(fmt.Errorf("translating node to resource slices, %w", err), client.IgnoreNotFound(c.kubeClient.Delete(ctx, node)))
+	}
+	for _, slice := range slices {
+		if err := c.kubeClient.Create(ctx, slice); err != nil {
+			// Delete the node so that it doesn't leak, the resource slices that were already created are garbage
+			// collected with it since the node owns them
+			return nil, multierr.Combine(fmt.Errorf("creating resource slice, %w", err), client.IgnoreNotFound(c.kubeClient.Delete(ctx, node)))
+		}
+	}
 	// convert the node back into a node claim to get the chosen resolved requirement values.
 	return c.toNodeClaim(node)
 }
@@ -163,6 +182,9 @@ func (c CloudProvider) toNode(nodeClaim *v1.NodeClaim) (*corev1.Node, error) {
 		}
 
 		availableOfferings := it.Offerings.Available().Compatible(requirements)
+		if len(availableOfferings) == 0 {
+			continue
+		}
 
 		offeringsByPrice := lo.GroupBy(availableOfferings, func(of cloudprovider.Offering) float64 { return of.Price })
 		minOfferingPrice := lo.Min(lo.Keys(offeringsByPrice))
@@ -171,6 +193,9 @@ func (c CloudProvider) toNode(nodeClaim *v1.NodeClaim) (*corev1.Node, error) {
 			instanceType = it
 		}
 	}
+	if cheapestOffering == nil {
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("no available offerings"), c.unavailableOfferings(req.Values, requirements)...)
+	}
 
 	return &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
@@ -190,6 +215,56 @@ func (c CloudProvider) toNode(nodeClaim *v1.NodeClaim) (*corev1.Node, error) {
 	}, nil
 }
 
+// unavailableOfferings returns the offerings of the instance types that are compatible with the requirements but aren't
+// available, which are the offerings that failed to launch when a NodeClaim can't be launched on any offering
+func (c CloudProvider) unavailableOfferings(instanceTypeNames []string, requirements scheduling.Requirements) []cloudprovider.OfferingKey {
+	var keys []cloudprovider.OfferingKey
+	for _, name := range instanceTypeNames {
+		it, err := c.getInstanceType(name)
+		if err != nil {
+			continue
+		}
+		for _, o := range it.Offerings.Compatible(requirements) {
+			keys = append(keys, cloudprovider.OfferingKey{InstanceType: it.Name, Zone: o.Zone(), CapacityType: o.CapacityType()})
+		}
+	}
+	return keys
+}
+
+// toResourceSlices creates a ResourceSlice for every DeviceClass of the node's instance type. The DeviceClass name is
+// used as the driver name, so DeviceClasses can select the devices with a `device.driver == "<DeviceClass name>"`
+// selector. The ResourceSlices are owned by the node so that they are garbage collected when the node is deleted.
+func (c CloudProvider) toResourceSlices(node *corev1.Node) ([]*resourcev1alpha3.ResourceSlice, error) {
+	it, err := c.getInstanceType(node.Labels[corev1.LabelInstanceTypeStable])
+	if err != nil {
+		return nil, err
+	}
+	return lo.MapToSlice(it.Devices, func(deviceClass corev1.ResourceName, count resource.Quantity) *resourcev1alpha3.ResourceSlice {
+		return &resourcev1alpha3.ResourceSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("%s-%s", node.Name, deviceClass),
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				}},
+			},
+			Spec: resourcev1alpha3.ResourceSliceSpec{
+				Driver:   string(deviceClass),
+				NodeName: node.Name,
+				Pool: resourcev1alpha3.ResourcePool{
+					Name:               node.Name,
+					ResourceSliceCount: 1,
+				},
+				Devices: lo.Times(int(count.Value()), func(i int) resourcev1alpha3.Device {
+					return resourcev1alpha3.Device{Name: fmt.Sprintf("device-%d", i), Basic: &resourcev1alpha3.BasicDevice{}}
+				}),
+			},
+		}
+	}), nil
+}
+
 func addInstanceLabels(labels map[string]string, instanceType *cloudprovider.InstanceType, nodeClaim *v1.NodeClaim, offering *cloudprovider.Offering) map[string]string {
 	ret := make(map[string]string, len(labels))
 	// start with labels on the nodeclaim
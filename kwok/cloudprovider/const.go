@@ -18,6 +18,9 @@ package kwok
 
 const (
 	kwokProviderPrefix = "kwok://"
+
+	// DeviceClassGPU is the DeviceClass of the fake GPUs that are attached to the GPU instance types
+	DeviceClassGPU = "gpu.karpenter.kwok.sh"
 )
 
 var kwokPartitions = []string{"a"}
@@ -20,6 +20,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 
 	"github.com/samber/lo"
@@ -53,6 +54,9 @@ type InstanceTypeOptions struct {
 	Architecture     string              `json:"architecture"`
 	OperatingSystems []corev1.OSName     `json:"operatingSystems"`
 	Resources        corev1.ResourceList `json:"resources"`
+	// Devices are the number of devices of each DeviceClass that are attached to the instance type. KWOK nodes
+	// publish a ResourceSlice for each DeviceClass, using the DeviceClass name as the driver name.
+	Devices corev1.ResourceList `json:"devices,omitempty"`
 
 	// These are used for setting default requirements, they should not be used
 	// for setting arbitrary node labels.  Set the labels on the created NodePool for
@@ -135,7 +139,7 @@ func setDefaultOptions(opts InstanceTypeOptions) InstanceTypeOptions {
 
 	// make sure all the instance types are available
 	for i := range opts.Offerings {
-		opts.Offerings[i].Available = true
+		opts.Offerings[i].Available = math.MaxInt
 	}
 
 	return opts
@@ -182,6 +186,7 @@ func newInstanceType(options InstanceTypeOptions) *cloudprovider.InstanceType {
 			}
 		}),
 		Capacity: options.Resources,
+		Devices:  options.Devices,
 		Overhead: &cloudprovider.InstanceTypeOverhead{
 			KubeReserved: corev1.ResourceList{
 				corev1.ResourceCPU:    resource.MustParse("100m"),
@@ -0,0 +1,110 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kwok
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dcoppa/karpenter/kwok/apis/v1alpha1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+)
+
+// interruptionPollingPeriod is the period at which the nodes are inspected for injected interruption notices
+const interruptionPollingPeriod = 5 * time.Second
+
+// InterruptionNotices streams an interruption notice for every KwoK node that is annotated with an interruption deadline
+func (c CloudProvider) InterruptionNotices(ctx context.Context) (<-chan cloudprovider.InterruptionNotice, error) {
+	notices := make(chan cloudprovider.InterruptionNotice)
+	go func() {
+		defer close(notices)
+		// The providers ids of the nodes that were already notified
+		notified := map[string]struct{}{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.clock.After(interruptionPollingPeriod):
+			}
+			nodeList := &corev1.NodeList{}
+			if err := c.kubeClient.List(ctx, nodeList); err != nil {
+				log.FromContext(ctx).Error(err, "failed listing nodes for interruption notices")
+				continue
+			}
+			// Forget the nodes that no longer exist so that the set doesn't grow unbounded
+			providerIDs := sets.New(lo.Map(nodeList.Items, func(n corev1.Node, _ int) string { return n.Spec.ProviderID })...)
+			for providerID := range notified {
+				if !providerIDs.Has(providerID) {
+					delete(notified, providerID)
+				}
+			}
+			for i := range nodeList.Items {
+				node := &nodeList.Items[i]
+				if _, ok := notified[node.Spec.ProviderID]; ok || !strings.HasPrefix(node.Spec.ProviderID, kwokProviderPrefix) {
+					continue
+				}
+				notice, ok, err := interruptionNotice(node, c.clock.Now())
+				if err != nil {
+					log.FromContext(ctx).WithValues("Node", node.Name).Error(err, "failed parsing interruption notice")
+					continue
+				}
+				if !ok {
+					continue
+				}
+				select {
+				case notices <- notice:
+					notified[node.Spec.ProviderID] = struct{}{}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return notices, nil
+}
+
+// interruptionNotice returns the interruption notice that is injected into the node through its annotations
+func interruptionNotice(node *corev1.Node, now time.Time) (cloudprovider.InterruptionNotice, bool, error) {
+	value, ok := node.Annotations[v1alpha1.InterruptionDeadlineAnnotationKey]
+	if !ok {
+		return cloudprovider.InterruptionNotice{}, false, nil
+	}
+	notice := cloudprovider.InterruptionNotice{
+		ProviderID: node.Spec.ProviderID,
+		Reason:     node.Annotations[v1alpha1.InterruptionReasonAnnotationKey],
+	}
+	if notice.Reason == "" {
+		notice.Reason = "Injected"
+	}
+	if deadline, err := time.Parse(time.RFC3339, value); err == nil {
+		notice.Deadline = deadline
+		return notice, true, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return cloudprovider.InterruptionNotice{}, false, fmt.Errorf("parsing %s %q, expected an RFC3339 timestamp or a duration", v1alpha1.InterruptionDeadlineAnnotationKey, value)
+	}
+	notice.Deadline = now.Add(d)
+	return notice, true, nil
+}
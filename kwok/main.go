@@ -17,6 +17,9 @@ limitations under the License.
 package main
 
 import (
+	// The time zones of budget schedules need the time zone database even if the host doesn't have one
+	_ "time/tzdata"
+
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kwok "github.com/dcoppa/karpenter/kwok/cloudprovider"
@@ -31,7 +34,7 @@ func main() {
 		log.FromContext(ctx).Error(err, "failed constructing instance types")
 	}
 
-	cloudProvider := kwok.NewCloudProvider(ctx, op.GetClient(), instanceTypes)
+	cloudProvider := kwok.NewCloudProvider(ctx, op.Clock, op.GetClient(), instanceTypes)
 	op.
 		WithControllers(ctx, controllers.NewControllers(
 			ctx,
@@ -19,6 +19,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 
 	"github.com/samber/lo"
@@ -65,6 +66,33 @@ func priceFromResources(resources corev1.ResourceList) float64 {
 	return price
 }
 
+func priceFromDevices(devices corev1.ResourceList) float64 {
+	price := 0.0
+	for _, v := range devices {
+		price += 1.0 * v.AsApproximateFloat64()
+	}
+	return price
+}
+
+func makeOfferings(price float64) []kwok.KWOKOffering {
+	offerings := []kwok.KWOKOffering{}
+	for _, zone := range KwokZones {
+		for _, ct := range []string{v1.CapacityTypeSpot, v1.CapacityTypeOnDemand} {
+			offerings = append(offerings, kwok.KWOKOffering{
+				Requirements: []corev1.NodeSelectorRequirement{
+					corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{ct}},
+					corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
+				},
+				Offering: cloudprovider.Offering{
+					Price:     lo.Ternary(ct == v1.CapacityTypeSpot, price*.7, price),
+					Available: math.MaxInt,
+				},
+			})
+		}
+	}
+	return offerings
+}
+
 func constructGenericInstanceTypes() []kwok.InstanceTypeOptions {
 	var instanceTypesOptions []kwok.InstanceTypeOptions
 
@@ -87,23 +115,7 @@ func constructGenericInstanceTypes() []kwok.InstanceTypeOptions {
 							corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
 						},
 					}
-					price := priceFromResources(opts.Resources)
-
-					opts.Offerings = []kwok.KWOKOffering{}
-					for _, zone := range KwokZones {
-						for _, ct := range []string{v1.CapacityTypeSpot, v1.CapacityTypeOnDemand} {
-							opts.Offerings = append(opts.Offerings, kwok.KWOKOffering{
-								Requirements: []corev1.NodeSelectorRequirement{
-									corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{ct}},
-									corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{zone}},
-								},
-								Offering: cloudprovider.Offering{
-									Price:     lo.Ternary(ct == v1.CapacityTypeSpot, price*.7, price),
-									Available: true,
-								},
-							})
-						}
-					}
+					opts.Offerings = makeOfferings(priceFromResources(opts.Resources))
 					instanceTypesOptions = append(instanceTypesOptions, opts)
 				}
 			}
@@ -112,8 +124,35 @@ func constructGenericInstanceTypes() []kwok.InstanceTypeOptions {
 	return instanceTypesOptions
 }
 
+// constructDeviceInstanceTypes creates GPU instance types that have devices which can be allocated to pods through
+// ResourceClaims, so that Dynamic Resource Allocation can be tested without hardware
+func constructDeviceInstanceTypes() []kwok.InstanceTypeOptions {
+	var instanceTypesOptions []kwok.InstanceTypeOptions
+
+	for _, gpus := range []int{1, 2, 4, 8} {
+		cpu := gpus * 8
+		opts := kwok.InstanceTypeOptions{
+			Name:             fmt.Sprintf("g-%dx-%s-%s", cpu, v1.ArchitectureAmd64, corev1.Linux),
+			Architecture:     v1.ArchitectureAmd64,
+			OperatingSystems: []corev1.OSName{corev1.Linux},
+			Resources: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse(fmt.Sprintf("%d", cpu)),
+				corev1.ResourceMemory:           resource.MustParse(fmt.Sprintf("%dGi", cpu*8)),
+				corev1.ResourcePods:             resource.MustParse(fmt.Sprintf("%d", cpu*16)),
+				corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
+			},
+			Devices: corev1.ResourceList{
+				kwok.DeviceClassGPU: resource.MustParse(fmt.Sprintf("%d", gpus)),
+			},
+		}
+		opts.Offerings = makeOfferings(priceFromResources(opts.Resources) + priceFromDevices(opts.Devices))
+		instanceTypesOptions = append(instanceTypesOptions, opts)
+	}
+	return instanceTypesOptions
+}
+
 func main() {
-	opts := constructGenericInstanceTypes()
+	opts := append(constructGenericInstanceTypes(), constructDeviceInstanceTypes()...)
 	output, err := json.MarshalIndent(opts, "", "    ")
 	if err != nil {
 		fmt.Printf("could not marshal generated instance types to JSON: %v\n", err)
@@ -33,6 +33,7 @@ const (
 	ArchitectureArm64    = "arm64"
 	CapacityTypeSpot     = "spot"
 	CapacityTypeOnDemand = "on-demand"
+	CapacityTypeReserved = "reserved"
 )
 
 // Karpenter specific domains and labels
@@ -46,12 +47,24 @@ const (
 // Karpenter specific annotations
 const (
 	DoNotDisruptAnnotationKey                  = apis.Group + "/do-not-disrupt"
+	DisruptionDryRunAnnotationKey              = apis.Group + "/disruption-dry-run"
+	DisruptionCommandAnnotationKey             = apis.Group + "/disruption-command"
 	ProviderCompatibilityAnnotationKey         = apis.CompatibilityGroup + "/provider"
 	NodePoolHashAnnotationKey                  = apis.Group + "/nodepool-hash"
 	NodePoolHashVersionAnnotationKey           = apis.Group + "/nodepool-hash-version"
+	NodePoolReplacementHashAnnotationKey       = apis.Group + "/nodepool-replacement-hash"
+	NodeClaimTemplateTaintsAnnotationKey       = apis.Group + "/nodepool-template-taints"
+	NodeClaimTemplateLabelsAnnotationKey       = apis.Group + "/nodepool-template-labels"
 	NodeClaimTerminationTimestampAnnotationKey = apis.Group + "/nodeclaim-termination-timestamp"
 )
 
+// Karpenter specific pod conditions
+const (
+	// PodConditionTypeProvisionable is set to False on pending pods that Karpenter can't launch capacity for, with a
+	// message that explains why each NodePool rejected the pod
+	PodConditionTypeProvisionable v1.PodConditionType = apis.Group + "/Provisionable"
+)
+
 // Karpenter specific finalizers
 const (
 	TerminationFinalizer = apis.Group + "/termination"
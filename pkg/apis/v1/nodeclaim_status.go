@@ -57,6 +57,25 @@ type NodeClaimStatus struct {
 	// is also considered as removed.
 	// +optional
 	LastPodEventTime metav1.Time `json:"lastPodEventTime,omitempty"`
+	// RepairAttempts are the most recent attempts to repair the node, oldest first
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	RepairAttempts []RepairAttempt `json:"repairAttempts,omitempty"`
+}
+
+// MaxRepairAttempts is the number of repair attempts that are kept on the NodeClaim status
+const MaxRepairAttempts = 10
+
+// RepairAttempt records an attempt to repair an unhealthy node
+type RepairAttempt struct {
+	// Action that was taken to repair the node
+	Action RepairAction `json:"action"`
+	// ConditionType is the type of the node condition that marked the node as unhealthy
+	ConditionType v1.NodeConditionType `json:"conditionType"`
+	// ConditionStatus is the status of the node condition that marked the node as unhealthy
+	ConditionStatus v1.ConditionStatus `json:"conditionStatus"`
+	// Time is when the repair was attempted
+	Time metav1.Time `json:"time"`
 }
 
 func (in *NodeClaim) StatusConditions() status.ConditionSet {
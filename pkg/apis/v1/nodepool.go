@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/robfig/cron/v3"
@@ -35,6 +37,8 @@ import (
 // launch nodes in response to pods that are unschedulable. A single nodepool
 // is capable of managing a diverse set of nodes. Node properties are determined
 // from a combination of nodepool and pod scheduling constraints.
+// +kubebuilder:validation:XValidation:message="'headroom' can't be set on a static nodepool",rule="!has(self.replicas) || !has(self.headroom)"
+// +kubebuilder:validation:XValidation:message="'capacityFloors' can't be set on a static nodepool",rule="!has(self.replicas) || !has(self.capacityFloors)"
 type NodePoolSpec struct {
 	// Template contains the template of possibilities for the provisioning logic to launch a NodeClaim with.
 	// NodeClaims launched from this NodePool will often be further constrained than the template specifies.
@@ -47,6 +51,9 @@ type NodePoolSpec struct {
 	// Limits define a set of bounds for provisioning capacity.
 	// +optional
 	Limits Limits `json:"limits,omitempty"`
+	// Repair contains the parameters that relate to Karpenter's node repair logic
+	// +optional
+	Repair Repair `json:"repair,omitempty"`
 	// Weight is the priority given to the nodepool during scheduling. A higher
 	// numerical weight indicates that this nodepool will be ordered
 	// ahead of other nodepools with lower weights. A nodepool with no weight
@@ -55,8 +62,120 @@ type NodePoolSpec struct {
 	// +kubebuilder:validation:Maximum:=100
 	// +optional
 	Weight *int32 `json:"weight,omitempty"`
+	// BinPacking configures how the scheduler packs pods onto the NodeClaims that it launches for this NodePool
+	// +optional
+	BinPacking BinPacking `json:"binPacking,omitempty"`
+	// Headroom is spare capacity that Karpenter keeps available on the nodes of this NodePool, so that pods can
+	// schedule without waiting for a node to launch
+	// +optional
+	Headroom *Headroom `json:"headroom,omitempty"`
+	// Replicas makes the NodePool static. A static NodePool keeps exactly this many NodeClaims whether or not pods are
+	// pending, and doesn't launch NodeClaims for pending pods. Its NodeClaims aren't consolidated, but they're still
+	// disrupted for drift and expiration.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Replicas *int64 `json:"replicas,omitempty"`
+	// CapacityFloors are minimum amounts of resources that the nodes of this NodePool keep room for while the floors
+	// are active. Karpenter launches capacity ahead of demand to meet an active floor, and consolidation doesn't shrink
+	// the NodePool below it. If there are multiple active floors, Karpenter uses the largest value of each resource.
+	// +kubebuilder:validation:XValidation:message="'schedule' must be set with 'duration'",rule="self.all(x, has(x.schedule) == has(x.duration))"
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	CapacityFloors []CapacityFloor `json:"capacityFloors,omitempty"`
+}
+
+// CapacityFloor is a minimum amount of resources that the nodes of a NodePool keep room for while it is active. The
+// room includes the resources that are requested by the pods on the nodes, excluding daemonsets.
+type CapacityFloor struct {
+	// Resources are the minimum amount of each resource.
+	// +kubebuilder:validation:MinProperties:=1
+	// +required
+	Resources v1.ResourceList `json:"resources"`
+	// Schedule specifies when a capacity floor begins being active, following
+	// the upstream cronjob syntax. If omitted, the floor is always active.
+	// Timezones are not supported.
+	// This field is required if Duration is set.
+	// +kubebuilder:validation:Pattern:=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly))|((.+)\s(.+)\s(.+)\s(.+)\s(.+))$`
+	// +optional
+	Schedule *string `json:"schedule,omitempty"`
+	// Duration determines how long a capacity floor is active since each Schedule hit.
+	// Only minutes and hours are accepted, as cron does not work in seconds.
+	// If omitted, the floor is always active.
+	// This is required if Schedule is set.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// BinPacking configures how the scheduler packs pods onto the NodeClaims that it launches for a NodePool.
+// +kubebuilder:validation:XValidation:message="'maxPodsPerNodeClaim' must be set with the spread strategy",rule="!has(self.strategy) || self.strategy != 'spread' || has(self.maxPodsPerNodeClaim)"
+// +kubebuilder:validation:XValidation:message="'maxPodsPerNodeClaim' can only be set with the spread strategy",rule="!has(self.maxPodsPerNodeClaim) || (has(self.strategy) && self.strategy == 'spread')"
+type BinPacking struct {
+	// Strategy is the bin-packing strategy of the NodePool. The default strategy packs pods onto the in-flight
+	// NodeClaims with the fewest pods first, the spread strategy limits the number of pods per NodeClaim, and the
+	// binpack-dense strategy packs pods onto the in-flight NodeClaims with the least remaining room first.
+	// +kubebuilder:validation:Enum:={default,spread,binpack-dense}
+	// +kubebuilder:default:="default"
+	// +optional
+	Strategy BinPackingStrategy `json:"strategy,omitempty"`
+	// MaxPodsPerNodeClaim is the maximum number of pods that the spread strategy packs onto a NodeClaim that it
+	// launches. Pods that the scheduler places on existing nodes aren't limited.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	MaxPodsPerNodeClaim *int32 `json:"maxPodsPerNodeClaim,omitempty"`
+}
+
+// Headroom declares spare capacity as a number of virtual pods with a resource shape. Karpenter schedules the virtual
+// pods alongside the pending pods when it provisions, and consolidation only removes nodes if the virtual pods still
+// fit afterwards.
+// +kubebuilder:validation:XValidation:message="exactly one of 'pods' or 'percentage' must be set",rule="has(self.pods) != has(self.percentage)"
+type Headroom struct {
+	// Pods is the number of virtual pods that Karpenter keeps room for.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Pods *int32 `json:"pods,omitempty"`
+	// Percentage sizes the headroom as a percentage of the resources that are requested by the pods on the nodes of the
+	// NodePool, excluding daemonsets. Karpenter keeps room for as many virtual pods as are needed to cover the
+	// percentage of each resource of the virtual pods.
+	// +kubebuilder:validation:Pattern:="^[0-9]+%$"
+	// +optional
+	Percentage *string `json:"percentage,omitempty"`
+	// Resources are the resource requests of each virtual pod.
+	// +kubebuilder:validation:MinProperties:=1
+	// +required
+	Resources v1.ResourceList `json:"resources"`
+}
+
+// GetPods returns the number of virtual pods that Karpenter keeps room for, given the resources that are requested by
+// the pods on the nodes of the NodePool
+func (in *Headroom) GetPods(usage v1.ResourceList) (int, error) {
+	if in.Pods != nil {
+		return int(*in.Pods), nil
+	}
+	percentage, err := strconv.ParseFloat(strings.TrimSuffix(lo.FromPtr(in.Percentage), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing percentage %q, %w", lo.FromPtr(in.Percentage), err)
+	}
+	pods := 0
+	for resourceName, quantity := range in.Resources {
+		if quantity.IsZero() {
+			continue
+		}
+		used := usage[resourceName]
+		pods = lo.Max([]int{pods, int(math.Ceil(used.AsApproximateFloat64() * percentage / 100 / quantity.AsApproximateFloat64()))})
+	}
+	return pods, nil
 }
 
+type BinPackingStrategy string
+
+const (
+	BinPackingStrategyDefault      BinPackingStrategy = "default"
+	BinPackingStrategySpread       BinPackingStrategy = "spread"
+	BinPackingStrategyBinpackDense BinPackingStrategy = "binpack-dense"
+)
+
 type Disruption struct {
 	// ConsolidateAfter is the duration the controller will wait
 	// before attempting to terminate nodes that are underutilized.
@@ -72,11 +191,39 @@ type Disruption struct {
 	// +kubebuilder:validation:Enum:={WhenEmpty,WhenEmptyOrUnderutilized}
 	// +optional
 	ConsolidationPolicy ConsolidationPolicy `json:"consolidationPolicy,omitempty"`
+	// ConsolidationPriceThreshold is the minimum saving that a consolidation replacement must achieve over the price of the
+	// nodes that it replaces. It can either be a percentage of the price of the replaced nodes (e.g. "10%") or an absolute
+	// price in the same unit as the instance type offerings (e.g. "0.05"). Replacements that don't save more than this
+	// threshold are not launched. If left undefined, any replacement that is cheaper than the replaced nodes is launched.
+	// +kubebuilder:validation:Pattern:=`^((100(\.0+)?|[0-9]{1,2}(\.[0-9]+)?)%|[0-9]+(\.[0-9]+)?)$`
+	// +optional
+	ConsolidationPriceThreshold *string `json:"consolidationPriceThreshold,omitempty"`
+	// ExpirationGracePeriod enables graceful expiration. Expired nodes are disrupted through the Expired disruption method,
+	// which respects the disruption budgets and launches replacements before the nodes are drained. Nodes that are still
+	// running after the grace period, measured from when they expire, are deleted forcefully.
+	// If left undefined, expired nodes are deleted as soon as they expire.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	ExpirationGracePeriod *metav1.Duration `json:"expirationGracePeriod,omitempty"`
+	// MetadataUpdatePolicy describes how changes to the labels and taints of the template are applied to the existing
+	// nodes. With "Replace", the nodes are drifted and replaced. With "InPlace", the labels and taints are patched onto
+	// the existing nodes and nodeclaims, which aren't replaced unless other fields of the template change as well.
+	// This policy defaults to "Replace" if not specified
+	// +kubebuilder:default:="Replace"
+	// +kubebuilder:validation:Enum:={Replace,InPlace}
+	// +optional
+	MetadataUpdatePolicy MetadataUpdatePolicy `json:"metadataUpdatePolicy,omitempty"`
+	// RollingUpdate controls how many drifted nodes are replaced at once, in addition to the disruption budgets.
+	// If left undefined, drifted nodes are only limited by the disruption budgets.
+	// +optional
+	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
 	// Budgets is a list of Budgets.
 	// If there are multiple active budgets, Karpenter uses
 	// the most restrictive value. If left undefined,
 	// this will default to one budget with a value to 10%.
 	// +kubebuilder:validation:XValidation:message="'schedule' must be set with 'duration'",rule="self.all(x, has(x.schedule) == has(x.duration))"
+	// +kubebuilder:validation:XValidation:message="'timeZone' must be set with 'schedule'",rule="self.all(x, !has(x.timeZone) || has(x.schedule))"
 	// +kubebuilder:default:={{nodes: "10%"}}
 	// +kubebuilder:validation:MaxItems=50
 	// +optional
@@ -88,7 +235,7 @@ type Disruption struct {
 type Budget struct {
 	// Reasons is a list of disruption methods that this budget applies to. If Reasons is not set, this budget applies to all methods.
 	// Otherwise, this will apply to each reason defined.
-	// allowed reasons are Underutilized, Empty, and Drifted.
+	// allowed reasons are Underutilized, Empty, Drifted, and Expired.
 	// +optional
 	Reasons []DisruptionReason `json:"reasons,omitempty"`
 	// Nodes dictates the maximum number of NodeClaims owned by this NodePool
@@ -103,7 +250,7 @@ type Budget struct {
 	Nodes string `json:"nodes" hash:"ignore"`
 	// Schedule specifies when a budget begins being active, following
 	// the upstream cronjob syntax. If omitted, the budget is always active.
-	// Timezones are not supported.
+	// The schedule is evaluated in TimeZone, or in UTC if TimeZone is not set.
 	// This field is required if Duration is set.
 	// +kubebuilder:validation:Pattern:=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly))|((.+)\s(.+)\s(.+)\s(.+)\s(.+))$`
 	// +optional
@@ -118,23 +265,134 @@ type Budget struct {
 	// +kubebuilder:validation:Type="string"
 	// +optional
 	Duration *metav1.Duration `json:"duration,omitempty" hash:"ignore"`
+	// TimeZone is the IANA name of the time zone that the Schedule is evaluated in, e.g. "Europe/Rome".
+	// Schedule hits follow the wall clock of the time zone across daylight saving time transitions.
+	// If omitted, the schedule is evaluated in UTC.
+	// This field can only be set with Schedule.
+	// +kubebuilder:validation:XValidation:message="'timeZone' must be a valid IANA time zone",rule="timestamp('2000-01-01T00:00:00Z').getHours(self) >= 0"
+	// +kubebuilder:validation:MaxLength=255
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty" hash:"ignore"`
+}
+
+// RollingUpdate controls the rollout of the replacements for the drifted nodes of a NodePool.
+type RollingUpdate struct {
+	// MaxSurge is the maximum number of NodeClaims of the NodePool that can be launching at once, either as a number of
+	// nodes or as a percentage of the nodes of the NodePool. Karpenter doesn't launch replacements for drifted nodes
+	// beyond it. The value is rounded up to the nearest whole number. If left undefined, it isn't limited.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +optional
+	MaxSurge *string `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the maximum number of nodes of the NodePool that can be unavailable at once, either as a number
+	// of nodes or as a percentage of the nodes of the NodePool. Nodes are unavailable from when they are tainted for
+	// disruption until they are deleted, except while their replacements are launching. Karpenter doesn't disrupt drifted
+	// nodes beyond it, and a value of 0 only lets it replace drifted nodes within maxSurge. The value is rounded up to
+	// the nearest whole number. If left undefined, it isn't limited.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +optional
+	MaxUnavailable *string `json:"maxUnavailable,omitempty"`
+}
+
+// Repair configures how Karpenter repairs the unhealthy nodes of a NodePool.
+type Repair struct {
+	// Policies are merged with the repair policies of the cloud provider. A policy with the same condition type and
+	// status as a cloud provider policy overrides its toleration duration and action.
+	// +kubebuilder:validation:XValidation:message="policies must have unique conditionType and conditionStatus pairs",rule="self.all(x, self.exists_one(y, x.conditionType == y.conditionType && x.conditionStatus == y.conditionStatus))"
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Policies []RepairPolicy `json:"policies,omitempty"`
+	// MaxUnhealthy is the maximum number of nodes of the NodePool that can be unhealthy before Karpenter stops
+	// repairing them, either as a number of nodes or as a percentage of the nodes of the NodePool. The value is rounded
+	// up to the nearest whole number. If left undefined, up to 20% of the nodes can be unhealthy.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +optional
+	MaxUnhealthy *string `json:"maxUnhealthy,omitempty"`
+}
+
+// RepairPolicy defines a node condition that marks a node as unhealthy, and how long the condition is tolerated before
+// the node is repaired.
+type RepairPolicy struct {
+	// ConditionType is the type of the node condition, for example a condition reported by node-problem-detector.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	ConditionType v1.NodeConditionType `json:"conditionType"`
+	// ConditionStatus is the status of the node condition when the node is unhealthy.
+	// +kubebuilder:validation:Enum:={True,False,Unknown}
+	// +required
+	ConditionStatus v1.ConditionStatus `json:"conditionStatus"`
+	// TolerationDuration is the duration the controller will wait before force terminating a node that has the
+	// node condition.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +required
+	TolerationDuration metav1.Duration `json:"tolerationDuration"`
+	// Action is how the node is repaired. With "Reboot", the node is rebooted first and replaced if the condition
+	// persists for the toleration duration after the reboot. Nodes are rebooted only if the cloud provider supports it.
+	// This defaults to "Replace" if not specified.
+	// +kubebuilder:validation:Enum:={Reboot,Replace}
+	// +optional
+	Action RepairAction `json:"action,omitempty"`
+}
+
+type RepairAction string
+
+const (
+	RepairActionReboot  RepairAction = "Reboot"
+	RepairActionReplace RepairAction = "Replace"
+)
+
+// DefaultMaxUnhealthy is the maximum number of nodes of a NodePool that can be unhealthy before Karpenter stops
+// repairing them when the NodePool doesn't set it.
+const DefaultMaxUnhealthy = "20%"
+
+// GetMaxUnhealthy returns the maximum number of nodes of the NodePool that can be unhealthy out of the given number of
+// nodes before Karpenter stops repairing them.
+func (in *Repair) GetMaxUnhealthy(numNodes int) (int, error) {
+	return intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(GetIntStrFromValue(lo.FromPtrOr(in.MaxUnhealthy, DefaultMaxUnhealthy))), numNodes, true)
+}
+
+// GetMaxSurge returns the maximum number of NodeClaims of the NodePool that can be launching at once out of the given
+// number of nodes. This returns MAXINT if the value is unbounded.
+func (in *RollingUpdate) GetMaxSurge(numNodes int) (int, error) {
+	return getScaledValueOrMax(in.MaxSurge, numNodes)
+}
+
+// GetMaxUnavailable returns the maximum number of nodes of the NodePool that can be unavailable at once out of the
+// given number of nodes. This returns MAXINT if the value is unbounded.
+func (in *RollingUpdate) GetMaxUnavailable(numNodes int) (int, error) {
+	return getScaledValueOrMax(in.MaxUnavailable, numNodes)
+}
+
+func getScaledValueOrMax(value *string, numNodes int) (int, error) {
+	if value == nil {
+		return math.MaxInt32, nil
+	}
+	return intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(GetIntStrFromValue(*value)), numNodes, true)
 }
 
 type ConsolidationPolicy string
 
+type MetadataUpdatePolicy string
+
+const (
+	MetadataUpdatePolicyReplace MetadataUpdatePolicy = "Replace"
+	MetadataUpdatePolicyInPlace MetadataUpdatePolicy = "InPlace"
+)
+
 const (
 	ConsolidationPolicyWhenEmpty                ConsolidationPolicy = "WhenEmpty"
 	ConsolidationPolicyWhenEmptyOrUnderutilized ConsolidationPolicy = "WhenEmptyOrUnderutilized"
 )
 
 // DisruptionReason defines valid reasons for disruption budgets.
-// +kubebuilder:validation:Enum={Underutilized,Empty,Drifted}
+// +kubebuilder:validation:Enum={Underutilized,Empty,Drifted,Expired}
 type DisruptionReason string
 
 const (
 	DisruptionReasonUnderutilized DisruptionReason = "Underutilized"
 	DisruptionReasonEmpty         DisruptionReason = "Empty"
 	DisruptionReasonDrifted       DisruptionReason = "Drifted"
+	DisruptionReasonExpired       DisruptionReason = "Expired"
 )
 
 type Limits v1.ResourceList
@@ -272,7 +530,13 @@ type NodePool struct {
 // 1. A field changes its default value for an existing field that is already hashed
 // 2. A field is added to the hash calculation with an already-set value
 // 3. A field is removed from the hash calculations
-const NodePoolHashVersion = "v3"
+// 4. A hash is added to the hashes that are kept on the NodeClaims
+const NodePoolHashVersion = "v4"
+
+// IsStatic returns true if the NodePool keeps a fixed number of NodeClaims
+func (in *NodePool) IsStatic() bool {
+	return in.Spec.Replicas != nil
+}
 
 func (in *NodePool) Hash() string {
 	return fmt.Sprint(lo.Must(hashstructure.Hash(in.Spec.Template, hashstructure.FormatV2, &hashstructure.HashOptions{
@@ -282,6 +546,19 @@ func (in *NodePool) Hash() string {
 	})))
 }
 
+// ReplacementHash is the hash of the fields of the template that can only be applied to a node by replacing it, i.e. all
+// fields but the labels and the taints, which can be updated in place
+func (in *NodePool) ReplacementHash() string {
+	template := *in.Spec.Template.DeepCopy()
+	template.Labels = nil
+	template.Spec.Taints = nil
+	return fmt.Sprint(lo.Must(hashstructure.Hash(template, hashstructure.FormatV2, &hashstructure.HashOptions{
+		SlicesAsSets:    true,
+		IgnoreZeroValue: true,
+		ZeroNil:         true,
+	})))
+}
+
 // NodePoolList contains a list of NodePool
 // +kubebuilder:object:root=true
 type NodePoolList struct {
@@ -350,20 +627,118 @@ func (in *Budget) GetAllowedDisruptions(c clock.Clock, numNodes int) (int, error
 // If the last schedule hit is exactly the duration in the past, this means the
 // schedule is active, as any more schedule hits in between would only extend this
 // window. This ensures that any previous schedule hits for a schedule are considered.
+// The schedule is evaluated in the time zone of the budget, or in UTC if it isn't set.
 func (in *Budget) IsActive(c clock.Clock) (bool, error) {
-	if in.Schedule == nil && in.Duration == nil {
+	loc := time.UTC
+	if in.TimeZone != nil {
+		var err error
+		if loc, err = time.LoadLocation(*in.TimeZone); err != nil {
+			return false, fmt.Errorf("invalid time zone %q, %w", *in.TimeZone, err)
+		}
+	}
+	return isScheduleActive(c, in.Schedule, in.Duration, loc)
+}
+
+// IsActive takes a clock as input and returns if a capacity floor is active, with the same semantics as a budget.
+func (in *CapacityFloor) IsActive(c clock.Clock) (bool, error) {
+	return isScheduleActive(c, in.Schedule, in.Duration, time.UTC)
+}
+
+// GetCapacityFloor returns the largest value of each resource across the active capacity floors of the NodePool. It
+// returns an empty list if no floor is active.
+func (in *NodePool) GetCapacityFloor(c clock.Clock) (v1.ResourceList, error) {
+	floor := v1.ResourceList{}
+	for i := range in.Spec.CapacityFloors {
+		active, err := in.Spec.CapacityFloors[i].IsActive(c)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			continue
+		}
+		for name, quantity := range in.Spec.CapacityFloors[i].Resources {
+			if current, ok := floor[name]; !ok || quantity.Cmp(current) > 0 {
+				floor[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return floor, nil
+}
+
+// maxTimeZoneShift is larger than any shift of the wall clock of a time zone, e.g. for daylight saving time
+const maxTimeZoneShift = 3 * time.Hour
+
+// isScheduleActive returns if the last hit of the schedule in the location is less than the duration in the past.
+//
+// The cron package walks the wall clock of the location hour by hour, so it never hits a time that is skipped when the
+// clocks go forward. Instead, we walk the schedule on the wall clock as if it were UTC, and then convert each hit back
+// to an instant in the location. A hit that is skipped when the clocks go forward happens at the transition, and a
+// hit that is repeated when the clocks go back happens once, at its first occurrence.
+func isScheduleActive(c clock.Clock, cronSchedule *string, duration *metav1.Duration, loc *time.Location) (bool, error) {
+	if cronSchedule == nil && duration == nil {
 		return true, nil
 	}
-	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", lo.FromPtr(in.Schedule)))
+	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", lo.FromPtr(cronSchedule)))
 	if err != nil {
 		// Should only occur if there's a discrepancy
 		// with the validation regex and the cron package.
-		return false, fmt.Errorf("invariant violated, invalid cron %s", schedule)
+		return false, fmt.Errorf("invariant violated, invalid cron %s", lo.FromPtr(cronSchedule))
 	}
+	now := c.Now()
 	// Walk back in time for the duration associated with the schedule
-	checkPoint := c.Now().UTC().Add(-lo.FromPtr(in.Duration).Duration)
-	nextHit := schedule.Next(checkPoint)
-	return !nextHit.After(c.Now().UTC()), nil
+	checkPoint := now.Add(-lo.FromPtr(duration).Duration)
+	// The wall clock can be behind the checkpoint after the clocks go back, so we start walking the schedule earlier
+	// and skip the hits before the checkpoint
+	for hit := schedule.Next(wallClock(checkPoint, loc).Add(-maxTimeZoneShift)); !hit.IsZero(); hit = schedule.Next(hit) {
+		if instant := inLocation(hit, loc); instant.After(checkPoint) {
+			return !instant.After(now), nil
+		}
+	}
+	return false, nil
+}
+
+// wallClock returns the wall clock time of t in the location, as a time in UTC
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// inLocation returns the instant that the wall clock of the location shows the wall clock time t, which is in UTC.
+// If the wall clock skips t, this returns the instant that the wall clock skips it. If the wall clock shows t twice,
+// this returns the first instant.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	instant := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	// If the wall clock skips t, the instant is normalized with the offset of either side of the transition
+	start, end := instant.ZoneBounds()
+	switch wall := wallClock(instant, loc); {
+	case wall.Before(t):
+		return end
+	case wall.After(t):
+		return start
+	default:
+		return instant
+	}
+}
+
+// GetConsolidationMaxPrice returns the maximum price that a replacement for nodes with the given price can have so that
+// the replacement saves more than the ConsolidationPriceThreshold. If no threshold is set, this returns the price itself.
+func (in *Disruption) GetConsolidationMaxPrice(price float64) (float64, error) {
+	if in.ConsolidationPriceThreshold == nil {
+		return price, nil
+	}
+	threshold := *in.ConsolidationPriceThreshold
+	if percent, ok := strings.CutSuffix(threshold, "%"); ok {
+		val, err := strconv.ParseFloat(percent, 64)
+		if err != nil || val < 0 || val > 100 {
+			return 0, fmt.Errorf("invalid consolidation price threshold %q", threshold)
+		}
+		return price * (1 - val/100), nil
+	}
+	val, err := strconv.ParseFloat(threshold, 64)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("invalid consolidation price threshold %q", threshold)
+	}
+	return price - val, nil
 }
 
 func GetIntStrFromValue(str string) intstr.IntOrString {
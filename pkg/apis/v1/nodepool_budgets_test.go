@@ -264,5 +264,99 @@ var _ = Describe("Budgets", func() {
 			Expect(err).To(Succeed())
 			Expect(active).ToNot(BeTrue())
 		})
+		Context("TimeZone", func() {
+			var newYork *time.Location
+
+			BeforeEach(func() {
+				newYork = lo.Must(time.LoadLocation("America/New_York"))
+				budgets[0].TimeZone = lo.ToPtr("America/New_York")
+			})
+			It("should consider the schedule in the time zone of the budget", func() {
+				// 09:00-17:00 in New York is 14:00-22:00 UTC in the winter
+				budgets[0].Schedule = lo.ToPtr("0 9 * * *")
+				budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))})
+				fakeClock.SetTime(time.Date(2024, time.January, 8, 13, 30, 0, 0, time.UTC))
+				active, err := budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeFalse())
+
+				fakeClock.SetTime(time.Date(2024, time.January, 8, 21, 30, 0, 0, time.UTC))
+				active, err = budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeTrue())
+			})
+			It("should follow the wall clock of the time zone across daylight saving time", func() {
+				// 09:00-17:00 in New York is 13:00-21:00 UTC in the summer
+				budgets[0].Schedule = lo.ToPtr("0 9 * * *")
+				budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))})
+				fakeClock.SetTime(time.Date(2024, time.July, 8, 13, 30, 0, 0, time.UTC))
+				active, err := budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeTrue())
+
+				fakeClock.SetTime(time.Date(2024, time.July, 8, 21, 30, 0, 0, time.UTC))
+				active, err = budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeFalse())
+			})
+			It("should start a schedule hit that is skipped when the clocks go forward at the transition", func() {
+				// The clocks go forward from 02:00 to 03:00 on March 10th 2024 in New York, so 02:30 never happens
+				budgets[0].Schedule = lo.ToPtr("30 2 * * *")
+				budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("1h"))})
+				fakeClock.SetTime(time.Date(2024, time.March, 10, 3, 30, 0, 0, newYork))
+				active, err := budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeTrue())
+
+				fakeClock.SetTime(time.Date(2024, time.March, 10, 4, 30, 0, 0, newYork))
+				active, err = budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeFalse())
+			})
+			It("should only hit a schedule that is repeated when the clocks go back once", func() {
+				// The clocks go back from 02:00 to 01:00 on November 3rd 2024 in New York, so 01:30 happens twice
+				budgets[0].Schedule = lo.ToPtr("30 1 * * *")
+				budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("30m"))})
+				// 01:45 before the clocks go back
+				fakeClock.SetTime(time.Date(2024, time.November, 3, 5, 45, 0, 0, time.UTC))
+				active, err := budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeTrue())
+
+				// 01:45 after the clocks go back
+				fakeClock.SetTime(time.Date(2024, time.November, 3, 6, 45, 0, 0, time.UTC))
+				active, err = budgets[0].IsActive(fakeClock)
+				Expect(err).To(Succeed())
+				Expect(active).To(BeFalse())
+			})
+			It("should return an error for an unknown time zone", func() {
+				budgets[0].TimeZone = lo.ToPtr("Mars/Olympus_Mons")
+				_, err := budgets[0].IsActive(fakeClock)
+				Expect(err).To(HaveOccurred())
+				val, err := budgets[0].GetAllowedDisruptions(fakeClock, 100)
+				Expect(err).To(HaveOccurred())
+				Expect(val).To(BeNumerically("==", 0))
+			})
+		})
+	})
+})
+
+var _ = Describe("ConsolidationPriceThreshold", func() {
+	It("should return the price when no threshold is set", func() {
+		disruption := Disruption{}
+		Expect(disruption.GetConsolidationMaxPrice(1.0)).To(BeNumerically("~", 1.0))
+	})
+	It("should subtract a relative threshold", func() {
+		disruption := Disruption{ConsolidationPriceThreshold: lo.ToPtr("10%")}
+		Expect(disruption.GetConsolidationMaxPrice(2.0)).To(BeNumerically("~", 1.8))
+	})
+	It("should subtract an absolute threshold", func() {
+		disruption := Disruption{ConsolidationPriceThreshold: lo.ToPtr("0.25")}
+		Expect(disruption.GetConsolidationMaxPrice(2.0)).To(BeNumerically("~", 1.75))
+	})
+	It("should return an error for an invalid threshold", func() {
+		disruption := Disruption{ConsolidationPriceThreshold: lo.ToPtr("150%")}
+		_, err := disruption.GetConsolidationMaxPrice(2.0)
+		Expect(err).To(HaveOccurred())
 	})
 })
@@ -0,0 +1,87 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	. "github.com/dcoppa/karpenter/pkg/apis/v1"
+)
+
+var _ = Describe("CapacityFloors", func() {
+	var nodePool *NodePool
+	var fakeClock *clock.FakeClock
+
+	BeforeEach(func() {
+		// Thursday, June 15th 2000 at 12:30:30 UTC
+		fakeClock = clock.NewFakeClock(time.Date(2000, time.June, 15, 12, 30, 30, 0, time.UTC))
+		nodePool = &NodePool{
+			Spec: NodePoolSpec{
+				CapacityFloors: []CapacityFloor{
+					{
+						// 07:45-18:00 on weekdays
+						Schedule:  lo.ToPtr("45 7 * * 1-5"),
+						Duration:  &metav1.Duration{Duration: 10*time.Hour + 15*time.Minute},
+						Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("40")},
+					},
+					{
+						Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("16Gi")},
+					},
+				},
+			},
+		}
+	})
+	It("should use the largest value of each resource across the active floors", func() {
+		floor, err := nodePool.GetCapacityFloor(fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(floor.Cpu().Value()).To(BeNumerically("==", 40))
+		Expect(floor.Memory().Value()).To(BeNumerically("==", 16*1024*1024*1024))
+	})
+	It("should ignore floors outside of their schedule", func() {
+		// Thursday at 18:30 is after the end of the window
+		fakeClock.SetTime(time.Date(2000, time.June, 15, 18, 30, 0, 0, time.UTC))
+		floor, err := nodePool.GetCapacityFloor(fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(floor.Cpu().Value()).To(BeNumerically("==", 4))
+
+		// Saturday isn't a weekday
+		fakeClock.SetTime(time.Date(2000, time.June, 17, 12, 0, 0, 0, time.UTC))
+		floor, err = nodePool.GetCapacityFloor(fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(floor.Cpu().Value()).To(BeNumerically("==", 4))
+	})
+	It("should return an empty floor when no floor is active", func() {
+		nodePool.Spec.CapacityFloors = nodePool.Spec.CapacityFloors[:1]
+		fakeClock.SetTime(time.Date(2000, time.June, 15, 7, 0, 0, 0, time.UTC))
+		floor, err := nodePool.GetCapacityFloor(fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(floor).To(BeEmpty())
+	})
+	It("should return an error for an invalid schedule", func() {
+		nodePool.Spec.CapacityFloors[0].Schedule = lo.ToPtr("invalid")
+		_, err := nodePool.GetCapacityFloor(fakeClock)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -34,11 +34,26 @@ type NodePoolStatus struct {
 	// Resources is the list of resources that have been provisioned.
 	// +optional
 	Resources v1.ResourceList `json:"resources,omitempty"`
+	// Drift reports the progress of the replacement of the drifted nodes of the NodePool.
+	// +optional
+	Drift *DriftStatus `json:"drift,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
 }
 
+// DriftStatus reports the progress of a drift rollout. A rollout starts when nodes of the NodePool drift while none of
+// its nodes are drifted, and its counts are kept once it completes until the next rollout starts.
+type DriftStatus struct {
+	// Drifted is the number of nodes that drifted during the rollout.
+	Drifted int64 `json:"drifted"`
+	// Replaced is the number of nodes that drifted during the rollout and that are no longer drifted, usually because
+	// they were replaced.
+	Replaced int64 `json:"replaced"`
+	// Remaining is the number of nodes that are still drifted.
+	Remaining int64 `json:"remaining"`
+}
+
 func (in *NodePool) StatusConditions() status.ConditionSet {
 	return status.NewReadyConditions(
 		ConditionTypeValidationSucceeded,
@@ -18,14 +18,152 @@ package v1
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/robfig/cron/v3"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // RuntimeValidate will be used to validate any part of the CRD that can not be validated at CRD creation
 func (in *NodePool) RuntimeValidate() (errs error) {
-	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist())
+	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist(), in.Spec.Repair.validate(), in.Spec.BinPacking.validate(), in.Spec.Headroom.validate(), in.Spec.validateReplicas())
+	errs = multierr.Append(errs, in.Spec.Disruption.RollingUpdate.validate())
+	for i := range in.Spec.Disruption.Budgets {
+		errs = multierr.Append(errs, in.Spec.Disruption.Budgets[i].validate())
+	}
+	for i := range in.Spec.CapacityFloors {
+		errs = multierr.Append(errs, in.Spec.CapacityFloors[i].validate())
+	}
+	return errs
+}
+
+func (in *RollingUpdate) validate() (errs error) {
+	if in == nil {
+		return nil
+	}
+	if _, err := in.GetMaxSurge(100); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid maxSurge %q, %w", lo.FromPtr(in.MaxSurge), err))
+	}
+	if _, err := in.GetMaxUnavailable(100); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid maxUnavailable %q, %w", lo.FromPtr(in.MaxUnavailable), err))
+	}
+	return errs
+}
+
+func (in *Budget) validate() (errs error) {
+	if in.TimeZone == nil {
+		return nil
+	}
+	if in.Schedule == nil {
+		errs = multierr.Append(errs, fmt.Errorf("budget timeZone must be set with schedule"))
+	}
+	if _, err := time.LoadLocation(*in.TimeZone); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid budget timeZone %q, %w", *in.TimeZone, err))
+	}
+	return errs
+}
+
+func (in *Repair) validate() (errs error) {
+	seen := map[string]struct{}{}
+	for _, policy := range in.Policies {
+		if policy.ConditionType == "" {
+			errs = multierr.Append(errs, fmt.Errorf("invalid repair policy, conditionType must be set"))
+		}
+		if !lo.Contains([]corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown}, policy.ConditionStatus) {
+			errs = multierr.Append(errs, fmt.Errorf("invalid conditionStatus %q in repair policy for %q", policy.ConditionStatus, policy.ConditionType))
+		}
+		if !lo.Contains([]RepairAction{"", RepairActionReboot, RepairActionReplace}, policy.Action) {
+			errs = multierr.Append(errs, fmt.Errorf("invalid action %q in repair policy for %q", policy.Action, policy.ConditionType))
+		}
+		if policy.TolerationDuration.Duration < 0 {
+			errs = multierr.Append(errs, fmt.Errorf("invalid tolerationDuration %s in repair policy for %q, must not be negative", policy.TolerationDuration.Duration, policy.ConditionType))
+		}
+		key := fmt.Sprintf("%s=%s", policy.ConditionType, policy.ConditionStatus)
+		if _, ok := seen[key]; ok {
+			errs = multierr.Append(errs, fmt.Errorf("duplicate repair policy for %s", key))
+		}
+		seen[key] = struct{}{}
+	}
+	if _, err := in.GetMaxUnhealthy(100); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid maxUnhealthy %q, %w", lo.FromPtr(in.MaxUnhealthy), err))
+	}
+	return errs
+}
+
+func (in *BinPacking) validate() (errs error) {
+	if !lo.Contains([]BinPackingStrategy{"", BinPackingStrategyDefault, BinPackingStrategySpread, BinPackingStrategyBinpackDense}, in.Strategy) {
+		errs = multierr.Append(errs, fmt.Errorf("invalid bin-packing strategy %q", in.Strategy))
+	}
+	if in.Strategy == BinPackingStrategySpread && in.MaxPodsPerNodeClaim == nil {
+		errs = multierr.Append(errs, fmt.Errorf("maxPodsPerNodeClaim must be set with the %s strategy", BinPackingStrategySpread))
+	}
+	if in.Strategy != BinPackingStrategySpread && in.MaxPodsPerNodeClaim != nil {
+		errs = multierr.Append(errs, fmt.Errorf("maxPodsPerNodeClaim can only be set with the %s strategy", BinPackingStrategySpread))
+	}
+	if in.MaxPodsPerNodeClaim != nil && *in.MaxPodsPerNodeClaim < 1 {
+		errs = multierr.Append(errs, fmt.Errorf("invalid maxPodsPerNodeClaim %d, must be at least 1", *in.MaxPodsPerNodeClaim))
+	}
+	return errs
+}
+
+func (in *NodePoolSpec) validateReplicas() (errs error) {
+	if in.Replicas == nil {
+		return nil
+	}
+	if *in.Replicas < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("invalid replicas %d, must not be negative", *in.Replicas))
+	}
+	if in.Headroom != nil {
+		errs = multierr.Append(errs, fmt.Errorf("headroom can't be set on a static nodepool"))
+	}
+	if len(in.CapacityFloors) > 0 {
+		errs = multierr.Append(errs, fmt.Errorf("capacityFloors can't be set on a static nodepool"))
+	}
+	return errs
+}
+
+func (in *CapacityFloor) validate() (errs error) {
+	if len(in.Resources) == 0 {
+		errs = multierr.Append(errs, fmt.Errorf("capacity floor resources must be set"))
+	}
+	for name, quantity := range in.Resources {
+		if quantity.Sign() < 0 {
+			errs = multierr.Append(errs, fmt.Errorf("invalid capacity floor %s %s, must not be negative", name, quantity.String()))
+		}
+	}
+	if (in.Schedule == nil) != (in.Duration == nil) {
+		errs = multierr.Append(errs, fmt.Errorf("capacity floor schedule must be set with duration"))
+	}
+	if in.Schedule != nil {
+		if _, err := cron.ParseStandard(*in.Schedule); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("invalid capacity floor schedule %q, %w", *in.Schedule, err))
+		}
+	}
+	return errs
+}
+
+func (in *Headroom) validate() (errs error) {
+	if in == nil {
+		return nil
+	}
+	if (in.Pods == nil) == (in.Percentage == nil) {
+		errs = multierr.Append(errs, fmt.Errorf("exactly one of pods or percentage must be set in headroom"))
+	}
+	if in.Pods != nil && *in.Pods < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("invalid headroom pods %d, must not be negative", *in.Pods))
+	}
+	if in.Percentage != nil {
+		if _, err := in.GetPods(nil); err != nil || !strings.HasSuffix(*in.Percentage, "%") || strings.HasPrefix(*in.Percentage, "-") {
+			errs = multierr.Append(errs, fmt.Errorf("invalid headroom percentage %q", *in.Percentage))
+		}
+	}
+	if len(in.Resources) == 0 {
+		errs = multierr.Append(errs, fmt.Errorf("headroom resources must be set"))
+	}
 	return errs
 }
 
@@ -27,6 +27,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -76,6 +77,18 @@ var _ = Describe("CEL/Validation", func() {
 			nodePool.Spec.Template.Spec.ExpireAfter = MustParseNillableDuration("30s")
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed on a valid rollingUpdate", func() {
+			nodePool.Spec.Disruption.RollingUpdate = &RollingUpdate{MaxSurge: lo.ToPtr("25%"), MaxUnavailable: lo.ToPtr("1")}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail on an invalid rollingUpdate", func() {
+			nodePool.Spec.Disruption.RollingUpdate = &RollingUpdate{MaxSurge: lo.ToPtr("abc")}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+			nodePool.Spec.Disruption.RollingUpdate = &RollingUpdate{MaxUnavailable: lo.ToPtr("101%")}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
 		It("should fail on negative consolidateAfter", func() {
 			nodePool.Spec.Disruption.ConsolidateAfter = MustParseNillableDuration("-1s")
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
@@ -109,6 +122,34 @@ var _ = Describe("CEL/Validation", func() {
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed when setting metadataUpdatePolicy=InPlace", func() {
+			nodePool.Spec.Disruption.MetadataUpdatePolicy = MetadataUpdatePolicyInPlace
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an invalid metadataUpdatePolicy", func() {
+			nodePool.Spec.Disruption.MetadataUpdatePolicy = "Patch"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		DescribeTable("should succeed when setting a valid consolidationPriceThreshold", func(threshold string) {
+			nodePool.Spec.Disruption.ConsolidationPriceThreshold = lo.ToPtr(threshold)
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		},
+			Entry("percentage", "10%"),
+			Entry("fractional percentage", "2.5%"),
+			Entry("100 percent", "100%"),
+			Entry("absolute price", "0.05"),
+			Entry("integer absolute price", "1"),
+		)
+		DescribeTable("should fail when setting an invalid consolidationPriceThreshold", func(threshold string) {
+			nodePool.Spec.Disruption.ConsolidationPriceThreshold = lo.ToPtr(threshold)
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		},
+			Entry("negative percentage", "-10%"),
+			Entry("percentage with more than 3 digits", "1000%"),
+			Entry("percentage over 100", "100.5%"),
+			Entry("negative absolute price", "-0.05"),
+			Entry("non-numeric value", "cheap"),
+		)
 		It("should fail when creating a budget with an invalid cron", func() {
 			nodePool.Spec.Disruption.Budgets = []Budget{{
 				Nodes:    "10",
@@ -246,6 +287,7 @@ var _ = Describe("CEL/Validation", func() {
 			Entry("should allow disruption reason Drifted", DisruptionReasonDrifted),
 			Entry("should allow disruption reason Underutilized", DisruptionReasonUnderutilized),
 			Entry("should allow disruption reason Empty", DisruptionReasonEmpty),
+			Entry("should allow disruption reason Expired", DisruptionReasonExpired),
 		)
 
 		DescribeTable("should fail when creating a budget with invalid reasons", func(reason string) {
@@ -271,6 +313,34 @@ var _ = Describe("CEL/Validation", func() {
 			}}
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed when creating a budget with a time zone", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:    "10",
+				Schedule: lo.ToPtr("0 9 * * 1-5"),
+				Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))},
+				TimeZone: lo.ToPtr("America/New_York"),
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail when creating a budget with an unknown time zone", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:    "10",
+				Schedule: lo.ToPtr("0 9 * * 1-5"),
+				Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))},
+				TimeZone: lo.ToPtr("Mars/Olympus_Mons"),
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail when creating a budget with a time zone and no schedule", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:    "10",
+				TimeZone: lo.ToPtr("America/New_York"),
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
 	})
 	Context("Taints", func() {
 		It("should succeed for valid taints", func() {
@@ -620,6 +690,162 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
 		})
 	})
+	Context("Repair", func() {
+		It("should succeed with valid repair policies", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionUnknown, TolerationDuration: metav1.Duration{Duration: time.Hour}, Action: RepairActionReboot},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail with duplicate repair policies", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: time.Hour}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail with an invalid condition status", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: "Maybe", TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail with an invalid action", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}, Action: "Restart"},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail with an empty condition type", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		It("should fail with a negative toleration duration", func() {
+			nodePool.Spec.Repair.Policies = []RepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: -10 * time.Minute}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+		DescribeTable("should validate maxUnhealthy",
+			func(maxUnhealthy string, valid bool) {
+				nodePool.Spec.Repair.MaxUnhealthy = lo.ToPtr(maxUnhealthy)
+				if valid {
+					Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+				} else {
+					Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+				}
+			},
+			Entry("percentage", "30%", true),
+			Entry("count", "5", true),
+			Entry("zero", "0", true),
+			Entry("over 100 percent", "101%", false),
+			Entry("negative", "-1", false),
+			Entry("fraction", "1.5", false),
+		)
+	})
+	Context("CapacityFloors", func() {
+		DescribeTable("should validate the capacity floors",
+			func(floor CapacityFloor, valid bool) {
+				nodePool.Spec.CapacityFloors = []CapacityFloor{floor}
+				if valid {
+					Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+					Expect(nodePool.RuntimeValidate()).To(Succeed())
+				} else {
+					Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+					Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+				}
+			},
+			Entry("always active", CapacityFloor{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("40")}}, true),
+			Entry("scheduled", CapacityFloor{Schedule: lo.ToPtr("45 7 * * 1-5"), Duration: &metav1.Duration{Duration: 10*time.Hour + 15*time.Minute}, Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("40")}}, true),
+			Entry("schedule without duration", CapacityFloor{Schedule: lo.ToPtr("45 7 * * 1-5"), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("40")}}, false),
+			Entry("duration without schedule", CapacityFloor{Duration: &metav1.Duration{Duration: time.Hour}, Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("40")}}, false),
+			Entry("no resources", CapacityFloor{}, false),
+		)
+		It("should fail when capacity floors are set on a static nodepool", func() {
+			nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+			nodePool.Spec.CapacityFloors = []CapacityFloor{{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("40")}}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
+	})
+	Context("Headroom", func() {
+		DescribeTable("should validate the headroom",
+			func(headroom *Headroom, valid bool) {
+				nodePool.Spec.Headroom = headroom
+				if valid {
+					Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+					Expect(nodePool.RuntimeValidate()).To(Succeed())
+				} else {
+					Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+					Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+				}
+			},
+			Entry("pods", &Headroom{Pods: lo.ToPtr(int32(2)), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, true),
+			Entry("percentage", &Headroom{Percentage: lo.ToPtr("20%"), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, true),
+			Entry("pods and percentage", &Headroom{Pods: lo.ToPtr(int32(2)), Percentage: lo.ToPtr("20%"), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, false),
+			Entry("neither pods nor percentage", &Headroom{Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, false),
+			Entry("negative pods", &Headroom{Pods: lo.ToPtr(int32(-1)), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, false),
+			Entry("invalid percentage", &Headroom{Percentage: lo.ToPtr("20"), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, false),
+			Entry("no resources", &Headroom{Pods: lo.ToPtr(int32(2))}, false),
+		)
+		It("should compute the number of pods from a percentage of the usage", func() {
+			headroom := &Headroom{Percentage: lo.ToPtr("25%"), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}}
+			pods, err := headroom.GetPods(v1.ResourceList{v1.ResourceCPU: resource.MustParse("10"), v1.ResourceMemory: resource.MustParse("20Gi")})
+			Expect(err).ToNot(HaveOccurred())
+			// 25% of 20Gi of memory needs 5 pods, which is more than the 3 pods that 25% of 10 cpus needs
+			Expect(pods).To(Equal(5))
+		})
+	})
+	Context("Replicas", func() {
+		DescribeTable("should validate the replicas",
+			func(replicas *int64, headroom *Headroom, valid bool) {
+				nodePool.Spec.Replicas = replicas
+				nodePool.Spec.Headroom = headroom
+				if valid {
+					Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+					Expect(nodePool.RuntimeValidate()).To(Succeed())
+				} else {
+					Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+					Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+				}
+			},
+			Entry("unset", nil, nil, true),
+			Entry("zero", lo.ToPtr(int64(0)), nil, true),
+			Entry("positive", lo.ToPtr(int64(3)), nil, true),
+			Entry("negative", lo.ToPtr(int64(-1)), nil, false),
+			Entry("with headroom", lo.ToPtr(int64(3)), &Headroom{Pods: lo.ToPtr(int32(2)), Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}, false),
+		)
+	})
+	Context("BinPacking", func() {
+		DescribeTable("should validate the strategy",
+			func(strategy BinPackingStrategy, maxPods *int32, valid bool) {
+				nodePool.Spec.BinPacking = BinPacking{Strategy: strategy, MaxPodsPerNodeClaim: maxPods}
+				if valid {
+					Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+					Expect(nodePool.RuntimeValidate()).To(Succeed())
+				} else {
+					Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+					Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+				}
+			},
+			Entry("default", BinPackingStrategyDefault, nil, true),
+			Entry("spread", BinPackingStrategySpread, lo.ToPtr(int32(2)), true),
+			Entry("binpack-dense", BinPackingStrategyBinpackDense, nil, true),
+			Entry("unknown strategy", BinPackingStrategy("unknown"), nil, false),
+			Entry("spread without maxPodsPerNodeClaim", BinPackingStrategySpread, nil, false),
+			Entry("spread with zero maxPodsPerNodeClaim", BinPackingStrategySpread, lo.ToPtr(int32(0)), false),
+			Entry("maxPodsPerNodeClaim without spread", BinPackingStrategyBinpackDense, lo.ToPtr(int32(2)), false),
+		)
+	})
 	Context("NodeClassRef", func() {
 		It("should fail to mutate group", func() {
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
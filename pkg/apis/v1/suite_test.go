@@ -19,6 +19,8 @@ package v1_test
 import (
 	"context"
 	"testing"
+	// The time zones of budget schedules need the time zone database even if the host doesn't have one
+	_ "time/tzdata"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -57,6 +57,9 @@ type CloudProvider struct {
 	DeleteCalls        []*v1.NodeClaim
 	GetCalls           []string
 
+	// InsufficientCapacityOfferings are the offerings that fail to launch with an InsufficientCapacityError
+	InsufficientCapacityOfferings sets.Set[cloudprovider.OfferingKey]
+
 	CreatedNodeClaims         map[string]*v1.NodeClaim
 	Drifted                   cloudprovider.DriftReason
 	NodeClassGroupVersionKind []schema.GroupVersionKind
@@ -65,10 +68,11 @@ type CloudProvider struct {
 
 func NewCloudProvider() *CloudProvider {
 	return &CloudProvider{
-		AllowedCreateCalls:       math.MaxInt,
-		CreatedNodeClaims:        map[string]*v1.NodeClaim{},
-		InstanceTypesForNodePool: map[string][]*cloudprovider.InstanceType{},
-		ErrorsForNodePool:        map[string]error{},
+		AllowedCreateCalls:            math.MaxInt,
+		CreatedNodeClaims:             map[string]*v1.NodeClaim{},
+		InstanceTypesForNodePool:      map[string][]*cloudprovider.InstanceType{},
+		ErrorsForNodePool:             map[string]error{},
+		InsufficientCapacityOfferings: sets.New[cloudprovider.OfferingKey](),
 	}
 }
 
@@ -85,6 +89,7 @@ func (c *CloudProvider) Reset() {
 	c.NextCreateErr = nil
 	c.NextDeleteErr = nil
 	c.NextGetErr = nil
+	c.InsufficientCapacityOfferings = sets.New[cloudprovider.OfferingKey]()
 	c.DeleteCalls = []*v1.NodeClaim{}
 	c.GetCalls = nil
 	c.Drifted = "drifted"
@@ -147,6 +152,13 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v
 			break
 		}
 	}
+	if key := (cloudprovider.OfferingKey{
+		InstanceType: instanceType.Name,
+		Zone:         labels[corev1.LabelTopologyZone],
+		CapacityType: labels[v1.CapacityTypeLabelKey],
+	}); c.InsufficientCapacityOfferings.Has(key) {
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("offering has no capacity left"), key)
+	}
 	created := &v1.NodeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        nodeClaim.Name,
@@ -224,6 +236,12 @@ func (c *CloudProvider) GetInstanceTypes(_ context.Context, np *v1.NodePool) ([]
 				ResourceGPUVendorB: resource.MustParse("2"),
 			},
 		}),
+		NewInstanceType(InstanceTypeOptions{
+			Name: "gpu-device-instance-type",
+			Devices: map[corev1.ResourceName]resource.Quantity{
+				DeviceClassGPU: resource.MustParse("2"),
+			},
+		}),
 		NewInstanceType(InstanceTypeOptions{
 			Name:             "arm-instance-type",
 			Architecture:     "arm64",
@@ -18,6 +18,7 @@ package fake
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/samber/lo"
@@ -36,6 +37,7 @@ const (
 	IntegerInstanceLabelKey                     = "integer"
 	ResourceGPUVendorA      corev1.ResourceName = "fake.com/vendor-a"
 	ResourceGPUVendorB      corev1.ResourceName = "fake.com/vendor-b"
+	DeviceClassGPU          corev1.ResourceName = "gpu.fake.com"
 )
 
 func init() {
@@ -68,23 +70,23 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 			{Requirements: scheduling.NewLabelRequirements(map[string]string{
 				v1.CapacityTypeLabelKey:  "spot",
 				corev1.LabelTopologyZone: "test-zone-1",
-			}), Price: PriceFromResources(options.Resources), Available: true},
+			}), Price: PriceFromResources(options.Resources) + PriceFromDevices(options.Devices), Available: math.MaxInt},
 			{Requirements: scheduling.NewLabelRequirements(map[string]string{
 				v1.CapacityTypeLabelKey:  "spot",
 				corev1.LabelTopologyZone: "test-zone-2",
-			}), Price: PriceFromResources(options.Resources), Available: true},
+			}), Price: PriceFromResources(options.Resources) + PriceFromDevices(options.Devices), Available: math.MaxInt},
 			{Requirements: scheduling.NewLabelRequirements(map[string]string{
 				v1.CapacityTypeLabelKey:  "on-demand",
 				corev1.LabelTopologyZone: "test-zone-1",
-			}), Price: PriceFromResources(options.Resources), Available: true},
+			}), Price: PriceFromResources(options.Resources) + PriceFromDevices(options.Devices), Available: math.MaxInt},
 			{Requirements: scheduling.NewLabelRequirements(map[string]string{
 				v1.CapacityTypeLabelKey:  "on-demand",
 				corev1.LabelTopologyZone: "test-zone-2",
-			}), Price: PriceFromResources(options.Resources), Available: true},
+			}), Price: PriceFromResources(options.Resources) + PriceFromDevices(options.Devices), Available: math.MaxInt},
 			{Requirements: scheduling.NewLabelRequirements(map[string]string{
 				v1.CapacityTypeLabelKey:  "on-demand",
 				corev1.LabelTopologyZone: "test-zone-3",
-			}), Price: PriceFromResources(options.Resources), Available: true},
+			}), Price: PriceFromResources(options.Resources) + PriceFromDevices(options.Devices), Available: math.MaxInt},
 		}
 	}
 	if len(options.Architecture) == 0 {
@@ -123,6 +125,7 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 		Requirements: requirements,
 		Offerings:    options.Offerings,
 		Capacity:     options.Resources,
+		Devices:      options.Devices,
 		Overhead: &cloudprovider.InstanceTypeOverhead{
 			KubeReserved: corev1.ResourceList{
 				corev1.ResourceCPU:    resource.MustParse("100m"),
@@ -158,7 +161,7 @@ func InstanceTypesAssorted() []*cloudprovider.InstanceType {
 										corev1.LabelTopologyZone: zone,
 									}),
 									Price:     price,
-									Available: true,
+									Available: math.MaxInt,
 								},
 							}
 							instanceTypes = append(instanceTypes, NewInstanceType(opts))
@@ -198,6 +201,7 @@ type InstanceTypeOptions struct {
 	Architecture     string
 	OperatingSystems sets.Set[string]
 	Resources        corev1.ResourceList
+	Devices          corev1.ResourceList
 }
 
 func PriceFromResources(resources corev1.ResourceList) float64 {
@@ -214,3 +218,11 @@ func PriceFromResources(resources corev1.ResourceList) float64 {
 	}
 	return price
 }
+
+func PriceFromDevices(devices corev1.ResourceList) float64 {
+	price := 0.0
+	for _, v := range devices {
+		price += 1.0 * v.AsApproximateFloat64()
+	}
+	return price
+}
@@ -17,15 +17,26 @@ limitations under the License.
 package metrics_test
 
 import (
+	"context"
 	"errors"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/metrics"
 )
 
+// interruptionNotifier is a CloudProvider that implements the optional InterruptionNotifier extension
+type interruptionNotifier struct {
+	*fake.CloudProvider
+}
+
+func (interruptionNotifier) InterruptionNotices(context.Context) (<-chan cloudprovider.InterruptionNotice, error) {
+	return nil, nil
+}
+
 var _ = Describe("Cloudprovider", func() {
 	var nodeClaimNotFoundErr = cloudprovider.NewNodeClaimNotFoundError(errors.New("not found"))
 	var insufficientCapacityErr = cloudprovider.NewInsufficientCapacityError(errors.New("not enough capacity"))
@@ -50,4 +61,14 @@ var _ = Describe("Cloudprovider", func() {
 			})
 		})
 	})
+	Describe("CloudProvider extensions via cloudprovider.Extension()", func() {
+		It("should find the extensions of the decorated CloudProvider", func() {
+			_, ok := cloudprovider.Extension[cloudprovider.InterruptionNotifier](metrics.Decorate(interruptionNotifier{fake.NewCloudProvider()}))
+			Expect(ok).To(BeTrue())
+		})
+		It("should not find extensions that the decorated CloudProvider doesn't implement", func() {
+			_, ok := cloudprovider.Extension[cloudprovider.InterruptionNotifier](metrics.Decorate(fake.NewCloudProvider()))
+			Expect(ok).To(BeFalse())
+		})
+	})
 })
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 var (
 	SpotRequirement     = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeSpot))
 	OnDemandRequirement = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeOnDemand))
+	ReservedRequirement = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved))
 )
 
 type DriftReason string
@@ -50,6 +52,9 @@ type RepairPolicy struct {
 	// TolerationDuration is the duration the controller will wait
 	// before force terminating nodes that are unhealthy.
 	TolerationDuration time.Duration
+	// Action is how the controller repairs nodes that are unhealthy. Nodes are
+	// replaced if it's not set, or if the CloudProvider can't reboot them.
+	Action v1.RepairAction
 }
 
 // CloudProvider interface is implemented by cloud providers to support provisioning.
@@ -81,6 +86,47 @@ type CloudProvider interface {
 	GetSupportedNodeClasses() []status.Object
 }
 
+// InterruptionNotice is a notice from the cloud provider that the instance with the given provider id is going to be
+// interrupted, e.g. because its spot capacity is reclaimed, its host is retired or it's scheduled for maintenance
+type InterruptionNotice struct {
+	// ProviderID of the instance that is going to be interrupted
+	ProviderID string
+	// Reason is a human readable reason of the interruption, used for events and logs
+	Reason string
+	// Deadline is the time at which the instance is interrupted
+	Deadline time.Time
+}
+
+// InterruptionNotifier is an optional extension of the CloudProvider interface. CloudProviders that implement it have
+// their nodes cordoned, replaced and drained before the deadline of the interruption notices that they stream.
+type InterruptionNotifier interface {
+	// InterruptionNotices streams the interruption notices of the cloud provider until the context is canceled
+	InterruptionNotices(context.Context) (<-chan InterruptionNotice, error)
+}
+
+// Rebooter is an optional extension of the CloudProvider interface. CloudProviders that implement it have the nodes
+// matching a repair policy with the Reboot action rebooted before they're replaced.
+type Rebooter interface {
+	// Reboot reboots the instance of the NodeClaim
+	Reboot(context.Context, *v1.NodeClaim) error
+}
+
+// Extension returns the CloudProvider as the optional extension interface T, looking through the CloudProviders that
+// decorate it, and whether the CloudProvider implements the extension
+func Extension[T any](cloudProvider CloudProvider) (T, bool) {
+	for cloudProvider != nil {
+		if extension, ok := cloudProvider.(T); ok {
+			return extension, true
+		}
+		decorator, ok := cloudProvider.(interface{ Unwrap() CloudProvider })
+		if !ok {
+			break
+		}
+		cloudProvider = decorator.Unwrap()
+	}
+	return *new(T), false
+}
+
 // InstanceType describes the properties of a potential node (either concrete attributes of an instance of this type
 // or supported options in the case of arrays)
 type InstanceType struct {
@@ -93,6 +139,9 @@ type InstanceType struct {
 	Offerings Offerings
 	// Resources are the full resource capacities for this instance type
 	Capacity corev1.ResourceList
+	// Devices are the number of devices of each DeviceClass, keyed by DeviceClass name, that are attached to this
+	// instance type and can be allocated to pods through ResourceClaims
+	Devices corev1.ResourceList
 	// Overhead is the amount of resource overhead expected to be used by kubelet and any other system daemons outside
 	// of Kubernetes.
 	Overhead *InstanceTypeOverhead
@@ -115,15 +164,13 @@ func (i *InstanceType) Allocatable() corev1.ResourceList {
 }
 
 func (its InstanceTypes) OrderByPrice(reqs scheduling.Requirements) InstanceTypes {
-	// Order instance types so that we get the cheapest instance types of the available offerings
+	// Order instance types so that we get the cheapest instance types of the available offerings. Reserved offerings
+	// have already been paid for, so instance types that still have reserved capacity are always ordered first.
 	sort.Slice(its, func(i, j int) bool {
-		iPrice := math.MaxFloat64
-		jPrice := math.MaxFloat64
-		if ofs := its[i].Offerings.Available().Compatible(reqs); len(ofs) > 0 {
-			iPrice = ofs.Cheapest().Price
-		}
-		if ofs := its[j].Offerings.Available().Compatible(reqs); len(ofs) > 0 {
-			jPrice = ofs.Cheapest().Price
+		iReserved, iPrice := its[i].cheapestAvailablePrice(reqs)
+		jReserved, jPrice := its[j].cheapestAvailablePrice(reqs)
+		if iReserved != jReserved {
+			return iReserved
 		}
 		if iPrice == jPrice {
 			return its[i].Name < its[j].Name
@@ -133,6 +180,20 @@ func (its InstanceTypes) OrderByPrice(reqs scheduling.Requirements) InstanceType
 	return its
 }
 
+// cheapestAvailablePrice returns the price of the cheapest available offering that is compatible with the requirements
+// and whether that price comes from a reserved offering. Reserved offerings take precedence over cheaper spot and
+// on-demand offerings while they still have capacity.
+func (i *InstanceType) cheapestAvailablePrice(reqs scheduling.Requirements) (bool, float64) {
+	ofs := i.Offerings.Available().Compatible(reqs)
+	if reserved := ofs.Compatible(ReservedRequirement); len(reserved) > 0 {
+		return true, reserved.Cheapest().Price
+	}
+	if len(ofs) > 0 {
+		return false, ofs.Cheapest().Price
+	}
+	return false, math.MaxFloat64
+}
+
 // Compatible returns the list of instanceTypes based on the supported capacityType and zones in the requirements
 func (its InstanceTypes) Compatible(requirements scheduling.Requirements) InstanceTypes {
 	var filteredInstanceTypes []*InstanceType
@@ -244,9 +305,22 @@ func (i InstanceTypeOverhead) Total() corev1.ResourceList {
 type Offering struct {
 	Requirements scheduling.Requirements
 	Price        float64
-	// Available is added so that Offerings can return all offerings that have ever existed for an instance type,
-	// so we can get historical pricing data for calculating savings in consolidation
-	Available bool
+	// Available is the number of instances that can currently be launched into this offering. Offerings with
+	// unconstrained capacity (e.g. spot and on-demand) should set this to math.MaxInt and offerings that can't be
+	// launched should set this to 0. Reserved offerings report the remaining count of their reservation.
+	// Offerings that can't be launched are still returned so that we can get historical pricing data for calculating
+	// savings in consolidation.
+	Available int
+}
+
+// CapacityType returns the capacity type of the offering
+func (o Offering) CapacityType() string {
+	return o.Requirements.Get(v1.CapacityTypeLabelKey).Any()
+}
+
+// Zone returns the zone of the offering
+func (o Offering) Zone() string {
+	return o.Requirements.Get(corev1.LabelTopologyZone).Any()
 }
 
 type Offerings []Offering
@@ -254,7 +328,14 @@ type Offerings []Offering
 // Available filters the available offerings from the returned offerings
 func (ofs Offerings) Available() Offerings {
 	return lo.Filter(ofs, func(o Offering, _ int) bool {
-		return o.Available
+		return o.Available > 0
+	})
+}
+
+// Reserved filters the reserved offerings from the returned offerings
+func (ofs Offerings) Reserved() Offerings {
+	return lo.Filter(ofs, func(o Offering, _ int) bool {
+		return o.CapacityType() == v1.CapacityTypeReserved
 	})
 }
 
@@ -290,9 +371,17 @@ func (ofs Offerings) MostExpensive() Offering {
 }
 
 // WorstLaunchPrice gets the worst-case launch price from the offerings that are offered
-// on an instance type. If the instance type has a spot offering available, then it uses the spot offering
-// to get the launch price; else, it uses the on-demand launch price
+// on an instance type. If the instance type has a reserved offering available, then it uses the reserved offering
+// to get the launch price; else, if it has a spot offering available, then it uses the spot offering;
+// else, it uses the on-demand launch price
 func (ofs Offerings) WorstLaunchPrice(reqs scheduling.Requirements) float64 {
+	// Reserved capacity is launched before any other capacity type while the reservation still has capacity
+	if reqs.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeReserved) {
+		reservedOfferings := ofs.Compatible(reqs).Compatible(ReservedRequirement)
+		if len(reservedOfferings) > 0 {
+			return reservedOfferings.MostExpensive().Price
+		}
+	}
 	// We prefer to launch spot offerings, so we will get the worst price based on the node requirements
 	if reqs.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot) {
 		spotOfferings := ofs.Compatible(reqs).Compatible(SpotRequirement)
@@ -342,16 +431,24 @@ func IgnoreNodeClaimNotFoundError(err error) error {
 // InsufficientCapacityError is an error type returned by CloudProviders when a launch fails due to a lack of capacity from NodeClaim requirements
 type InsufficientCapacityError struct {
 	error
+	// Offerings are the offerings that didn't have capacity, if known by the CloudProvider
+	Offerings []OfferingKey
 }
 
-func NewInsufficientCapacityError(err error) *InsufficientCapacityError {
+// NewInsufficientCapacityError returns an InsufficientCapacityError for the offerings that didn't have capacity. It only
+// builds the error, the NodeClaim lifecycle controller marks the offerings as unavailable when it handles the error.
+func NewInsufficientCapacityError(err error, offerings ...OfferingKey) *InsufficientCapacityError {
 	return &InsufficientCapacityError{
-		error: err,
+		error:     err,
+		Offerings: offerings,
 	}
 }
 
 func (e *InsufficientCapacityError) Error() string {
-	return fmt.Sprintf("insufficient capacity, %s", e.error)
+	if len(e.Offerings) == 0 {
+		return fmt.Sprintf("insufficient capacity, %s", e.error)
+	}
+	return fmt.Sprintf("insufficient capacity for offerings [%s], %s", strings.Join(lo.Map(e.Offerings, func(k OfferingKey, _ int) string { return k.String() }), ", "), e.error)
 }
 
 func IsInsufficientCapacityError(err error) bool {
@@ -0,0 +1,151 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dcoppa/karpenter/pkg/metrics"
+)
+
+const (
+	// UnavailableOfferingsTTL is the time before offerings that were marked as unavailable can be selected again
+	UnavailableOfferingsTTL = 3 * time.Minute
+
+	unavailableOfferingsCleanupInterval = time.Minute
+
+	instanceTypeLabel = "instance_type"
+	zoneLabel         = "zone"
+)
+
+var UnavailableOfferingsCount = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "unavailable_offerings",
+		Help:      "Offerings that are currently marked as unavailable after failing to launch due to insufficient capacity. Labeled by instance type, zone and capacity type.",
+	},
+	[]string{
+		instanceTypeLabel,
+		zoneLabel,
+		metrics.CapacityTypeLabel,
+	},
+)
+
+// OfferingKey identifies an offering of an instance type
+type OfferingKey struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+}
+
+func (k OfferingKey) String() string {
+	return fmt.Sprintf("instance-type=%s zone=%s capacity-type=%s", k.InstanceType, k.Zone, k.CapacityType)
+}
+
+func (k OfferingKey) labels() map[string]string {
+	return map[string]string{
+		instanceTypeLabel:         k.InstanceType,
+		zoneLabel:                 k.Zone,
+		metrics.CapacityTypeLabel: k.CapacityType,
+	}
+}
+
+// UnavailableOfferings stores the offerings that recently failed to launch due to insufficient capacity, so that the
+// scheduler doesn't select them again until they expire. It is independent of the CloudProvider implementation.
+type UnavailableOfferings struct {
+	cache *cache.Cache
+}
+
+func NewUnavailableOfferings() *UnavailableOfferings {
+	c := cache.New(UnavailableOfferingsTTL, unavailableOfferingsCleanupInterval)
+	c.OnEvicted(func(key string, value interface{}) {
+		UnavailableOfferingsCount.Delete(value.(OfferingKey).labels())
+	})
+	return &UnavailableOfferings{cache: c}
+}
+
+// MarkUnavailable marks the offering as unavailable until the UnavailableOfferingsTTL expires
+func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, reason string, key OfferingKey) {
+	log.FromContext(ctx).WithValues(
+		"reason", reason,
+		"instance-type", key.InstanceType,
+		"zone", key.Zone,
+		"capacity-type", key.CapacityType,
+		"ttl", UnavailableOfferingsTTL).V(1).Info("removing offering from offerings")
+	u.cache.SetDefault(key.String(), key)
+	UnavailableOfferingsCount.Set(1, key.labels())
+}
+
+// IsUnavailable returns whether the offering is currently marked as unavailable
+func (u *UnavailableOfferings) IsUnavailable(key OfferingKey) bool {
+	_, found := u.cache.Get(key.String())
+	return found
+}
+
+// Delete removes the offering from the unavailable offerings
+func (u *UnavailableOfferings) Delete(key OfferingKey) {
+	u.cache.Delete(key.String())
+}
+
+// Flush removes all of the offerings from the unavailable offerings
+func (u *UnavailableOfferings) Flush() {
+	for key := range u.cache.Items() {
+		u.cache.Delete(key)
+	}
+}
+
+// Apply returns the instance types with the offerings that are marked as unavailable set to have no available capacity.
+// Instance types without unavailable offerings are returned as is, while the others are copied so that the instance types
+// that are cached by the CloudProvider aren't modified. Unavailable offerings are kept so that their prices can still
+// be used for calculating savings in consolidation.
+func (u *UnavailableOfferings) Apply(instanceTypes []*InstanceType) []*InstanceType {
+	if u.cache.ItemCount() == 0 {
+		return instanceTypes
+	}
+	return lo.Map(instanceTypes, func(it *InstanceType, _ int) *InstanceType {
+		unavailable := func(o Offering) bool {
+			return o.Available > 0 && u.IsUnavailable(OfferingKey{InstanceType: it.Name, Zone: o.Zone(), CapacityType: o.CapacityType()})
+		}
+		if !lo.ContainsBy(it.Offerings, unavailable) {
+			return it
+		}
+		return &InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Offerings: lo.Map(it.Offerings, func(o Offering, _ int) Offering {
+				if unavailable(o) {
+					o.Available = 0
+				}
+				return o
+			}),
+			Capacity: it.Capacity,
+			Devices:  it.Devices,
+			Overhead: it.Overhead,
+		}
+	})
+}
@@ -43,11 +43,14 @@ import (
 	"github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/expiration"
 	nodeclaimgarbagecollection "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/garbagecollection"
 	nodeclaimhydration "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/hydration"
+	nodeclaiminterruption "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/interruption"
 	nodeclaimlifecycle "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	podevents "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/podevents"
+	nodeclaimpropagation "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/propagation"
 	nodepoolcounter "github.com/dcoppa/karpenter/pkg/controllers/nodepool/counter"
 	nodepoolhash "github.com/dcoppa/karpenter/pkg/controllers/nodepool/hash"
 	nodepoolreadiness "github.com/dcoppa/karpenter/pkg/controllers/nodepool/readiness"
+	nodepoolstatic "github.com/dcoppa/karpenter/pkg/controllers/nodepool/static"
 	nodepoolvalidation "github.com/dcoppa/karpenter/pkg/controllers/nodepool/validation"
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
 	"github.com/dcoppa/karpenter/pkg/controllers/state"
@@ -65,7 +68,8 @@ func NewControllers(
 	cloudProvider cloudprovider.CloudProvider,
 ) []controller.Controller {
 	cluster := state.NewCluster(clock, kubeClient, cloudProvider)
-	p := provisioning.NewProvisioner(kubeClient, recorder, cloudProvider, cluster, clock)
+	unavailableOfferings := cloudprovider.NewUnavailableOfferings()
+	p := provisioning.NewProvisioner(kubeClient, recorder, cloudProvider, cluster, clock, unavailableOfferings)
 	evictionQueue := terminator.NewQueue(kubeClient, recorder)
 	disruptionQueue := orchestration.NewQueue(kubeClient, recorder, cluster, clock, p)
 
@@ -74,6 +78,7 @@ func NewControllers(
 		disruption.NewController(clock, kubeClient, p, cloudProvider, recorder, cluster, disruptionQueue),
 		provisioning.NewPodController(kubeClient, p),
 		provisioning.NewNodeController(kubeClient, p),
+		provisioning.NewNodePoolController(kubeClient, p),
 		nodepoolhash.NewController(kubeClient, cloudProvider),
 		expiration.NewController(clock, kubeClient, cloudProvider),
 		informer.NewDaemonSetController(kubeClient, cluster),
@@ -87,23 +92,30 @@ func NewControllers(
 		metricsnode.NewController(cluster),
 		nodepoolreadiness.NewController(kubeClient, cloudProvider),
 		nodepoolcounter.NewController(kubeClient, cloudProvider, cluster),
+		nodepoolstatic.NewController(kubeClient, cloudProvider, cluster, p),
 		nodepoolvalidation.NewController(kubeClient, cloudProvider),
 		podevents.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimconsistency.NewController(clock, kubeClient, cloudProvider, recorder),
-		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, recorder),
+		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, recorder, unavailableOfferings),
 		nodeclaimgarbagecollection.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimdisruption.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimhydration.NewController(kubeClient, cloudProvider),
+		nodeclaimpropagation.NewController(kubeClient, cloudProvider),
 		nodehydration.NewController(kubeClient, cloudProvider),
 		status.NewController[*v1.NodeClaim](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		status.NewController[*v1.NodePool](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		status.NewGenericObjectController[*corev1.Node](kubeClient, mgr.GetEventRecorderFor("karpenter")),
 	}
 
-	// The cloud provider must define status conditions for the node repair controller to use to detect unhealthy nodes
-	if len(cloudProvider.RepairPolicies()) != 0 && options.FromContext(ctx).FeatureGates.NodeRepair {
+	// The node repair controller detects unhealthy nodes with the repair policies of the cloud provider and the NodePools
+	if options.FromContext(ctx).FeatureGates.NodeRepair {
 		controllers = append(controllers, health.NewController(kubeClient, cloudProvider, clock, recorder))
 	}
 
+	// The cloud provider must stream interruption notices for the interruption controller to replace interrupted nodes
+	if notifier, ok := cloudprovider.Extension[cloudprovider.InterruptionNotifier](cloudProvider); ok {
+		controllers = append(controllers, nodeclaiminterruption.NewController(clock, kubeClient, cloudProvider, notifier, cluster, p, recorder))
+	}
+
 	return controllers
 }
@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
+)
+
+// ApprovalDecision is the verdict of the external approver on a disruption command
+type ApprovalDecision string
+
+const (
+	// ApprovalDecisionApprove lets Karpenter execute the command
+	ApprovalDecisionApprove ApprovalDecision = "Approve"
+	// ApprovalDecisionDeny drops the command, Karpenter moves on to the next disruption method
+	ApprovalDecisionDeny ApprovalDecision = "Deny"
+	// ApprovalDecisionDefer drops the command and pauses disruption until the approver asks to be called again
+	ApprovalDecisionDefer ApprovalDecision = "Defer"
+)
+
+// ApprovalRequest is the body that is POSTed to the external approver before a disruption command is executed
+type ApprovalRequest struct {
+	CommandID         types.UID                    `json:"commandID"`
+	Reason            v1.DisruptionReason          `json:"reason"`
+	Decision          Decision                     `json:"decision"`
+	ConsolidationType string                       `json:"consolidationType,omitempty"`
+	EstimatedSavings  float64                      `json:"estimatedSavings"`
+	Candidates        []ApprovalRequestCandidate   `json:"candidates"`
+	Replacements      []ApprovalRequestReplacement `json:"replacements,omitempty"`
+}
+
+// ApprovalRequestCandidate describes a node that the command disrupts
+type ApprovalRequestCandidate struct {
+	NodeClaim    string `json:"nodeClaim"`
+	Node         string `json:"node,omitempty"`
+	NodePool     string `json:"nodePool"`
+	InstanceType string `json:"instanceType,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+}
+
+// ApprovalRequestReplacement describes a NodeClaim that the command launches before disrupting its candidates
+type ApprovalRequestReplacement struct {
+	NodePool      string   `json:"nodePool"`
+	InstanceTypes []string `json:"instanceTypes"`
+	CapacityTypes []string `json:"capacityTypes,omitempty"`
+}
+
+// ApprovalResponse is the body returned by the external approver. RetryAfter is only considered for a deferred
+// command and defaults to the disruption polling period.
+type ApprovalResponse struct {
+	Decision   ApprovalDecision `json:"decision"`
+	Reason     string           `json:"reason,omitempty"`
+	RetryAfter *metav1.Duration `json:"retryAfter,omitempty"`
+}
+
+// NewApprovalRequest builds the request that describes the command to the external approver
+func NewApprovalRequest(commandID types.UID, m Method, cmd Command) ApprovalRequest {
+	return ApprovalRequest{
+		CommandID:         commandID,
+		Reason:            m.Reason(),
+		Decision:          cmd.Decision(),
+		ConsolidationType: m.ConsolidationType(),
+		EstimatedSavings:  cmd.EstimatedSavings(),
+		Candidates: lo.Map(cmd.candidates, func(c *Candidate, _ int) ApprovalRequestCandidate {
+			candidate := ApprovalRequestCandidate{
+				NodeClaim:    c.NodeClaim.Name,
+				NodePool:     c.nodePool.Name,
+				InstanceType: c.Labels()[corev1.LabelInstanceTypeStable],
+				CapacityType: c.capacityType,
+				Zone:         c.zone,
+			}
+			if c.Node != nil {
+				candidate.Node = c.Node.Name
+			}
+			return candidate
+		}),
+		Replacements: lo.Map(cmd.replacements, func(r *scheduling.NodeClaim, _ int) ApprovalRequestReplacement {
+			return ApprovalRequestReplacement{
+				NodePool:      r.NodePoolName,
+				InstanceTypes: lo.Map(r.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+				CapacityTypes: r.Requirements.Get(v1.CapacityTypeLabelKey).Values(),
+			}
+		}),
+	}
+}
+
+// requestApproval asks the external approver whether the command can be executed. If no approver is configured,
+// every command is approved. If the approver can't be reached or returns an unexpected response, the configured
+// failure policy decides whether the command is denied or approved.
+func requestApproval(ctx context.Context, req ApprovalRequest) ApprovalResponse {
+	opts := options.FromContext(ctx)
+	if opts.DisruptionApprovalURL == "" {
+		return ApprovalResponse{Decision: ApprovalDecisionApprove}
+	}
+	resp, err := callApprover(ctx, opts, req)
+	if err == nil {
+		return resp
+	}
+	if opts.DisruptionApprovalFailurePolicy == options.DisruptionApprovalFailurePolicyIgnore {
+		log.FromContext(ctx).WithValues("command-id", req.CommandID).Error(err, "failed requesting disruption approval, approving command")
+		return ApprovalResponse{Decision: ApprovalDecisionApprove}
+	}
+	log.FromContext(ctx).WithValues("command-id", req.CommandID).Error(err, "failed requesting disruption approval, denying command")
+	return ApprovalResponse{Decision: ApprovalDecisionDeny, Reason: fmt.Sprintf("approval request failed, %s", err)}
+}
+
+func callApprover(ctx context.Context, opts *options.Options, req ApprovalRequest) (ApprovalResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return ApprovalResponse{}, fmt.Errorf("marshaling approval request, %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.DisruptionApprovalURL, bytes.NewReader(body))
+	if err != nil {
+		return ApprovalResponse{}, fmt.Errorf("building approval request, %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := (&http.Client{Timeout: opts.DisruptionApprovalTimeout}).Do(httpReq)
+	if err != nil {
+		return ApprovalResponse{}, fmt.Errorf("calling approver, %w", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return ApprovalResponse{}, fmt.Errorf("approver returned status %d, %s", httpResp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var resp ApprovalResponse
+	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return ApprovalResponse{}, fmt.Errorf("decoding approval response, %w", err)
+	}
+	switch resp.Decision {
+	case ApprovalDecisionApprove, ApprovalDecisionDeny, ApprovalDecisionDefer:
+		return resp, nil
+	default:
+		return ApprovalResponse{}, fmt.Errorf("approver returned unknown decision %q", resp.Decision)
+	}
+}
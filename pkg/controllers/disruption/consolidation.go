@@ -106,6 +106,11 @@ func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
 		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodePool %q has consolidation disabled", cn.nodePool.Name))...)
 		return false
 	}
+	// Static NodePools keep a fixed number of NodeClaims, so their NodeClaims are never consolidated
+	if cn.nodePool.IsStatic() {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodePool %q is static", cn.nodePool.Name))...)
+		return false
+	}
 	// If we don't have the "WhenEmptyOrUnderutilized" policy set, we should not do any of the consolidation methods, but
 	// we should also not fire an event here to users since this can be confusing when the field on the NodePool
 	// is named "consolidationPolicy"
@@ -156,14 +161,6 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 		}, results, nil
 	}
 
-	// we're not going to turn a single node into multiple candidates
-	if len(results.NewNodeClaims) != 1 {
-		if len(candidates) == 1 {
-			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't remove without creating %d candidates", len(results.NewNodeClaims)))...)
-		}
-		return Command{}, pscheduling.Results{}, nil
-	}
-
 	// get the current node price based on the offering
 	// fallback if we can't find the specific zonal pricing data
 	candidatePrice, err := getCandidatePrices(candidates)
@@ -171,6 +168,10 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 		return Command{}, pscheduling.Results{}, fmt.Errorf("getting offering price from candidate node, %w", err)
 	}
 
+	if len(results.NewNodeClaims) > 1 {
+		return c.computeMultiReplacementConsolidation(candidates, results, candidatePrice)
+	}
+
 	allExistingAreSpot := true
 	for _, cn := range candidates {
 		if cn.capacityType != v1.CapacityTypeSpot {
@@ -182,6 +183,10 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	// that meets the minimum requirement after filteringByPrice
 	results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions = results.NewNodeClaims[0].InstanceTypeOptions.OrderByPrice(results.NewNodeClaims[0].Requirements)
 
+	if isReservedConsolidation(candidates, results.NewNodeClaims[0]) {
+		return c.computeReservedConsolidation(candidates, results)
+	}
+
 	if allExistingAreSpot &&
 		results.NewNodeClaims[0].Requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot) {
 		return c.computeSpotToSpotConsolidation(ctx, candidates, results, candidatePrice)
@@ -205,6 +210,9 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
+	if ok, err := c.removeInstanceTypeOptionsByPriceThreshold(candidates, results.NewNodeClaims[0], candidatePrice); !ok || err != nil {
+		return Command{}, pscheduling.Results{}, err
+	}
 
 	// We are consolidating a node from OD -> [OD,Spot] but have filtered the instance types by cost based on the
 	// assumption, that the spot variant will launch. We also need to add a requirement to the node to ensure that if
@@ -221,6 +229,99 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	}, results, nil
 }
 
+// computeMultiReplacementConsolidation computes a command to replace the candidates with multiple NodeClaims. This is
+// only worth it if the replacements are cheaper together than the candidates, so each replacement is constrained to a
+// share of the price of the candidates: the cheapest launch price of its instance types plus an even split of the
+// savings. Whichever instance types the replacements launch with, their combined price stays under the price of the
+// candidates. Spot candidates aren't replaced with multiple spot NodeClaims since spot-to-spot consolidation relies on
+// the flexibility of a single replacement to avoid churning onto less available capacity.
+func (c *consolidation) computeMultiReplacementConsolidation(candidates []*Candidate, results pscheduling.Results, candidatePrice float64) (Command, pscheduling.Results, error) {
+	if lo.EveryBy(candidates, func(cn *Candidate) bool { return cn.capacityType == v1.CapacityTypeSpot }) &&
+		lo.SomeBy(results.NewNodeClaims, func(n *pscheduling.NodeClaim) bool {
+			return n.Requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot)
+		}) {
+		if len(candidates) == 1 {
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't replace a spot node with %d spot nodes", len(results.NewNodeClaims)))...)
+		}
+		return Command{}, pscheduling.Results{}, nil
+	}
+	maxPrice, err := consolidationMaxPrice(candidates, candidatePrice)
+	if err != nil {
+		return Command{}, pscheduling.Results{}, err
+	}
+	cheapestPrices := make([]float64, len(results.NewNodeClaims))
+	for i, replacement := range results.NewNodeClaims {
+		replacement.NodeClaimTemplate.InstanceTypeOptions = replacement.InstanceTypeOptions.OrderByPrice(replacement.Requirements)
+		prices := lo.Map(replacement.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) float64 {
+			return it.Offerings.Available().WorstLaunchPrice(replacement.Requirements)
+		})
+		if len(prices) == 0 {
+			if len(candidates) == 1 {
+				c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't replace with %d nodes, a replacement has no available instance types", len(results.NewNodeClaims)))...)
+			}
+			return Command{}, pscheduling.Results{}, nil
+		}
+		cheapestPrices[i] = lo.Min(prices)
+	}
+	savings := maxPrice - lo.Sum(cheapestPrices)
+	if savings <= 0 {
+		if len(candidates) == 1 {
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't replace with %d cheaper nodes", len(results.NewNodeClaims)))...)
+		}
+		return Command{}, pscheduling.Results{}, nil
+	}
+	for i := range results.NewNodeClaims {
+		results.NewNodeClaims[i], err = results.NewNodeClaims[i].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[i].Requirements,
+			cheapestPrices[i]+savings/float64(len(results.NewNodeClaims)))
+		if err != nil {
+			if len(candidates) == 1 {
+				c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Filtering by price: %v", err))...)
+			}
+			return Command{}, pscheduling.Results{}, nil
+		}
+		// The instance types were filtered by their worst launch price, so pin the replacement to spot like we do for a
+		// single replacement to avoid launching a more expensive on-demand node if spot capacity is insufficient
+		ctReq := results.NewNodeClaims[i].Requirements.Get(v1.CapacityTypeLabelKey)
+		if ctReq.Has(v1.CapacityTypeSpot) && ctReq.Has(v1.CapacityTypeOnDemand) {
+			results.NewNodeClaims[i].Requirements.Add(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeSpot))
+		}
+	}
+	return Command{
+		candidates:   candidates,
+		replacements: results.NewNodeClaims,
+	}, results, nil
+}
+
+// computeReservedConsolidation computes a command to move the workloads of spot and on-demand candidates onto reserved
+// capacity that is still free. Reserved capacity has already been paid for, so the replacement is always considered the
+// cheapest option regardless of the price of the candidates. The scheduling simulation only constrains a NodeClaim to
+// reserved capacity when the CapacityReservations feature gate is enabled and it holds capacity from the reservations.
+func (c *consolidation) computeReservedConsolidation(candidates []*Candidate, results pscheduling.Results) (Command, pscheduling.Results, error) {
+	if len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions) == 0 {
+		if len(candidates) == 1 {
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace with reserved capacity")...)
+		}
+		return Command{}, pscheduling.Results{}, nil
+	}
+	return Command{
+		candidates:   candidates,
+		replacements: results.NewNodeClaims,
+	}, results, nil
+}
+
+// isReservedConsolidation returns true if the replacement can only launch into reserved capacity and none of the
+// candidates are already running on reserved capacity. Consolidating between reserved capacity options goes through
+// the standard price comparison, since reserved offerings are priced as their on-demand equivalent.
+func isReservedConsolidation(candidates []*Candidate, replacement *pscheduling.NodeClaim) bool {
+	ctReq := replacement.Requirements.Get(v1.CapacityTypeLabelKey)
+	if ctReq.Len() != 1 || !ctReq.Has(v1.CapacityTypeReserved) {
+		return false
+	}
+	return lo.NoneBy(candidates, func(cn *Candidate) bool {
+		return cn.capacityType == v1.CapacityTypeReserved
+	})
+}
+
 // Compute command to execute spot-to-spot consolidation if:
 //  1. The SpotToSpotConsolidation feature flag is set to true.
 //  2. For single-node consolidation:
@@ -257,6 +358,9 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
+	if ok, err := c.removeInstanceTypeOptionsByPriceThreshold(candidates, results.NewNodeClaims[0], candidatePrice); !ok || err != nil {
+		return Command{}, pscheduling.Results{}, err
+	}
 
 	// For multi-node consolidation:
 	// We don't have any requirement to check the remaining instance type flexibility, so exit early in this case.
@@ -301,6 +405,42 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 	}, results, nil
 }
 
+// removeInstanceTypeOptionsByPriceThreshold removes the instance type options of the replacement that don't save more
+// than the ConsolidationPriceThreshold of the NodePools of the candidates. When the candidates come from NodePools with
+// different thresholds, the replacement needs to satisfy the most restrictive one. This returns false if no instance
+// type options remain.
+func (c *consolidation) removeInstanceTypeOptionsByPriceThreshold(candidates []*Candidate, replacement *pscheduling.NodeClaim, candidatePrice float64) (bool, error) {
+	maxPrice, err := consolidationMaxPrice(candidates, candidatePrice)
+	if err != nil {
+		return false, err
+	}
+	if maxPrice >= candidatePrice {
+		return true, nil
+	}
+	if _, err := replacement.RemoveInstanceTypeOptionsByPriceAndMinValues(replacement.Requirements, maxPrice); err != nil || len(replacement.InstanceTypeOptions) == 0 {
+		if len(candidates) == 1 {
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't replace with a node that saves more than the consolidation price threshold of %s",
+				lo.FromPtr(candidates[0].nodePool.Spec.Disruption.ConsolidationPriceThreshold)))...)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// consolidationMaxPrice returns the price that the replacements of the candidates need to stay under to satisfy the
+// ConsolidationPriceThreshold of the NodePools of all the candidates
+func consolidationMaxPrice(candidates []*Candidate, candidatePrice float64) (float64, error) {
+	maxPrice := candidatePrice
+	for _, cn := range candidates {
+		price, err := cn.nodePool.Spec.Disruption.GetConsolidationMaxPrice(candidatePrice)
+		if err != nil {
+			return 0, fmt.Errorf("getting consolidation price threshold for nodepool %q, %w", cn.nodePool.Name, err)
+		}
+		maxPrice = lo.Min([]float64{maxPrice, price})
+	}
+	return maxPrice, nil
+}
+
 // getCandidatePrices returns the sum of the prices of the given candidates
 func getCandidatePrices(candidates []*Candidate) (float64, error) {
 	var price float64
@@ -18,6 +18,7 @@ package disruption_test
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"sort"
 	"strings"
@@ -929,6 +930,103 @@ var _ = Describe("Consolidation", func() {
 			Entry("if the candidate is on-demand node", false),
 			Entry("if the candidate is spot node", true),
 		)
+		DescribeTable("can replace a node with multiple nodes",
+			func(replacementPrice float64, replaced bool) {
+				currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "current-on-demand",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        1.0,
+							Available:    math.MaxInt,
+						},
+					},
+				})
+				replacementInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "small-on-demand",
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:  resource.MustParse("4"),
+						corev1.ResourcePods: resource.MustParse("10"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        replacementPrice,
+							Available:    math.MaxInt,
+						},
+					},
+				})
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, replacementInstance}
+
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+				// The pods can't run on the same node, so they need a replacement each
+				pods := test.Pods(2, test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						}},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+					PodAntiRequirements: []corev1.PodAffinityTerm{{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+						TopologyKey:   corev1.LabelHostname,
+					}},
+				})
+				nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey:            nodePool.Name,
+							corev1.LabelInstanceTypeStable: currentInstance.Name,
+							v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+							corev1.LabelTopologyZone:       "test-zone-1a",
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+					},
+				})
+				nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+				ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], nodeClaim, node, nodePool)
+				ExpectManualBinding(ctx, env.Client, pods[0], node)
+				ExpectManualBinding(ctx, env.Client, pods[1], node)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+				fakeClock.Step(10 * time.Minute)
+
+				if !replaced {
+					ExpectSingletonReconciled(ctx, disruptionController)
+					Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+					ExpectExists(ctx, env.Client, nodeClaim)
+					return
+				}
+				// consolidation won't delete the old nodeclaim until both new nodeclaims are ready
+				var wg sync.WaitGroup
+				ExpectToWait(&wg)
+				ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 2)
+				ExpectSingletonReconciled(ctx, disruptionController)
+				wg.Wait()
+
+				// Process the item so that the nodes can be deleted.
+				ExpectSingletonReconciled(ctx, queue)
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(2))
+				for _, nc := range nodeClaims {
+					Expect(scheduling.NewNodeSelectorRequirementsWithMinValues(nc.Spec.Requirements...).Get(corev1.LabelInstanceTypeStable).Has(currentInstance.Name)).To(BeFalse())
+				}
+				ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			},
+			Entry("if the replacements are cheaper together", 0.3, true),
+			Entry("unless the replacements are more expensive together", 0.6, false),
+		)
 		It("cannot replace spot with spot if less than minimum InstanceTypes flexibility", func() {
 			// Forcefully shrink the possible instanceTypes to be lower than 15 to replace a nodeclaim
 			cloudProvider.InstanceTypes = lo.Slice(fake.InstanceTypesAssorted(), 0, 5)
@@ -2052,7 +2150,7 @@ var _ = Describe("Consolidation", func() {
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
 						Price:        0.5,
-						Available:    false,
+						Available:    0,
 					},
 				},
 			})
@@ -2062,17 +2160,17 @@ var _ = Describe("Consolidation", func() {
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}),
 						Price:        1.0,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1b"}),
 						Price:        0.2,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1c"}),
 						Price:        0.4,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 				},
 			})
@@ -2136,7 +2234,7 @@ var _ = Describe("Consolidation", func() {
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
 						Price:        0.5,
-						Available:    false,
+						Available:    0,
 					},
 				},
 			})
@@ -2146,22 +2244,22 @@ var _ = Describe("Consolidation", func() {
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
 						Price:        0.6,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1b"}),
 						Price:        0.6,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1b"}),
 						Price:        0.2,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 					{
 						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1c"}),
 						Price:        0.3,
-						Available:    true,
+						Available:    math.MaxInt,
 					},
 				},
 			})
@@ -2230,6 +2328,372 @@ var _ = Describe("Consolidation", func() {
 			ExpectExists(ctx, env.Client, nodeClaim)
 			ExpectExists(ctx, env.Client, node)
 		})
+		DescribeTable("should respect the consolidation price threshold",
+			func(threshold string, replaced bool) {
+				currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "current-on-demand",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        0.5,
+							Available:    math.MaxInt,
+						},
+					},
+				})
+				replacementInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "on-demand-replacement",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        0.45,
+							Available:    math.MaxInt,
+						},
+					},
+				})
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, replacementInstance}
+
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						}}})
+				nodePool.Spec.Disruption.ConsolidationPriceThreshold = lo.ToPtr(threshold)
+				nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey:            nodePool.Name,
+							corev1.LabelInstanceTypeStable: currentInstance.Name,
+							v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+							corev1.LabelTopologyZone:       "test-zone-1a",
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+					},
+				})
+				nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+				ExpectApplied(ctx, env.Client, rs, pod, nodeClaim, node, nodePool)
+				ExpectManualBinding(ctx, env.Client, pod, node)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+				fakeClock.Step(10 * time.Minute)
+
+				if !replaced {
+					ExpectSingletonReconciled(ctx, disruptionController)
+					Expect(recorder.Calls("Unconsolidatable")).To(BeNumerically(">", 0))
+					Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+					ExpectExists(ctx, env.Client, nodeClaim)
+					ExpectExists(ctx, env.Client, node)
+					return
+				}
+				var wg sync.WaitGroup
+				ExpectToWait(&wg)
+				ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+				ExpectSingletonReconciled(ctx, disruptionController)
+				wg.Wait()
+				ExpectSingletonReconciled(ctx, queue)
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				Expect(nodeClaims[0].Name).ToNot(Equal(nodeClaim.Name))
+				requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...)
+				Expect(requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf(replacementInstance.Name))
+				ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			},
+			Entry("when the replacement saves more than a relative threshold", "5%", true),
+			Entry("when the replacement doesn't save more than a relative threshold", "20%", false),
+			Entry("when the replacement saves more than an absolute threshold", "0.01", true),
+			Entry("when the replacement doesn't save more than an absolute threshold", "0.1", false),
+		)
+		It("should not replace with an offering that is marked as unavailable", func() {
+			currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "current-on-demand",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        0.5,
+						Available:    math.MaxInt,
+					},
+				},
+			})
+			replacementInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "on-demand-replacement",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        0.2,
+						Available:    math.MaxInt,
+					},
+				},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, replacementInstance}
+			unavailableOfferings.MarkUnavailable(ctx, "test", cloudprovider.OfferingKey{InstanceType: replacementInstance.Name, Zone: "test-zone-1a", CapacityType: v1.CapacityTypeOnDemand})
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: currentInstance.Name,
+						v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone:       "test-zone-1a",
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+			ExpectApplied(ctx, env.Client, rs, pod, nodeClaim, node, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			fakeClock.Step(10 * time.Minute)
+
+			ExpectSingletonReconciled(ctx, disruptionController)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, node)
+		})
+		It("should apply the consolidation price threshold to the combined price of multiple candidates", func() {
+			currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "current-on-demand",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        0.5,
+						Available:    math.MaxInt,
+					},
+				},
+			})
+			// Replacing both candidates with this instance type saves 10% of their combined price
+			replacementInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "on-demand-replacement",
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("64"),
+					corev1.ResourcePods: resource.MustParse("110"),
+				},
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        0.9,
+						Available:    math.MaxInt,
+					},
+				},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, replacementInstance}
+			nodePool.Spec.Disruption.ConsolidationPriceThreshold = lo.ToPtr("20%")
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pods := test.Pods(2, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}},
+			})
+			nodeClaims, nodes := test.NodeClaimsAndNodes(2, v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: currentInstance.Name,
+						v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone:       "test-zone-1a",
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+			for _, nc := range nodeClaims {
+				nc.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+			}
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodePool)
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			ExpectExists(ctx, env.Client, nodeClaims[0])
+			ExpectExists(ctx, env.Client, nodeClaims[1])
+		})
+		DescribeTable("can replace node with free reserved capacity",
+			func(capacityType string) {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{CapacityReservations: lo.ToPtr(true)}}))
+				currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "current-instance",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: capacityType, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        0.5,
+							Available:    math.MaxInt,
+						},
+					},
+				})
+				// The reserved offering is priced as its on-demand equivalent, but it has already been paid for
+				reservedInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "reserved-replacement",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeReserved, corev1.LabelTopologyZone: "test-zone-1a"}),
+							Price:        1.0,
+							Available:    1,
+						},
+					},
+				})
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, reservedInstance}
+
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						}}})
+				nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey:            nodePool.Name,
+							corev1.LabelInstanceTypeStable: currentInstance.Name,
+							v1.CapacityTypeLabelKey:        capacityType,
+							corev1.LabelTopologyZone:       "test-zone-1a",
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+					},
+				})
+				nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+				ExpectApplied(ctx, env.Client, rs, pod, nodeClaim, node, nodePool)
+				ExpectManualBinding(ctx, env.Client, pod, node)
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+				fakeClock.Step(10 * time.Minute)
+
+				var wg sync.WaitGroup
+				ExpectToWait(&wg)
+				ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+				ExpectSingletonReconciled(ctx, disruptionController)
+				wg.Wait()
+				ExpectSingletonReconciled(ctx, queue)
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				Expect(nodeClaims[0].Name).ToNot(Equal(nodeClaim.Name))
+				requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...)
+				Expect(requirements.Get(v1.CapacityTypeLabelKey).Values()).To(ConsistOf(v1.CapacityTypeReserved))
+				Expect(requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf(reservedInstance.Name))
+				ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			},
+			Entry("if the candidate is on-demand node", v1.CapacityTypeOnDemand),
+			Entry("if the candidate is spot node", v1.CapacityTypeSpot),
+		)
+		It("won't replace node with reserved capacity when capacity reservations are disabled", func() {
+			currentInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "current-on-demand",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        0.5,
+						Available:    math.MaxInt,
+					},
+				},
+			})
+			reservedInstance := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "reserved-replacement",
+				Offerings: []cloudprovider.Offering{
+					{
+						Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeReserved, corev1.LabelTopologyZone: "test-zone-1a"}),
+						Price:        1.0,
+						Available:    1,
+					},
+				},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{currentInstance, reservedInstance}
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: currentInstance.Name,
+						v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone:       "test-zone-1a",
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+			ExpectApplied(ctx, env.Client, rs, pod, nodeClaim, node, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, node)
+		})
 	})
 	Context("Delete", func() {
 		var nodeClaims []*v1.NodeClaim
@@ -27,7 +27,10 @@ import (
 
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
+	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -41,6 +44,7 @@ import (
 
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	disruptionevents "github.com/dcoppa/karpenter/pkg/controllers/disruption/events"
 	"github.com/dcoppa/karpenter/pkg/controllers/disruption/orchestration"
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
@@ -49,6 +53,7 @@ import (
 	"github.com/dcoppa/karpenter/pkg/metrics"
 	"github.com/dcoppa/karpenter/pkg/operator/injection"
 	operatorlogging "github.com/dcoppa/karpenter/pkg/operator/logging"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
 )
 
 type Controller struct {
@@ -62,6 +67,10 @@ type Controller struct {
 	methods       []Method
 	mu            sync.Mutex
 	lastRun       map[string]time.Time
+	// doNotDisruptDeadline is the next time at which a karpenter.sh/do-not-disrupt annotation stops protecting a node
+	doNotDisruptDeadline time.Time
+	// approvalDeferredUntil is the time until which the external approver asked Karpenter to hold off disrupting
+	approvalDeferredUntil time.Time
 }
 
 // pollingPeriod that we inspect cluster to look for opportunities to disrupt
@@ -82,6 +91,8 @@ func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provi
 		cloudProvider: cp,
 		lastRun:       map[string]time.Time{},
 		methods: []Method{
+			// Replace any NodeClaims that have expired before they are deleted forcefully at the end of their grace period.
+			NewExpiration(clk, kubeClient, cluster, provisioner, recorder),
 			// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
 			NewDrift(kubeClient, cluster, provisioner, recorder),
 			// Delete any empty NodeClaims as there is zero cost in terms of disruption.
@@ -121,11 +132,18 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
 
+	// Rebuild the orchestration queue from the commands that were in-flight before Karpenter restarted, so that their
+	// candidates stay tainted and their replacements are waited on rather than orphaned.
+	if err := c.queue.Restore(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("restoring orchestration queue, %w", err)
+	}
+
 	// Karpenter taints nodes with a karpenter.sh/disruption taint as part of the disruption process while it progresses in memory.
 	// If Karpenter restarts or fails with an error during a disruption action, some nodes can be left tainted.
-	// Idempotently remove this taint from candidates that are not in the orchestration queue before continuing.
+	// Idempotently remove this taint from candidates that are not in the orchestration queue before continuing. Nodes that
+	// are marked for deletion, e.g. by the interruption controller while their replacements launch, keep the taint.
 	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, false, lo.Filter(c.cluster.Nodes(), func(s *state.StateNode, _ int) bool {
-		return !c.queue.HasAny(s.ProviderID())
+		return !c.queue.HasAny(s.ProviderID()) && !s.MarkedForDeletion()
 	})...); err != nil {
 		if errors.IsConflict(err) {
 			return reconcile.Result{Requeue: true}, nil
@@ -133,10 +151,24 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		return reconcile.Result{}, fmt.Errorf("removing taint %s from nodes, %w", pretty.Taint(v1.DisruptedNoScheduleTaint), err)
 	}
 
+	// A karpenter.sh/do-not-disrupt annotation that stops protecting a node doesn't change the cluster state, so we mark
+	// the cluster as unconsolidated for consolidation to consider the node again
+	if !c.doNotDisruptDeadline.IsZero() && !c.clock.Now().Before(c.doNotDisruptDeadline) {
+		c.cluster.MarkUnconsolidated()
+		c.doNotDisruptDeadline = time.Time{}
+	}
+
+	// The external approver deferred a command, so hold off disrupting until it asked to be called again
+	if c.approvalDeferredUntil.After(c.clock.Now()) {
+		return reconcile.Result{RequeueAfter: c.approvalDeferredUntil.Sub(c.clock.Now())}, nil
+	}
+
 	// Attempt different disruption methods. We'll only let one method perform an action
+	var doNotDisruptDeadline time.Time
 	for _, m := range c.methods {
 		c.recordRun(fmt.Sprintf("%T", m))
-		success, err := c.disrupt(ctx, m)
+		success, deadline, err := c.disrupt(ctx, m)
+		doNotDisruptDeadline = earliest(doNotDisruptDeadline, deadline)
 		if err != nil {
 			if errors.IsConflict(err) {
 				return reconcile.Result{Requeue: true}, nil
@@ -146,20 +178,30 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 		if success {
 			return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 		}
+		if c.approvalDeferredUntil.After(c.clock.Now()) {
+			return reconcile.Result{RequeueAfter: c.approvalDeferredUntil.Sub(c.clock.Now())}, nil
+		}
 	}
 
-	// All methods did nothing, so return nothing to do
+	// All methods did nothing, so return nothing to do. If a karpenter.sh/do-not-disrupt annotation stops protecting a
+	// node before the next poll, requeue right when it does.
+	c.doNotDisruptDeadline = doNotDisruptDeadline
+	if !c.doNotDisruptDeadline.IsZero() && c.doNotDisruptDeadline.Sub(c.clock.Now()) < pollingPeriod {
+		return reconcile.Result{RequeueAfter: c.doNotDisruptDeadline.Sub(c.clock.Now())}, nil
+	}
 	return reconcile.Result{RequeueAfter: pollingPeriod}, nil
 }
 
-func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, error) {
+// disrupt computes and executes the command of the disruption method. It also returns the earliest time in the future
+// at which a karpenter.sh/do-not-disrupt annotation stops blocking the disruption of a node, or zero if there is none.
+func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, time.Time, error) {
 	defer metrics.Measure(EvaluationDurationSeconds, map[string]string{
 		metrics.ReasonLabel:    strings.ToLower(string(disruption.Reason())),
 		consolidationTypeLabel: disruption.ConsolidationType(),
 	})()
-	candidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue)
+	candidates, doNotDisruptDeadline, err := getCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue)
 	if err != nil {
-		return false, fmt.Errorf("determining candidates, %w", err)
+		return false, time.Time{}, fmt.Errorf("determining candidates, %w", err)
 	}
 	EligibleNodes.Set(float64(len(candidates)), map[string]string{
 		metrics.ReasonLabel: strings.ToLower(string(disruption.Reason())),
@@ -167,34 +209,46 @@ func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, erro
 
 	// If there are no candidates, move to the next disruption
 	if len(candidates) == 0 {
-		return false, nil
+		return false, doNotDisruptDeadline, nil
 	}
 	disruptionBudgetMapping, err := BuildDisruptionBudgetMapping(ctx, c.cluster, c.clock, c.kubeClient, c.cloudProvider, c.recorder, disruption.Reason())
 	if err != nil {
-		return false, fmt.Errorf("building disruption budgets, %w", err)
+		return false, doNotDisruptDeadline, fmt.Errorf("building disruption budgets, %w", err)
 	}
 	// Determine the disruption action
 	cmd, schedulingResults, err := disruption.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
 	if err != nil {
-		return false, fmt.Errorf("computing disruption decision, %w", err)
+		return false, doNotDisruptDeadline, fmt.Errorf("computing disruption decision, %w", err)
 	}
 	if cmd.Decision() == NoOpDecision {
-		return false, nil
+		return false, doNotDisruptDeadline, nil
 	}
 
-	// Attempt to disrupt
-	if err := c.executeCommand(ctx, disruption, cmd, schedulingResults); err != nil {
-		return false, fmt.Errorf("disrupting candidates, %w", err)
+	// Attempt to disrupt. A dry-run command or a command that wasn't approved doesn't change the cluster, so give the
+	// next disruption method a chance to compute its command
+	executed, err := c.executeCommand(ctx, disruption, cmd, schedulingResults)
+	if err != nil {
+		return false, doNotDisruptDeadline, fmt.Errorf("disrupting candidates, %w", err)
 	}
-	return true, nil
+	return executed, doNotDisruptDeadline, nil
 }
 
 // executeCommand will do the following, untainting if the step fails.
-// 1. Taint candidate nodes
-// 2. Spin up replacement nodes
-// 3. Add Command to orchestration.Queue to wait to delete the candiates.
-func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command, schedulingResults scheduling.Results) error {
+// 1. Request approval from the external approver, if configured
+// 2. Taint candidate nodes
+// 3. Spin up replacement nodes
+// 4. Add Command to orchestration.Queue to wait to delete the candiates.
+// In dry-run mode, the command is only recorded and none of these steps are performed. It returns whether the
+// command was executed.
+func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command, schedulingResults scheduling.Results) (bool, error) {
+	if isDryRun(ctx, cmd) {
+		c.recordDryRun(ctx, m, cmd)
+		return false, nil
+	}
 	commandID := uuid.NewUUID()
+	if !c.approve(ctx, commandID, m, cmd) {
+		return false, nil
+	}
 	log.FromContext(ctx).WithValues("command-id", commandID, "reason", strings.ToLower(string(m.Reason()))).Info(fmt.Sprintf("disrupting nodeclaim(s) via %s", cmd))
 
 	stateNodes := lo.Map(cmd.candidates, func(c *Candidate, _ int) *state.StateNode {
@@ -202,7 +256,7 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	})
 	// Cordon the old nodes before we launch the replacements to prevent new pods from scheduling to the old nodes
 	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, true, stateNodes...); err != nil {
-		return fmt.Errorf("tainting nodes with %s (command-id: %s), %w", pretty.Taint(v1.DisruptedNoScheduleTaint), commandID, err)
+		return false, fmt.Errorf("tainting nodes with %s (command-id: %s), %w", pretty.Taint(v1.DisruptedNoScheduleTaint), commandID, err)
 	}
 
 	var nodeClaimNames []string
@@ -211,7 +265,7 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 		if nodeClaimNames, err = c.createReplacementNodeClaims(ctx, m, cmd); err != nil {
 			// If we failed to launch the replacement, don't disrupt.  If this is some permanent failure,
 			// we don't want to disrupt workloads with no way to provision new nodes for them.
-			return fmt.Errorf("launching replacement nodeclaim (command-id: %s), %w", commandID, err)
+			return false, fmt.Errorf("launching replacement nodeclaim (command-id: %s), %w", commandID, err)
 		}
 	}
 
@@ -230,10 +284,10 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	// We have the new NodeClaims created at the API server so mark the old NodeClaims for deletion
 	c.cluster.MarkForDeletion(providerIDs...)
 
-	if err = c.queue.Add(orchestration.NewCommand(nodeClaimNames,
+	if err = c.queue.Add(ctx, orchestration.NewCommand(nodeClaimNames,
 		lo.Map(cmd.candidates, func(c *Candidate, _ int) *state.StateNode { return c.StateNode }), commandID, m.Reason(), m.ConsolidationType())); err != nil {
 		c.cluster.UnmarkForDeletion(providerIDs...)
-		return fmt.Errorf("adding command to queue (command-id: %s), %w", commandID, err)
+		return false, fmt.Errorf("adding command to queue (command-id: %s), %w", commandID, err)
 	}
 
 	// An action is only performed and pods/nodes are only disrupted after a successful add to the queue
@@ -241,15 +295,80 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 		decisionLabel:          string(cmd.Decision()),
 		metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
 		consolidationTypeLabel: m.ConsolidationType(),
+		dryRunLabel:            "false",
+	})
+	return true, nil
+}
+
+// approve requests approval of the command from the external approver. A denied command is reported on its
+// candidates, a deferred command pauses disruption until the approver asks to be called again.
+func (c *Controller) approve(ctx context.Context, commandID types.UID, m Method, cmd Command) bool {
+	if options.FromContext(ctx).DisruptionApprovalURL == "" {
+		return true
+	}
+	resp := requestApproval(ctx, NewApprovalRequest(commandID, m, cmd))
+	ApprovalDecisionsTotal.Inc(map[string]string{
+		decisionLabel:       string(resp.Decision),
+		metrics.ReasonLabel: strings.ToLower(string(m.Reason())),
+	})
+	logger := log.FromContext(ctx).WithValues("command-id", commandID, "reason", strings.ToLower(string(m.Reason())), "approval-reason", resp.Reason)
+	switch resp.Decision {
+	case ApprovalDecisionDeny:
+		logger.Info(fmt.Sprintf("disruption denied by approver, %s", cmd))
+		for _, candidate := range cmd.candidates {
+			c.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, fmt.Sprintf("Disruption denied by approver (%s)", resp.Reason))...)
+		}
+		return false
+	case ApprovalDecisionDefer:
+		retryAfter := pollingPeriod
+		if resp.RetryAfter != nil && resp.RetryAfter.Duration > 0 {
+			retryAfter = resp.RetryAfter.Duration
+		}
+		c.approvalDeferredUntil = c.clock.Now().Add(retryAfter)
+		logger.WithValues("retry-after", retryAfter).Info(fmt.Sprintf("disruption deferred by approver, %s", cmd))
+		return false
+	default:
+		return true
+	}
+}
+
+// isDryRun returns true if disruption runs in dry-run mode globally or if any of the candidates of the command belong to
+// a NodePool that is annotated for dry-run, since executing the command would disrupt nodes of that NodePool.
+func isDryRun(ctx context.Context, cmd Command) bool {
+	if options.FromContext(ctx).DisruptionDryRun {
+		return true
+	}
+	return lo.ContainsBy(cmd.candidates, func(c *Candidate) bool {
+		return c.nodePool.Annotations[v1.DisruptionDryRunAnnotationKey] == "true"
+	})
+}
+
+// recordDryRun records the command that would have been executed through events, logs and metrics
+func (c *Controller) recordDryRun(ctx context.Context, m Method, cmd Command) {
+	savings := fmt.Sprintf("%.4f", cmd.EstimatedSavings())
+	log.FromContext(ctx).WithValues(
+		"reason", strings.ToLower(string(m.Reason())),
+		"decision", cmd.Decision(),
+		"estimated-savings", savings,
+	).Info(fmt.Sprintf("dry-run, would disrupt nodeclaim(s) via %s", cmd))
+	for _, candidate := range cmd.candidates {
+		c.recorder.Publish(disruptionevents.DryRun(candidate.Node, candidate.NodeClaim, string(m.Reason()), cmd.String(), savings)...)
+	}
+	DecisionsPerformedTotal.Inc(map[string]string{
+		decisionLabel:          string(cmd.Decision()),
+		metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
+		consolidationTypeLabel: m.ConsolidationType(),
+		dryRunLabel:            "true",
 	})
-	return nil
 }
 
 // createReplacementNodeClaims creates replacement NodeClaims
 func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method, cmd Command) ([]string, error) {
 	nodeClaimNames, err := c.provisioner.CreateNodeClaims(ctx, cmd.replacements, provisioning.WithReason(strings.ToLower(string(m.Reason()))))
 	if err != nil {
-		return nil, err
+		// Replacements are launched all-or-nothing, so we delete the replacements that were created rather than leaving
+		// part of the replacement running next to the candidates
+		return nil, multierr.Combine(err, c.rollbackReplacements(ctx, nodeClaimNames))
 	}
 	if len(nodeClaimNames) != len(cmd.replacements) {
 		// shouldn't ever occur since a partially failed CreateNodeClaims should return an error
@@ -258,6 +377,17 @@ func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method,
 	return nodeClaimNames, nil
 }
 
+// rollbackReplacements deletes the replacement NodeClaims that were created for a command that failed to launch
+func (c *Controller) rollbackReplacements(ctx context.Context, nodeClaimNames []string) error {
+	var multiErr error
+	for _, name := range lo.Compact(nodeClaimNames) {
+		if err := c.kubeClient.Delete(ctx, &v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}}); client.IgnoreNotFound(err) != nil {
+			multiErr = multierr.Append(multiErr, fmt.Errorf("deleting replacement nodeclaim %s, %w", name, err))
+		}
+	}
+	return multiErr
+}
+
 func (c *Controller) recordRun(s string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -19,8 +19,10 @@ package disruption
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 
+	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
@@ -59,7 +61,65 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 		return candidates[i].NodeClaim.StatusConditions().Get(string(d.Reason())).LastTransitionTime.Time.Before(
 			candidates[j].NodeClaim.StatusConditions().Get(string(d.Reason())).LastTransitionTime.Time)
 	})
+	surgeMapping, unavailableMapping := d.rollingUpdateMappings(candidates)
+	return computeReplaceCommand(ctx, d.kubeClient, d.cluster, d.provisioner, d.recorder, disruptionBudgetMapping, surgeMapping, unavailableMapping, candidates)
+}
+
+// rollingUpdateMappings returns the number of NodeClaims that can still be launched and the number of nodes that can
+// still become unavailable for each NodePool of the candidates that has a rolling update. A node that is replaced
+// isn't unavailable until its replacements are initialized, so the nodes that are marked for deletion while
+// replacements are launching aren't counted as unavailable.
+func (d *Drift) rollingUpdateMappings(candidates []*Candidate) (map[string]int, map[string]int) {
+	nodePools := map[string]*v1.NodePool{}
+	for _, candidate := range candidates {
+		if candidate.nodePool.Spec.Disruption.RollingUpdate != nil {
+			nodePools[candidate.nodePool.Name] = candidate.nodePool
+		}
+	}
+	if len(nodePools) == 0 {
+		return nil, nil
+	}
+	total, launching, deleting := map[string]int{}, map[string]int{}, map[string]int{}
+	d.cluster.ForEachNode(func(n *state.StateNode) bool {
+		name := n.Labels()[v1.NodePoolLabelKey]
+		if _, ok := nodePools[name]; !ok || !n.Managed() {
+			return true
+		}
+		total[name]++
+		switch {
+		case n.MarkedForDeletion():
+			deleting[name]++
+		case !n.Initialized():
+			launching[name]++
+		}
+		return true
+	})
+	surgeMapping, unavailableMapping := map[string]int{}, map[string]int{}
+	for name, nodePool := range nodePools {
+		// If the rolling update is misconfigured, fail closed. This should never happen since it is validated when the
+		// nodepool is applied.
+		maxSurge, err := nodePool.Spec.Disruption.RollingUpdate.GetMaxSurge(total[name])
+		if err != nil {
+			maxSurge = 0
+		}
+		maxUnavailable, err := nodePool.Spec.Disruption.RollingUpdate.GetMaxUnavailable(total[name])
+		if err != nil {
+			maxUnavailable = 0
+		}
+		surgeMapping[name] = lo.Max([]int{maxSurge - launching[name], 0})
+		unavailableMapping[name] = lo.Max([]int{maxUnavailable - lo.Max([]int{deleting[name] - launching[name], 0}), 0})
+	}
+	return surgeMapping, unavailableMapping
+}
 
+// computeReplaceCommand computes the command of the eventual disruption methods, which replace their candidates one at a
+// time in the given order once the candidates are no longer wanted. All the empty candidates are deleted together.
+// The surge mapping limits the number of replacements that can be launched for the candidates of a NodePool, and the
+// unavailable mapping limits the number of candidates of a NodePool that can be deleted without replacements.
+// NodePools that aren't in them aren't limited.
+func computeReplaceCommand(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
+	recorder events.Recorder, disruptionBudgetMapping map[string]int, surgeMapping map[string]int, unavailableMapping map[string]int,
+	candidates []*Candidate) (Command, scheduling.Results, error) {
 	// Do a quick check through the candidates to see if they're empty.
 	// For each candidate that is empty with a nodePool allowing its disruption
 	// add it to the existing command.
@@ -70,12 +130,15 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 		}
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
-		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
+		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 && limitAllows(unavailableMapping, candidate.nodePool.Name, 1) {
 			empty = append(empty, candidate)
 			disruptionBudgetMapping[candidate.nodePool.Name]--
+			if _, ok := unavailableMapping[candidate.nodePool.Name]; ok {
+				unavailableMapping[candidate.nodePool.Name]--
+			}
 		}
 	}
-	// Disrupt all empty drifted candidates, as they require no scheduling simulations.
+	// Disrupt all empty candidates, as they require no scheduling simulations.
 	if len(empty) > 0 {
 		return Command{
 			candidates: empty,
@@ -85,12 +148,27 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 	for _, candidate := range candidates {
 		// If the disruption budget doesn't allow this candidate to be disrupted,
 		// continue to the next candidate. We don't need to decrement any budget
-		// counter since drift commands can only have one candidate.
+		// counter since these commands can only have one candidate.
 		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
 			continue
 		}
+		// Static NodePools keep a fixed number of NodeClaims, so a candidate is replaced with a NodeClaim from the
+		// template of its NodePool rather than with the capacity that its pods need
+		if candidate.nodePool.IsStatic() {
+			if !limitAllows(surgeMapping, candidate.nodePool.Name, 1) {
+				continue
+			}
+			replacement, err := provisioner.NewStaticNodeClaim(ctx, candidate.nodePool)
+			if err != nil {
+				return Command{}, scheduling.Results{}, fmt.Errorf("creating replacement for static nodepool %q, %w", candidate.nodePool.Name, err)
+			}
+			return Command{
+				candidates:   []*Candidate{candidate},
+				replacements: []*scheduling.NodeClaim{replacement},
+			}, scheduling.Results{NewNodeClaims: []*scheduling.NodeClaim{replacement}}, nil
+		}
 		// Check if we need to create any NodeClaims.
-		results, err := SimulateScheduling(ctx, d.kubeClient, d.cluster, d.provisioner, candidate)
+		results, err := SimulateScheduling(ctx, kubeClient, cluster, provisioner, candidate)
 		if err != nil {
 			// if a candidate is now deleting, just retry
 			if errors.Is(err, errCandidateDeleting) {
@@ -100,7 +178,15 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 		}
 		// Emit an event that we couldn't reschedule the pods on the node.
 		if !results.AllNonPendingPodsScheduled() {
-			d.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, results.NonPendingPodSchedulingErrors())...)
+			recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, results.NonPendingPodSchedulingErrors())...)
+			continue
+		}
+		// The replacements would launch more NodeClaims than the rolling update of the NodePool allows
+		if !limitAllows(surgeMapping, candidate.nodePool.Name, len(results.NewNodeClaims)) {
+			continue
+		}
+		// Without replacements, the candidate becomes unavailable as soon as it is disrupted
+		if len(results.NewNodeClaims) == 0 && !limitAllows(unavailableMapping, candidate.nodePool.Name, 1) {
 			continue
 		}
 
@@ -112,6 +198,11 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 	return Command{}, scheduling.Results{}, nil
 }
 
+func limitAllows(mapping map[string]int, nodePoolName string, n int) bool {
+	limit, ok := mapping[nodePoolName]
+	return !ok || n <= limit
+}
+
 func (d *Drift) Reason() v1.DisruptionReason {
 	return v1.DisruptionReasonDrifted
 }
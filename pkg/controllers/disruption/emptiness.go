@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -47,6 +48,11 @@ func (e *Emptiness) ShouldDisrupt(_ context.Context, c *Candidate) bool {
 		e.recorder.Publish(disruptionevents.Unconsolidatable(c.Node, c.NodeClaim, fmt.Sprintf("NodePool %q has consolidation disabled", c.nodePool.Name))...)
 		return false
 	}
+	// Static NodePools keep a fixed number of NodeClaims, so their NodeClaims are never consolidated
+	if c.nodePool.IsStatic() {
+		e.recorder.Publish(disruptionevents.Unconsolidatable(c.Node, c.NodeClaim, fmt.Sprintf("NodePool %q is static", c.nodePool.Name))...)
+		return false
+	}
 	// return true if there are no pods and the nodeclaim is consolidatable
 	return len(c.reschedulablePods) == 0 && c.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
 }
@@ -71,6 +77,21 @@ func (e *Emptiness) ComputeCommand(ctx context.Context, disruptionBudgetMapping
 			constrainedByBudgets = true
 			continue
 		}
+		// Empty nodes may be holding the headroom or the capacity floor of their NodePool, in which case removing them
+		// would only cause the provisioner to launch them again
+		if candidate.nodePool.Spec.Headroom != nil || len(candidate.nodePool.Spec.CapacityFloors) > 0 {
+			fits, err := headroomFits(ctx, e.kubeClient, e.cluster, e.provisioner, append(slices.Clone(empty), candidate)...)
+			if err != nil {
+				if errors.Is(err, errCandidateDeleting) {
+					continue
+				}
+				return Command{}, scheduling.Results{}, err
+			}
+			if !fits {
+				e.recorder.Publish(disruptionevents.Unconsolidatable(candidate.Node, candidate.NodeClaim, fmt.Sprintf("Node holds the headroom or capacity floor of NodePool %q", candidate.nodePool.Name))...)
+				continue
+			}
+		}
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
 		empty = append(empty, candidate)
@@ -469,6 +469,91 @@ var _ = Describe("Emptiness", func() {
 			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
 			ExpectExists(ctx, env.Client, nodeClaim)
 		})
+		It("should ignore nodes of static nodepools", func() {
+			nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete an empty node that holds the headroom of its nodepool", func() {
+			nodePool.Spec.Headroom = &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should not delete an empty node that holds the capacity floor of its nodepool", func() {
+			nodePool.Spec.CapacityFloors = []v1.CapacityFloor{{Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should delete empty nodes while the headroom of their nodepool fits on the remaining nodes", func() {
+			nodePool.Spec.Headroom = &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, nodeClaim2, node2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+			wg := sync.WaitGroup{}
+			ExpectToWait(&wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Only one of the nodes is deleted, the other one holds the headroom
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should ignore the headroom of other nodepools", func() {
+			nodePool.Spec.Headroom = &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}
+			// The headroom of the other nodepool doesn't fit on any node, so it needs new capacity in every simulation
+			otherNodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				Headroom: &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			}})
+			ExpectApplied(ctx, env.Client, nodePool, otherNodePool, nodeClaim, node, nodeClaim2, node2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+			wg := sync.WaitGroup{}
+			ExpectToWait(&wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+			ExpectSingletonReconciled(ctx, queue)
+
+			// One of the nodes is deleted, the other one holds the headroom of its nodepool
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
 	})
 	It("can delete multiple empty nodes", func() {
 		ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
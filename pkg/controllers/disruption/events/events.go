@@ -67,6 +67,27 @@ func Terminating(node *corev1.Node, nodeClaim *v1.NodeClaim, reason string) []ev
 	}
 }
 
+// DryRun is an event that informs the user that a NodeClaim/Node combination would have been disrupted if disruption
+// wasn't running in dry-run mode
+func DryRun(node *corev1.Node, nodeClaim *v1.NodeClaim, reason, command, estimatedSavings string) []events.Event {
+	return []events.Event{
+		{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionDryRun",
+			Message:        fmt.Sprintf("Would disrupt Node: %s, %s, estimated savings %s", cases.Title(language.Und, cases.NoLower).String(reason), command, estimatedSavings),
+			DedupeValues:   []string{string(node.UID), reason},
+		},
+		{
+			InvolvedObject: nodeClaim,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionDryRun",
+			Message:        fmt.Sprintf("Would disrupt NodeClaim: %s, %s, estimated savings %s", cases.Title(language.Und, cases.NoLower).String(reason), command, estimatedSavings),
+			DedupeValues:   []string{string(nodeClaim.UID), reason},
+		},
+	}
+}
+
 // Unconsolidatable is an event that informs the user that a NodeClaim/Node combination cannot be consolidated
 // due to the state of the NodeClaim/Node or due to some state of the pods that are scheduled to the NodeClaim/Node
 func Unconsolidatable(node *corev1.Node, nodeClaim *v1.NodeClaim, reason string) []events.Event {
@@ -0,0 +1,79 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	"github.com/dcoppa/karpenter/pkg/events"
+	disruptionutils "github.com/dcoppa/karpenter/pkg/utils/disruption"
+)
+
+// Expiration is a subreconciler that replaces the expired candidates of NodePools with an expiration grace period. The
+// candidates that it doesn't replace within the grace period are deleted forcefully by the expiration controller.
+type Expiration struct {
+	clock       clock.Clock
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	provisioner *provisioning.Provisioner
+	recorder    events.Recorder
+}
+
+func NewExpiration(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Expiration {
+	return &Expiration{
+		clock:       clk,
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		provisioner: provisioner,
+		recorder:    recorder,
+	}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (e *Expiration) ShouldDisrupt(_ context.Context, c *Candidate) bool {
+	return c.nodePool.Spec.Disruption.ExpirationGracePeriod != nil && disruptionutils.IsExpired(e.clock, c.NodeClaim)
+}
+
+// ComputeCommand generates a disruption command given candidates
+func (e *Expiration) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	// Replace the candidates that expired first, since they are the closest to being deleted forcefully
+	sort.Slice(candidates, func(i int, j int) bool {
+		return candidates[i].NodeClaim.CreationTimestamp.Add(*candidates[i].NodeClaim.Spec.ExpireAfter.Duration).Before(
+			candidates[j].NodeClaim.CreationTimestamp.Add(*candidates[j].NodeClaim.Spec.ExpireAfter.Duration))
+	})
+	return computeReplaceCommand(ctx, e.kubeClient, e.cluster, e.provisioner, e.recorder, disruptionBudgetMapping, nil, nil, candidates)
+}
+
+func (e *Expiration) Reason() v1.DisruptionReason {
+	return v1.DisruptionReasonExpired
+}
+
+func (e *Expiration) Class() string {
+	return EventualDisruptionClass
+}
+
+func (e *Expiration) ConsolidationType() string {
+	return ""
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +39,7 @@ import (
 	"github.com/dcoppa/karpenter/pkg/events"
 	"github.com/dcoppa/karpenter/pkg/metrics"
 	operatorlogging "github.com/dcoppa/karpenter/pkg/operator/logging"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
 	nodeutils "github.com/dcoppa/karpenter/pkg/utils/node"
 	nodepoolutils "github.com/dcoppa/karpenter/pkg/utils/nodepool"
 	"github.com/dcoppa/karpenter/pkg/utils/pdb"
@@ -45,6 +47,18 @@ import (
 
 var errCandidateDeleting = fmt.Errorf("candidate is deleting")
 
+// maxSimulateSchedulingTimeout is the maximum amount of time that a single scheduling simulation can take when
+// evaluating disruption candidates
+const maxSimulateSchedulingTimeout = 30 * time.Second
+
+// SimulateSchedulingTimeout returns the amount of time that a single scheduling simulation can take when evaluating
+// disruption candidates. This is half of the solve timeout used for provisioning, capped at 30 seconds, since
+// consolidation runs many simulations per loop, and a simulation that times out can't be used to make a disruption
+// decision.
+func SimulateSchedulingTimeout(ctx context.Context) time.Duration {
+	return lo.Min([]time.Duration{options.FromContext(ctx).SolveTimeout / 2, maxSimulateSchedulingTimeout})
+}
+
 //nolint:gocyclo
 func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
 	candidates ...*Candidate,
@@ -79,6 +93,13 @@ func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *
 		pods = append(pods, n.reschedulablePods...)
 	}
 	pods = append(pods, deletingNodePods...)
+	// The headroom and the capacity floors of the NodePools are load that must still fit after the candidates are
+	// removed. The usage of the candidates still counts towards them, since their pods are rescheduled.
+	headroomPods, err := provisioner.GetHeadroomPods(ctx, nodes.Active())
+	if err != nil {
+		return pscheduling.Results{}, fmt.Errorf("determining headroom pods, %w", err)
+	}
+	pods = append(pods, headroomPods...)
 	scheduler, err := provisioner.NewScheduler(log.IntoContext(ctx, operatorlogging.NopLogger), pods, stateNodes)
 	if err != nil {
 		return pscheduling.Results{}, fmt.Errorf("creating scheduler, %w", err)
@@ -88,7 +109,7 @@ func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *
 		return client.ObjectKeyFromObject(p), nil
 	})
 
-	results := scheduler.Solve(log.IntoContext(ctx, operatorlogging.NopLogger), pods).TruncateInstanceTypes(pscheduling.MaxInstanceTypes)
+	results := scheduler.Solve(log.IntoContext(ctx, operatorlogging.NopLogger), pods, pscheduling.WithTimeout(SimulateSchedulingTimeout(ctx))).TruncateInstanceTypes(pscheduling.MaxInstanceTypes)
 	for _, n := range results.ExistingNodes {
 		// We consider existing nodes for scheduling. When these nodes are unmanaged, their taint logic should
 		// tell us if we can schedule to them or not; however, if these nodes are managed, we will still schedule to them
@@ -112,6 +133,20 @@ func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *
 	return results, nil
 }
 
+// headroomFits returns true if the headroom and the capacity floors of the NodePools of the candidates still fit on the
+// remaining nodes after the candidates are removed, without launching new capacity for them. The headroom of other
+// NodePools doesn't depend on the candidates, so it's ignored.
+func headroomFits(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, candidates ...*Candidate) (bool, error) {
+	results, err := SimulateScheduling(ctx, kubeClient, cluster, provisioner, candidates...)
+	if err != nil {
+		return false, err
+	}
+	nodePools := sets.New(lo.Map(candidates, func(c *Candidate, _ int) string { return c.nodePool.Name })...)
+	return !lo.ContainsBy(results.NewNodeClaims, func(n *pscheduling.NodeClaim) bool {
+		return lo.ContainsBy(n.Pods, func(p *corev1.Pod) bool { return nodePools.Has(provisioning.HeadroomPodNodePool(p)) })
+	}), nil
+}
+
 // UninitializedNodeError tracks a special pod error for disruption where pods schedule to a node
 // that hasn't been initialized yet, meaning that we can't be confident to make a disruption decision based off of it
 type UninitializedNodeError struct {
@@ -140,24 +175,64 @@ func instanceTypesAreSubset(lhs []*cloudprovider.InstanceType, rhs []*cloudprovi
 	return len(rhsNames.Intersection(lhsNames)) == len(lhsNames)
 }
 
+// replacementsAreSubsets returns true if the instance types of each replacement are a subset of the instance types of a
+// distinct NodeClaim of the scheduling simulation
+func replacementsAreSubsets(replacements []*pscheduling.NodeClaim, nodeClaims []*pscheduling.NodeClaim) bool {
+	matched := make([]bool, len(nodeClaims))
+	for _, replacement := range replacements {
+		found := false
+		for i, nodeClaim := range nodeClaims {
+			if !matched[i] && instanceTypesAreSubset(replacement.InstanceTypeOptions, nodeClaim.InstanceTypeOptions) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // GetCandidates returns nodes that appear to be currently deprovisionable based off of their nodePool
 func GetCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
 	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
 ) ([]*Candidate, error) {
+	candidates, _, err := getCandidates(ctx, cluster, kubeClient, recorder, clk, cloudProvider, shouldDisrupt, disruptionClass, queue)
+	return candidates, err
+}
+
+// getCandidates returns the candidates like GetCandidates, along with the earliest time in the future at which a
+// karpenter.sh/do-not-disrupt annotation stops blocking the disruption of a node. The time is zero if there is none.
+func getCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
+	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
+) ([]*Candidate, time.Time, error) {
 	nodePoolMap, nodePoolToInstanceTypesMap, err := BuildNodePoolMap(ctx, kubeClient, cloudProvider)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 	pdbs, err := pdb.NewLimits(ctx, clk, kubeClient)
 	if err != nil {
-		return nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+		return nil, time.Time{}, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
 	}
+	var doNotDisruptDeadline time.Time
 	candidates := lo.FilterMap(cluster.Nodes(), func(n *state.StateNode, _ int) (*Candidate, bool) {
 		cn, e := NewCandidate(ctx, kubeClient, recorder, clk, n, pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue, disruptionClass)
+		if deadline, ok := state.DoNotDisruptDeadline(e); ok && deadline.After(clk.Now()) {
+			doNotDisruptDeadline = earliest(doNotDisruptDeadline, deadline)
+		}
 		return cn, e == nil
 	})
 	// Filter only the valid candidates that we should disrupt
-	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDisrupt(ctx, c) }), nil
+	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDisrupt(ctx, c) }), doNotDisruptDeadline, nil
+}
+
+// earliest returns the earliest of two times, where a zero time is unset
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
 }
 
 // BuildNodePoolMap builds a provName -> nodePool map and a provName -> instanceName -> instance type map
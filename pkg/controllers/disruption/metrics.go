@@ -28,6 +28,7 @@ const (
 	voluntaryDisruptionSubsystem = "voluntary_disruption"
 	decisionLabel                = "decision"
 	consolidationTypeLabel       = "consolidation_type"
+	dryRunLabel                  = "dry_run"
 )
 
 var (
@@ -42,15 +43,27 @@ var (
 		},
 		[]string{metrics.ReasonLabel, consolidationTypeLabel},
 	)
+	// DecisionsPerformedTotal doesn't label decisions by their command or estimated savings since those are unbounded.
+	// They're only reported through the events and logs of dry-run decisions.
 	DecisionsPerformedTotal = opmetrics.NewPrometheusCounter(
 		crmetrics.Registry,
 		prometheus.CounterOpts{
 			Namespace: metrics.Namespace,
 			Subsystem: voluntaryDisruptionSubsystem,
 			Name:      "decisions_total",
-			Help:      "Number of disruption decisions performed. Labeled by disruption decision, reason, and consolidation type.",
+			Help:      "Number of disruption decisions performed. Labeled by disruption decision, reason, consolidation type, and whether the decision was only computed in dry-run mode.",
 		},
-		[]string{decisionLabel, metrics.ReasonLabel, consolidationTypeLabel},
+		[]string{decisionLabel, metrics.ReasonLabel, consolidationTypeLabel, dryRunLabel},
+	)
+	ApprovalDecisionsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: voluntaryDisruptionSubsystem,
+			Name:      "approval_decisions_total",
+			Help:      "Number of disruption commands decided by the external approver. Labeled by approval decision and reason.",
+		},
+		[]string{decisionLabel, metrics.ReasonLabel},
 	)
 	EligibleNodes = opmetrics.NewPrometheusGauge(
 		crmetrics.Registry,
@@ -140,11 +140,17 @@ func (m *MultiNodeConsolidation) firstNConsolidationOption(ctx context.Context,
 		}
 
 		// ensure that the action is sensical for replacements, see explanation on filterOutSameType for why this is
-		// required
+		// required. Replacements onto free reserved capacity are always cheaper than the candidates, so they don't need
+		// to be filtered.
 		replacementHasValidInstanceTypes := false
-		if cmd.Decision() == ReplaceDecision {
-			cmd.replacements[0].InstanceTypeOptions, err = filterOutSameType(cmd.replacements[0], candidatesToConsolidate)
-			replacementHasValidInstanceTypes = len(cmd.replacements[0].InstanceTypeOptions) > 0 && err == nil
+		if cmd.Decision() == ReplaceDecision && len(cmd.replacements) == 1 && isReservedConsolidation(candidatesToConsolidate, cmd.replacements[0]) {
+			replacementHasValidInstanceTypes = true
+		} else if cmd.Decision() == ReplaceDecision {
+			replacementHasValidInstanceTypes = true
+			for _, replacement := range cmd.replacements {
+				replacement.InstanceTypeOptions, err = filterOutSameType(replacement, candidatesToConsolidate)
+				replacementHasValidInstanceTypes = replacementHasValidInstanceTypes && len(replacement.InstanceTypeOptions) > 0 && err == nil
+			}
 		}
 
 		// replacementHasValidInstanceTypes will be false if the replacement action has valid instance types remaining after filtering.
@@ -0,0 +1,162 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+)
+
+// checkpoint is the representation of a command that is persisted as an annotation on the candidate and replacement
+// NodeClaims of the command, so that the queue can be rebuilt when the controller restarts.
+type checkpoint struct {
+	ID                types.UID           `json:"id"`
+	Reason            v1.DisruptionReason `json:"reason"`
+	ConsolidationType string              `json:"consolidationType"`
+	Candidates        []string            `json:"candidates"`
+	Replacements      []string            `json:"replacements,omitempty"`
+	TimeAdded         metav1.Time         `json:"timeAdded"`
+}
+
+func (c *Command) checkpoint() checkpoint {
+	return checkpoint{
+		ID:                c.id,
+		Reason:            c.reason,
+		ConsolidationType: c.consolidationType,
+		Candidates:        lo.Map(c.candidates, func(s *state.StateNode, _ int) string { return s.NodeClaim.Name }),
+		Replacements:      lo.Map(c.Replacements, func(r Replacement, _ int) string { return r.name }),
+		TimeAdded:         metav1.NewTime(c.timeAdded),
+	}
+}
+
+// nodeClaimNames returns the names of all of the NodeClaims that the command is checkpointed to
+func (c *Command) nodeClaimNames() []string {
+	return append(lo.Map(c.candidates, func(s *state.StateNode, _ int) string { return s.NodeClaim.Name }),
+		lo.Map(c.Replacements, func(r Replacement, _ int) string { return r.name })...)
+}
+
+// persist checkpoints the command to the candidate and replacement NodeClaims
+func (q *Queue) persist(ctx context.Context, cmd *Command) error {
+	raw, err := json.Marshal(cmd.checkpoint())
+	if err != nil {
+		return fmt.Errorf("marshaling command, %w", err)
+	}
+	var errs error
+	for _, name := range cmd.nodeClaimNames() {
+		errs = multierr.Append(errs, q.patchCheckpoint(ctx, name, string(raw)))
+	}
+	return errs
+}
+
+// forget removes the checkpoint of the command from the candidate and replacement NodeClaims
+func (q *Queue) forget(ctx context.Context, cmd *Command) error {
+	var errs error
+	for _, name := range cmd.nodeClaimNames() {
+		errs = multierr.Append(errs, q.patchCheckpoint(ctx, name, ""))
+	}
+	return errs
+}
+
+// patchCheckpoint sets the checkpoint annotation of the NodeClaim to the given value, removing it if the value is empty.
+// NodeClaims that no longer exist are ignored, since the checkpoint doesn't need to be persisted for them.
+func (q *Queue) patchCheckpoint(ctx context.Context, name string, value string) error {
+	nodeClaim := &v1.NodeClaim{}
+	if err := q.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodeClaim); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	stored := nodeClaim.DeepCopy()
+	if value == "" {
+		delete(nodeClaim.Annotations, v1.DisruptionCommandAnnotationKey)
+	} else {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.DisruptionCommandAnnotationKey: value})
+	}
+	if equality.Semantic.DeepEqual(stored, nodeClaim) {
+		return nil
+	}
+	if err := q.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("patching nodeclaim %s, %w", name, err)
+	}
+	return nil
+}
+
+// Restore rebuilds the queue from the commands that are checkpointed to the NodeClaims in the cluster, so that commands
+// that were in-flight when the controller restarted keep waiting on their replacements rather than being abandoned.
+// Restore must only be called once cluster state is synced, and only restores the queue the first time it succeeds.
+func (q *Queue) Restore(ctx context.Context) error {
+	q.restoreMu.Lock()
+	defer q.restoreMu.Unlock()
+	if q.restored {
+		return nil
+	}
+	nodeClaimList := &v1.NodeClaimList{}
+	if err := q.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	checkpoints := map[types.UID]checkpoint{}
+	for _, nodeClaim := range nodeClaimList.Items {
+		raw, ok := nodeClaim.Annotations[v1.DisruptionCommandAnnotationKey]
+		if !ok {
+			continue
+		}
+		cp := checkpoint{}
+		if err := json.Unmarshal([]byte(raw), &cp); err != nil {
+			log.FromContext(ctx).WithValues("NodeClaim", client.ObjectKeyFromObject(&nodeClaim)).Error(err, "failed parsing disruption command checkpoint")
+			continue
+		}
+		checkpoints[cp.ID] = cp
+	}
+	stateNodes := lo.SliceToMap(lo.Filter(q.cluster.Nodes(), func(s *state.StateNode, _ int) bool { return s.NodeClaim != nil }),
+		func(s *state.StateNode) (string, *state.StateNode) { return s.NodeClaim.Name, s })
+
+	var errs error
+	for _, cp := range checkpoints {
+		cmd := NewCommand(cp.Replacements, lo.FilterMap(cp.Candidates, func(name string, _ int) (*state.StateNode, bool) {
+			s, ok := stateNodes[name]
+			return s, ok
+		}), cp.ID, cp.Reason, cp.ConsolidationType)
+		cmd.timeAdded = cp.TimeAdded.Time
+		// If none of the candidates exist anymore, the command finished before the checkpoint was removed
+		if len(cmd.candidates) == 0 {
+			errs = multierr.Append(errs, q.forget(ctx, cmd))
+			continue
+		}
+		// The command was already restored by a previous attempt that failed
+		if q.HasAny(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string { return s.ProviderID() })...) {
+			continue
+		}
+		log.FromContext(ctx).WithValues("command-id", string(cmd.id), "reason", cmd.reason).Info("restored disruption command")
+		q.cluster.MarkForDeletion(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string { return s.ProviderID() })...)
+		q.add(cmd)
+	}
+	if errs != nil {
+		return fmt.Errorf("removing finished disruption commands, %w", errs)
+	}
+	q.restored = true
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
@@ -110,6 +111,9 @@ type Queue struct {
 	mu                  sync.RWMutex
 	providerIDToCommand map[string]*Command // providerID -> command, maps a candidate to its command
 
+	restoreMu sync.Mutex
+	restored  bool // restored is set once the queue has been rebuilt from the checkpointed commands
+
 	kubeClient  client.Client
 	recorder    events.Recorder
 	cluster     *state.Cluster
@@ -200,17 +204,36 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			consolidationTypeLabel: cmd.consolidationType,
 		})
 		multiErr := multierr.Combine(err, cmd.lastError, state.RequireNoScheduleTaint(ctx, q.kubeClient, false, cmd.candidates...))
+		// Replacing the candidates with multiple NodeClaims is only cheaper if all of them launch, so we remove the
+		// replacements that did launch rather than leaving part of the replacement running next to the candidates
+		if len(cmd.Replacements) > 1 && len(failedLaunches) > 0 {
+			multiErr = multierr.Append(multiErr, q.rollbackReplacements(ctx, cmd))
+		}
 		// Log the error
 		log.FromContext(ctx).WithValues("nodes", strings.Join(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string {
 			return s.Name()
 		}), ",")).Error(multiErr, "failed terminating nodes while executing a disruption command")
 	}
-	// If command is complete, remove command from queue.
+	// If command is complete, remove command from queue. The checkpoint is no longer needed to rebuild the queue.
+	if err := q.forget(ctx, cmd); err != nil {
+		log.FromContext(ctx).Error(err, "failed removing disruption command checkpoint")
+	}
 	q.Remove(cmd)
 	log.FromContext(ctx).V(1).Info("command succeeded")
 	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 }
 
+// rollbackReplacements deletes the replacement NodeClaims of a command that failed
+func (q *Queue) rollbackReplacements(ctx context.Context, cmd *Command) error {
+	var multiErr error
+	for _, replacement := range cmd.Replacements {
+		if err := q.kubeClient.Delete(ctx, &v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: replacement.name}}); client.IgnoreNotFound(err) != nil {
+			multiErr = multierr.Append(multiErr, fmt.Errorf("deleting replacement nodeclaim %s, %w", replacement.name, err))
+		}
+	}
+	return multiErr
+}
+
 // waitOrTerminate will wait until launched nodeclaims are ready.
 // Once the replacements are ready, it will terminate the candidates.
 // Will return true if the item in the queue should be re-queued. If a command has
@@ -280,8 +303,9 @@ func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
 }
 
 // Add adds commands to the Queue
-// Each command added to the queue should already be validated and ready for execution.
-func (q *Queue) Add(cmd *Command) error {
+// Each command added to the queue should already be validated and ready for execution. The command is checkpointed
+// to its candidate and replacement NodeClaims so that the queue can be restored if the controller restarts.
+func (q *Queue) Add(ctx context.Context, cmd *Command) error {
 	providerIDs := lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string {
 		return s.ProviderID()
 	})
@@ -291,13 +315,20 @@ func (q *Queue) Add(cmd *Command) error {
 	}
 
 	cmd.timeAdded = q.clock.Now()
+	if err := q.persist(ctx, cmd); err != nil {
+		return multierr.Append(fmt.Errorf("checkpointing command, %w", err), q.forget(ctx, cmd))
+	}
+	q.add(cmd)
+	return nil
+}
+
+func (q *Queue) add(cmd *Command) {
 	q.mu.Lock()
 	for _, candidate := range cmd.candidates {
 		q.providerIDToCommand[candidate.ProviderID()] = cmd
 	}
 	q.mu.Unlock()
 	q.RateLimitingInterface.Add(cmd)
-	return nil
 }
 
 // HasAny checks to see if the candidate is part of an currently executing command.
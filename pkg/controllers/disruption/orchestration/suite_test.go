@@ -18,6 +18,7 @@ package orchestration_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 
 	"github.com/dcoppa/karpenter/pkg/apis"
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
 	disruptionevents "github.com/dcoppa/karpenter/pkg/controllers/disruption/events"
 	"github.com/dcoppa/karpenter/pkg/controllers/disruption/orchestration"
@@ -85,7 +87,7 @@ var _ = BeforeSuite(func() {
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	recorder = test.NewEventRecorder()
-	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, cloudprovider.NewUnavailableOfferings())
 	queue = NewTestingQueue(env.Client, recorder, cluster, fakeClock, prov)
 })
 
@@ -171,7 +173,7 @@ var _ = Describe("Queue", func() {
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
 
 			stateNode := ExpectStateNodeExists(cluster, node1)
-			Expect(queue.Add(orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
 
 			node1 = ExpectNodeExists(ctx, env.Client, node1.Name)
 			Expect(node1.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
@@ -189,7 +191,7 @@ var _ = Describe("Queue", func() {
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
 			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
 
-			Expect(queue.Add(orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
 			ExpectSingletonReconciled(ctx, queue)
 		})
 		It("should untaint nodes when a command times out", func() {
@@ -197,7 +199,7 @@ var _ = Describe("Queue", func() {
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
 			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
 
-			Expect(queue.Add(orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
 
 			// Step the clock to trigger the timeout.
 			fakeClock.Step(11 * time.Minute)
@@ -212,7 +214,7 @@ var _ = Describe("Queue", func() {
 			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
 
 			cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
-			Expect(queue.Add(cmd)).To(BeNil())
+			Expect(queue.Add(ctx, cmd)).To(BeNil())
 			ExpectSingletonReconciled(ctx, queue)
 
 			// Get the command
@@ -249,7 +251,7 @@ var _ = Describe("Queue", func() {
 			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
 
 			cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
-			Expect(queue.Add(cmd)).To(BeNil())
+			Expect(queue.Add(ctx, cmd)).To(BeNil())
 
 			ExpectSingletonReconciled(ctx, queue)
 			Expect(cmd.Replacements[0].Initialized).To(BeFalse())
@@ -273,12 +275,41 @@ var _ = Describe("Queue", func() {
 			// And expect the nodeClaim and node to be deleted
 			ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
 		})
+		It("should remove the launched replacements when a command with multiple replacements times out", func() {
+			ncName2 := test.RandomName()
+			replacements = []string{ncName, ncName2}
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, replacementNodeClaim, replacementNode, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{replacementNode}, []*v1.NodeClaim{replacementNodeClaim})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			// The second replacement never launches
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
+			fakeClock.Step(11 * time.Minute)
+			ExpectSingletonReconciled(ctx, queue)
+
+			ExpectNotFound(ctx, env.Client, replacementNodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim1)
+			node1 = ExpectNodeExists(ctx, env.Client, node1.Name)
+			Expect(node1.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		})
+		It("should keep the replacement when a command with a single replacement times out", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, replacementNodeClaim, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type"))).To(BeNil())
+			fakeClock.Step(11 * time.Minute)
+			ExpectSingletonReconciled(ctx, queue)
+
+			ExpectExists(ctx, env.Client, replacementNodeClaim)
+		})
 		It("should not wait for replacements when none are needed", func() {
 			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool)
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
 			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
 			cmd := orchestration.NewCommand([]string{}, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
-			Expect(queue.Add(cmd)).To(BeNil())
+			Expect(queue.Add(ctx, cmd)).To(BeNil())
 
 			ExpectSingletonReconciled(ctx, queue)
 
@@ -305,9 +336,9 @@ var _ = Describe("Queue", func() {
 			stateNode2 := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim2)
 
 			cmd := orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "", "test-method", "fake-type")
-			Expect(queue.Add(cmd)).To(BeNil())
+			Expect(queue.Add(ctx, cmd)).To(BeNil())
 			cmd2 := orchestration.NewCommand(replacements2, []*state.StateNode{stateNode2}, "", "test-method", "fake-type")
-			Expect(queue.Add(cmd2)).To(BeNil())
+			Expect(queue.Add(ctx, cmd2)).To(BeNil())
 
 			// Reconcile the first command and expect nothing to be initialized
 			ExpectSingletonReconciled(ctx, queue)
@@ -347,6 +378,80 @@ var _ = Describe("Queue", func() {
 		})
 
 	})
+	Context("Checkpoint", func() {
+		It("should checkpoint commands to the candidate and replacement NodeClaims", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "command-id", "test-method", "fake-type"))).To(Succeed())
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			replacementNodeClaim = ExpectExists(ctx, env.Client, replacementNodeClaim)
+			Expect(nodeClaim1.Annotations).To(HaveKeyWithValue(v1.DisruptionCommandAnnotationKey, ContainSubstring("command-id")))
+			Expect(replacementNodeClaim.Annotations).To(HaveKeyWithValue(v1.DisruptionCommandAnnotationKey, nodeClaim1.Annotations[v1.DisruptionCommandAnnotationKey]))
+		})
+		It("should remove the checkpoint when a command completes", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1, replacementNode}, []*v1.NodeClaim{nodeClaim1, replacementNodeClaim})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "command-id", "test-method", "fake-type"))).To(Succeed())
+			ExpectSingletonReconciled(ctx, queue)
+
+			replacementNodeClaim = ExpectExists(ctx, env.Client, replacementNodeClaim)
+			Expect(replacementNodeClaim.Annotations).ToNot(HaveKey(v1.DisruptionCommandAnnotationKey))
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1)
+			ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
+		})
+		It("should remove the checkpoint when a command times out", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "command-id", "test-method", "fake-type"))).To(Succeed())
+			fakeClock.Step(11 * time.Minute)
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			replacementNodeClaim = ExpectExists(ctx, env.Client, replacementNodeClaim)
+			Expect(nodeClaim1.Annotations).ToNot(HaveKey(v1.DisruptionCommandAnnotationKey))
+			Expect(replacementNodeClaim.Annotations).ToNot(HaveKey(v1.DisruptionCommandAnnotationKey))
+		})
+		It("should restore checkpointed commands and keep waiting on their replacements", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+			Expect(queue.Add(ctx, orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "command-id", "test-method", "fake-type"))).To(Succeed())
+
+			// Simulate a restart of the controller, which loses the in-memory queue
+			*queue = lo.FromPtr(NewTestingQueue(env.Client, recorder, cluster, fakeClock, prov))
+			Expect(queue.HasAny(stateNode.ProviderID())).To(BeFalse())
+			Expect(queue.Restore(ctx)).To(Succeed())
+			Expect(queue.HasAny(stateNode.ProviderID())).To(BeTrue())
+			Expect(cluster.Nodes()[0].MarkedForDeletion()).To(BeTrue())
+
+			// The candidate shouldn't be disrupted until the replacement is initialized
+			ExpectSingletonReconciled(ctx, queue)
+			ExpectExists(ctx, env.Client, nodeClaim1)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{replacementNode}, []*v1.NodeClaim{replacementNodeClaim})
+			ExpectSingletonReconciled(ctx, queue)
+
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1)
+			ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
+		})
+		It("should remove checkpoints of commands whose candidates no longer exist", func() {
+			replacementNodeClaim.Annotations = lo.Assign(replacementNodeClaim.Annotations, map[string]string{
+				v1.DisruptionCommandAnnotationKey: fmt.Sprintf(`{"id":"command-id","reason":"test-method","candidates":["does-not-exist"],"replacements":[%q]}`, replacementNodeClaim.Name),
+			})
+			ExpectApplied(ctx, env.Client, nodePool, replacementNodeClaim, replacementNode)
+
+			Expect(queue.Restore(ctx)).To(Succeed())
+			Expect(queue.IsEmpty()).To(BeTrue())
+			replacementNodeClaim = ExpectExists(ctx, env.Client, replacementNodeClaim)
+			Expect(replacementNodeClaim.Annotations).ToNot(HaveKey(v1.DisruptionCommandAnnotationKey))
+		})
+	})
 })
 
 func NewTestingQueue(kubeClient client.Client, recorder events.Recorder, cluster *state.Cluster, clock clockiface.Clock,
@@ -18,7 +18,11 @@ package disruption_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"sync"
 	"testing"
@@ -66,6 +70,7 @@ var env *test.Environment
 var cluster *state.Cluster
 var disruptionController *disruption.Controller
 var prov *provisioning.Provisioner
+var unavailableOfferings *cloudprovider.UnavailableOfferings
 var cloudProvider *fake.CloudProvider
 var nodeStateController *informer.NodeController
 var nodeClaimStateController *informer.NodeClaimController
@@ -96,7 +101,8 @@ var _ = BeforeSuite(func() {
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	recorder = test.NewEventRecorder()
-	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+	unavailableOfferings = cloudprovider.NewUnavailableOfferings()
+	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, unavailableOfferings)
 	queue = NewTestingQueue(env.Client, recorder, cluster, fakeClock, prov)
 	disruptionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue)
 })
@@ -160,9 +166,11 @@ var _ = BeforeEach(func() {
 
 var _ = AfterEach(func() {
 	ExpectCleanedUp(ctx, env.Client)
+	unavailableOfferings.Flush()
 
 	// Reset the metrics collectors
 	disruption.DecisionsPerformedTotal.Reset()
+	disruption.ApprovalDecisionsTotal.Reset()
 })
 
 var _ = Describe("Simulate Scheduling", func() {
@@ -177,6 +185,14 @@ var _ = Describe("Simulate Scheduling", func() {
 			},
 		})
 	})
+	It("should bound scheduling simulations by half of the solve timeout", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SolveTimeout: lo.ToPtr(20 * time.Second)}))
+		Expect(disruption.SimulateSchedulingTimeout(ctx)).To(Equal(10 * time.Second))
+	})
+	It("should bound scheduling simulations by 30 seconds at most", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SolveTimeout: lo.ToPtr(5 * time.Minute)}))
+		Expect(disruption.SimulateSchedulingTimeout(ctx)).To(Equal(30 * time.Second))
+	})
 	It("should allow pods on deleting nodes to reschedule to uninitialized nodes", func() {
 		numNodes := 10
 		nodeClaims, nodes := test.NodeClaimsAndNodes(numNodes, v1.NodeClaim{
@@ -473,7 +489,7 @@ var _ = Describe("Disruption Taints", func() {
 				{
 					Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}),
 					Price:        1.5,
-					Available:    false,
+					Available:    0,
 				},
 			},
 		})
@@ -483,17 +499,17 @@ var _ = Describe("Disruption Taints", func() {
 				{
 					Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 				{
 					Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1b"}),
 					Price:        0.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 				{
 					Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1c"}),
 					Price:        0.4,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			},
 		})
@@ -1235,6 +1251,135 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err.Error()).To(Equal(`disruption is blocked through the "karpenter.sh/do-not-disrupt" annotation`))
 		Expect(recorder.DetectedEvent(`Cannot disrupt Node: disruption is blocked through the "karpenter.sh/do-not-disrupt" annotation`)).To(BeTrue())
 	})
+	It("should not consider candidates that have do-not-disrupt on nodes until its deadline", func() {
+		deadline := fakeClock.Now().Add(time.Hour).Truncate(time.Second)
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.DoNotDisruptAnnotationKey: deadline.Format(time.RFC3339),
+				},
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(`disruption is blocked through the "karpenter.sh/do-not-disrupt" annotation until %s`, deadline.Format(time.RFC3339))))
+		Expect(recorder.DetectedEvent(fmt.Sprintf(`Cannot disrupt Node: disruption is blocked through the "karpenter.sh/do-not-disrupt" annotation until %s`, deadline.Format(time.RFC3339)))).To(BeTrue())
+
+		fakeClock.SetTime(deadline)
+		_, err = disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should not consider candidates that have do-not-disrupt pods for the duration of the annotation", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.DoNotDisruptAnnotationKey: "6h",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		deadline := ExpectExists(ctx, env.Client, pod).CreationTimestamp.Add(6 * time.Hour)
+		fakeClock.SetTime(deadline.Add(-time.Minute))
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has "karpenter.sh/do-not-disrupt" annotation until %s`, client.ObjectKeyFromObject(pod), deadline.Format(time.RFC3339))))
+
+		fakeClock.SetTime(deadline)
+		_, err = disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should consider candidates that have do-not-disrupt annotations that aren't a duration or a deadline", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.DoNotDisruptAnnotationKey: "false",
+				},
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should requeue disruption at the deadline of a do-not-disrupt annotation", func() {
+		deadline := fakeClock.Now().Add(5 * time.Second).Truncate(time.Second)
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.DoNotDisruptAnnotationKey: deadline.Format(time.RFC3339),
+				},
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		result := ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(result.RequeueAfter).To(Equal(deadline.Sub(fakeClock.Now())))
+	})
+	It("should requeue disruption at the deadline of a do-not-disrupt annotation on a pod", func() {
+		deadline := fakeClock.Now().Add(5 * time.Second).Truncate(time.Second)
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1.DoNotDisruptAnnotationKey: deadline.Format(time.RFC3339),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		result := ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(result.RequeueAfter).To(Equal(deadline.Sub(fakeClock.Now())))
+	})
 	It("should not consider candidates that have fully blocking PDBs", func() {
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -1764,7 +1909,7 @@ var _ = Describe("Candidate Filtering", func() {
 		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
 
 		Expect(cluster.Nodes()).To(HaveLen(1))
-		Expect(queue.Add(orchestration.NewCommand([]string{}, []*state.StateNode{cluster.Nodes()[0]}, "", "test-method", "fake-type"))).To(Succeed())
+		Expect(queue.Add(ctx, orchestration.NewCommand([]string{}, []*state.StateNode{cluster.Nodes()[0]}, "", "test-method", "fake-type"))).To(Succeed())
 
 		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
 		Expect(err).To(HaveOccurred())
@@ -2005,6 +2150,289 @@ var _ = Describe("Metrics", func() {
 	})
 })
 
+var _ = Describe("Dry Run", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidationPolicy: v1.ConsolidationPolicyWhenEmptyOrUnderutilized,
+					ConsolidateAfter:    v1.MustParseNillableDuration("0s"),
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+	})
+	It("should not disrupt nodes when dry-run is enabled globally", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionDryRun: lo.ToPtr(true)}))
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		// The candidate shouldn't be tainted or enqueued for deletion
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		Expect(recorder.Calls("DisruptionDryRun")).To(BeNumerically(">", 0))
+		ExpectMetricCounterValue(disruption.DecisionsPerformedTotal, 1, map[string]string{
+			"decision":          "delete",
+			metrics.ReasonLabel: "drifted",
+			"dry_run":           "true",
+		})
+	})
+	It("should not disrupt nodes of a NodePool that is annotated for dry-run", func() {
+		nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.DisruptionDryRunAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		ExpectMetricCounterValue(disruption.DecisionsPerformedTotal, 1, map[string]string{
+			"decision":          "delete",
+			metrics.ReasonLabel: "drifted",
+			"dry_run":           "true",
+		})
+	})
+	It("should report the command and its estimated savings in the dry-run events", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionDryRun: lo.ToPtr(true)}))
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		var messages []string
+		recorder.ForEachEvent(func(evt events.Event) {
+			if evt.Reason == "DisruptionDryRun" {
+				messages = append(messages, evt.Message)
+			}
+		})
+		Expect(messages).ToNot(BeEmpty())
+		for _, message := range messages {
+			Expect(message).To(ContainSubstring(nodeClaim.Name))
+			// Deleting the node without a replacement saves its full price
+			Expect(message).To(ContainSubstring(fmt.Sprintf("estimated savings %.4f", mostExpensiveOffering.Price)))
+		}
+		// The decision is only counted as a dry-run decision
+		_, ok := FindMetricWithLabelValues("karpenter_voluntary_disruption_decisions_total", map[string]string{
+			metrics.ReasonLabel: "drifted",
+			"dry_run":           "false",
+		})
+		Expect(ok).To(BeFalse())
+	})
+	It("should disrupt nodes of a NodePool that isn't annotated for dry-run", func() {
+		nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.DisruptionDryRunAnnotationKey: "false"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(recorder.Calls("DisruptionDryRun")).To(Equal(0))
+		ExpectMetricCounterValue(disruption.DecisionsPerformedTotal, 1, map[string]string{
+			"decision":          "delete",
+			metrics.ReasonLabel: "drifted",
+			"dry_run":           "false",
+		})
+	})
+})
+
+var _ = Describe("Approval", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var server *httptest.Server
+	var requests []disruption.ApprovalRequest
+	var response disruption.ApprovalResponse
+	BeforeEach(func() {
+		requests = nil
+		response = disruption.ApprovalResponse{Decision: disruption.ApprovalDecisionApprove}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			var req disruption.ApprovalRequest
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			requests = append(requests, req)
+			Expect(json.NewEncoder(w).Encode(response)).To(Succeed())
+		}))
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionApprovalURL: lo.ToPtr(server.URL)}))
+
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidationPolicy: v1.ConsolidationPolicyWhenEmptyOrUnderutilized,
+					ConsolidateAfter:    v1.MustParseNillableDuration("Never"),
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+	It("should disrupt nodes when the approver approves the command", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeTrue())
+
+		// The approver is told which command it's approving
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Reason).To(Equal(v1.DisruptionReasonDrifted))
+		Expect(requests[0].Decision).To(Equal(disruption.DeleteDecision))
+		Expect(requests[0].EstimatedSavings).To(BeNumerically("~", mostExpensiveOffering.Price, 0.0001))
+		Expect(requests[0].Candidates).To(ConsistOf(disruption.ApprovalRequestCandidate{
+			NodeClaim:    nodeClaim.Name,
+			Node:         node.Name,
+			NodePool:     nodePool.Name,
+			InstanceType: mostExpensiveInstance.Name,
+			CapacityType: mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+			Zone:         mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+		}))
+		ExpectMetricCounterValue(disruption.ApprovalDecisionsTotal, 1, map[string]string{
+			"decision":          "Approve",
+			metrics.ReasonLabel: "drifted",
+		})
+	})
+	It("should not disrupt nodes when the approver denies the command", func() {
+		response = disruption.ApprovalResponse{Decision: disruption.ApprovalDecisionDeny, Reason: "change freeze"}
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		result := ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(result.RequeueAfter).To(BeNumerically(">", time.Second))
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		Expect(recorder.DetectedEvent("Cannot disrupt Node: Disruption denied by approver (change freeze)")).To(BeTrue())
+		ExpectMetricCounterValue(disruption.ApprovalDecisionsTotal, 1, map[string]string{
+			"decision":          "Deny",
+			metrics.ReasonLabel: "drifted",
+		})
+	})
+	It("should hold off disrupting nodes until the approver asks to be called again", func() {
+		response = disruption.ApprovalResponse{Decision: disruption.ApprovalDecisionDefer, RetryAfter: &metav1.Duration{Duration: 5 * time.Minute}}
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		result := ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+		Expect(requests).To(HaveLen(1))
+
+		// The approver isn't called again before the deferral expires
+		fakeClock.Step(time.Minute)
+		result = ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(result.RequeueAfter).To(Equal(4 * time.Minute))
+		Expect(requests).To(HaveLen(1))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+
+		// Once the deferral expires, the approver is called again and approves the command
+		response = disruption.ApprovalResponse{Decision: disruption.ApprovalDecisionApprove}
+		fakeClock.Step(4 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(requests).To(HaveLen(2))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+	})
+	It("should deny the command when the approver fails and the failure policy is Fail", func() {
+		server.Close()
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+	})
+	It("should approve the command when the approver fails and the failure policy is Ignore", func() {
+		server.Close()
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			DisruptionApprovalURL:           lo.ToPtr(server.URL),
+			DisruptionApprovalFailurePolicy: lo.ToPtr(options.DisruptionApprovalFailurePolicyIgnore),
+		}))
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeTrue())
+	})
+	It("should deny the command when the approver returns an unknown decision", func() {
+		response = disruption.ApprovalResponse{Decision: "Maybe"}
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectSingletonReconciled(ctx, disruptionController)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		Expect(requests).To(HaveLen(1))
+	})
+})
+
 func leastExpensiveInstanceWithZone(zone string) *cloudprovider.InstanceType {
 	for _, elem := range onDemandInstances {
 		if len(elem.Offerings.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone)))) > 0 {
@@ -33,6 +33,7 @@ import (
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
 	"github.com/dcoppa/karpenter/pkg/controllers/state"
 	"github.com/dcoppa/karpenter/pkg/events"
+	scheduler "github.com/dcoppa/karpenter/pkg/scheduling"
 	disruptionutils "github.com/dcoppa/karpenter/pkg/utils/disruption"
 	"github.com/dcoppa/karpenter/pkg/utils/pdb"
 	"github.com/dcoppa/karpenter/pkg/utils/pod"
@@ -70,7 +71,7 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 	nodePoolMap map[string]*v1.NodePool, nodePoolToInstanceTypesMap map[string]map[string]*cloudprovider.InstanceType, queue *orchestration.Queue, disruptionClass string) (*Candidate, error) {
 	var err error
 	var pods []*corev1.Pod
-	if err = node.ValidateNodeDisruptable(ctx, kubeClient); err != nil {
+	if err = node.ValidateNodeDisruptable(ctx, clk, kubeClient); err != nil {
 		// Only emit an event if the NodeClaim is not nil, ensuring that we only emit events for Karpenter-managed nodes
 		if node.NodeClaim != nil {
 			recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, err.Error())...)
@@ -92,7 +93,7 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 	}
 	// We only care if instanceType in non-empty consolidation to do price-comparison.
 	instanceType := instanceTypeMap[node.Labels()[corev1.LabelInstanceTypeStable]]
-	if pods, err = node.ValidatePodsDisruptable(ctx, kubeClient, pdbs); err != nil {
+	if pods, err = node.ValidatePodsDisruptable(ctx, clk, kubeClient, pdbs); err != nil {
 		// if the disruption class is not eventual or the nodepool has no TerminationGracePeriod, block disruption of pods
 		// if the error is anything but a PodBlockEvictionError, also block disruption of pods
 		if !(state.IsPodBlockEvictionError(err) && node.NodeClaim.Spec.TerminationGracePeriod != nil && disruptionClass == EventualDisruptionClass) {
@@ -136,6 +137,33 @@ func (c Command) Decision() Decision {
 	}
 }
 
+// EstimatedSavings returns the difference between the price of the candidates and the cheapest launch price of the
+// replacements. Candidates without a resolvable offering don't contribute to the savings.
+func (c Command) EstimatedSavings() float64 {
+	var savings float64
+	for _, cn := range c.candidates {
+		if cn.instanceType == nil {
+			continue
+		}
+		if offerings := cn.instanceType.Offerings.Compatible(scheduler.NewLabelRequirements(cn.Labels())); len(offerings) > 0 {
+			savings += offerings.Cheapest().Price
+		}
+	}
+	for _, replacement := range c.replacements {
+		prices := lo.FilterMap(replacement.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) (float64, bool) {
+			offerings := it.Offerings.Available().Compatible(replacement.Requirements)
+			if len(offerings) == 0 {
+				return 0, false
+			}
+			return offerings.Cheapest().Price, true
+		})
+		if len(prices) > 0 {
+			savings -= lo.Min(prices)
+		}
+	}
+	return savings
+}
+
 func (c Command) String() string {
 	var buf bytes.Buffer
 	podCount := lo.Reduce(c.candidates, func(_ int, cd *Candidate, _ int) int { return len(cd.reschedulablePods) }, 0)
@@ -153,6 +181,7 @@ func (c Command) String() string {
 	}
 	odNodeClaims := 0
 	spotNodeClaims := 0
+	reservedNodeClaims := 0
 	for _, nodeClaim := range c.replacements {
 		ct := nodeClaim.Requirements.Get(v1.CapacityTypeLabelKey)
 		if ct.Has(v1.CapacityTypeOnDemand) {
@@ -161,11 +190,14 @@ func (c Command) String() string {
 		if ct.Has(v1.CapacityTypeSpot) {
 			spotNodeClaims++
 		}
+		if ct.Has(v1.CapacityTypeReserved) {
+			reservedNodeClaims++
+		}
 	}
 	// Print list of instance types for the first replacements.
 	if len(c.replacements) > 1 {
-		fmt.Fprintf(&buf, " and replacing with %d spot and %d on-demand, from types %s",
-			spotNodeClaims, odNodeClaims,
+		fmt.Fprintf(&buf, " and replacing with %d spot, %d on-demand and %d reserved, from types %s",
+			spotNodeClaims, odNodeClaims, reservedNodeClaims,
 			scheduling.InstanceTypeList(c.replacements[0].InstanceTypeOptions))
 		return buf.String()
 	}
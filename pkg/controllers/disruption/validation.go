@@ -149,7 +149,7 @@ func (v *Validation) ValidateCandidates(ctx context.Context, candidates ...*Cand
 
 // ShouldDisrupt is a predicate used to filter candidates
 func (v *Validation) ShouldDisrupt(_ context.Context, c *Candidate) bool {
-	return c.nodePool.Spec.Disruption.ConsolidateAfter.Duration != nil && c.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
+	return c.nodePool.Spec.Disruption.ConsolidateAfter.Duration != nil && !c.nodePool.IsStatic() && c.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
 }
 
 // ValidateCommand validates a command for a Method
@@ -167,11 +167,10 @@ func (v *Validation) ValidateCommand(ctx context.Context, cmd Command, candidate
 	}
 
 	// We want to ensure that the re-simulated scheduling using the current cluster state produces the same result.
-	// There are three possible options for the number of new candidates that we need to handle:
+	// There are two possible options for the number of new candidates that we need to handle:
 	// len(NewNodeClaims) == 0, as long as we weren't expecting a new node, this is valid
-	// len(NewNodeClaims) > 1, something in the cluster changed so that the candidates we were going to delete can no longer
-	//                    be deleted without producing more than one node
-	// len(NewNodeClaims) == 1, as long as the noe looks like what we were expecting, this is valid
+	// len(NewNodeClaims) > 0, as long as we were expecting as many new NodeClaims and they look like what we were
+	//                    expecting, this is valid
 	if len(results.NewNodeClaims) == 0 {
 		if len(cmd.replacements) == 0 {
 			// scheduling produced zero new NodeClaims and we weren't expecting any, so this is valid.
@@ -182,34 +181,36 @@ func (v *Validation) ValidateCommand(ctx context.Context, cmd Command, candidate
 		return NewValidationError(fmt.Errorf("scheduling simulation produced new results"))
 	}
 
-	// we need more than one replacement node which is never valid currently (all of our node replacement is m->1, never m->n)
-	if len(results.NewNodeClaims) > 1 {
+	// something in the cluster changed so that the candidates can no longer be replaced with the NodeClaims we were
+	// expecting, including the case where we weren't expecting any new NodeClaims
+	if len(results.NewNodeClaims) != len(cmd.replacements) {
 		return NewValidationError(fmt.Errorf("scheduling simulation produced new results"))
 	}
 
-	// we now know that scheduling simulation wants to create one new node
-	if len(cmd.replacements) == 0 {
-		// but we weren't expecting any new NodeClaims, so this is invalid
-		return NewValidationError(fmt.Errorf("scheduling simulation produced new results"))
-	}
-
-	// We know that the scheduling simulation wants to create a new node and that the command we are verifying wants
-	// to create a new node. The scheduling simulation doesn't apply any filtering to instance types, so it may include
+	// We know that the scheduling simulation wants to create new NodeClaims and that the command we are verifying wants
+	// to create as many. The scheduling simulation doesn't apply any filtering to instance types, so it may include
 	// instance types that we don't want to launch which were filtered out when the lifecycleCommand was created.  To
 	// check if our lifecycleCommand is valid, we just want to ensure that the list of instance types we are considering
-	// creating are a subset of what scheduling says we should create.  We check for a subset since the scheduling
-	// simulation here does no price filtering, so it will include more expensive types.
+	// creating for each replacement are a subset of what scheduling says we should create.  We check for a subset since
+	// the scheduling simulation here does no price filtering, so it will include more expensive types.
 	//
 	// This is necessary since consolidation only wants cheaper NodeClaims.  Suppose consolidation determined we should delete
 	// a 4xlarge and replace it with a 2xlarge. If things have changed and the scheduling simulation we just performed
 	// now says that we need to launch a 4xlarge. It's still launching the correct number of NodeClaims, but it's just
 	// as expensive or possibly more so we shouldn't validate.
-	if !instanceTypesAreSubset(cmd.replacements[0].InstanceTypeOptions, results.NewNodeClaims[0].InstanceTypeOptions) {
+	if !replacementsAreSubsets(cmd.replacements, results.NewNodeClaims) {
 		return NewValidationError(fmt.Errorf("scheduling simulation produced new results"))
 	}
 
+	// If we are moving the candidates onto reserved capacity, we need to ensure that the reserved capacity is still free.
+	// Otherwise, the replacement wouldn't be cheaper than the candidates.
+	if len(cmd.replacements) == 1 && isReservedConsolidation(candidates, cmd.replacements[0]) && !isReservedConsolidation(candidates, results.NewNodeClaims[0]) {
+		return NewValidationError(fmt.Errorf("reserved capacity is no longer available"))
+	}
+
 	// Now we know:
-	// - current scheduling simulation says to create a new node with types T = {T_0, T_1, ..., T_n}
-	// - our lifecycle command says to create a node with types {U_0, U_1, ..., U_n} where U is a subset of T
+	// - current scheduling simulation says to create new NodeClaims with types T_i = {T_i0, T_i1, ..., T_in}
+	// - our lifecycle command says to create as many NodeClaims with types U_i = {U_i0, U_i1, ..., U_in} where each U_i
+	//   is a subset of a distinct T_i
 	return nil
 }
@@ -19,13 +19,14 @@ package health
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -43,8 +44,6 @@ import (
 	nodeutils "github.com/dcoppa/karpenter/pkg/utils/node"
 )
 
-var allowedUnhealthyPercent = intstr.FromString("20%")
-
 // Controller for the resource
 type Controller struct {
 	clock         clock.Clock
@@ -80,45 +79,80 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 		return reconcile.Result{}, nodeutils.IgnoreNodeClaimNotFoundError(err)
 	}
 
-	// If a nodeclaim does has a nodepool label, validate the nodeclaims inside the nodepool are healthy (i.e bellow the allowed threshold)
-	// In the case of standalone nodeclaim, validate the nodes inside the cluster are healthy before proceeding
-	// to repair the nodes
+	// NodeClaims of a NodePool are repaired according to the repair policies and unhealthy threshold of the NodePool,
+	// merged with the defaults of the cloud provider, as long as the NodePool is below the threshold. Standalone
+	// NodeClaims are repaired with the defaults of the cloud provider as long as the cluster is below the threshold.
+	var nodePool *v1.NodePool
 	nodePoolName, found := nodeClaim.Labels[v1.NodePoolLabelKey]
 	if found {
-		nodePoolHealthy, err := c.isNodePoolHealthy(ctx, nodePoolName)
+		nodePool = &v1.NodePool{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return reconcile.Result{}, err
+			}
+			nodePool = nil
+		}
+	}
+	policies := c.repairPolicies(nodePool)
+	if len(policies) == 0 {
+		return reconcile.Result{}, nil
+	}
+	maxUnhealthy := maxUnhealthy(nodePool)
+
+	if found {
+		nodePoolHealthy, err := c.isNodePoolHealthy(ctx, nodePoolName, policies, maxUnhealthy)
 		if err != nil {
-			return reconcile.Result{}, client.IgnoreNotFound(err)
+			return reconcile.Result{}, err
 		}
 		if !nodePoolHealthy {
-			return reconcile.Result{}, c.publishNodePoolHealthEvent(ctx, node, nodeClaim, nodePoolName)
+			if nodePool != nil {
+				c.recorder.Publish(NodeRepairBlocked(node, nodeClaim, nodePool, fmt.Sprintf("more then %s nodes are unhealthy in the nodepool", maxUnhealthy.String()))...)
+			}
+			return reconcile.Result{}, nil
 		}
 	} else {
-		clusterHealthy, err := c.isClusterHealthy(ctx)
+		clusterHealthy, err := c.isClusterHealthy(ctx, policies, maxUnhealthy)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
 		if !clusterHealthy {
-			c.recorder.Publish(NodeRepairBlockedUnmanagedNodeClaim(node, nodeClaim, fmt.Sprintf("more then %s nodes are unhealthy in the cluster", allowedUnhealthyPercent.String()))...)
+			c.recorder.Publish(NodeRepairBlockedUnmanagedNodeClaim(node, nodeClaim, fmt.Sprintf("more then %s nodes are unhealthy in the cluster", maxUnhealthy.String()))...)
 			return reconcile.Result{}, nil
 		}
 	}
 
-	unhealthyNodeCondition, policyTerminationDuration := c.findUnhealthyConditions(node)
+	unhealthyNodeCondition, policy := findUnhealthyConditions(node, policies)
 	if unhealthyNodeCondition == nil {
 		return reconcile.Result{}, nil
 	}
 
 	// If the Node is unhealthy, but has not reached it's full toleration disruption
 	// requeue at the termination time of the unhealthy node
-	terminationTime := unhealthyNodeCondition.LastTransitionTime.Add(policyTerminationDuration)
+	terminationTime := unhealthyNodeCondition.LastTransitionTime.Add(policy.TolerationDuration)
 	if c.clock.Now().Before(terminationTime) {
 		return reconcile.Result{RequeueAfter: terminationTime.Sub(c.clock.Now())}, nil
 	}
 
+	// Reboot the node first if the policy and the cloud provider allow it. If the condition persists for the toleration
+	// duration after the reboot, escalate to replacing the node.
+	if rebooter, ok := cloudprovider.Extension[cloudprovider.Rebooter](c.cloudProvider); ok && policy.Action == v1.RepairActionReboot {
+		attempt, rebooted := lastRebootAttempt(nodeClaim, unhealthyNodeCondition, policy)
+		if !rebooted {
+			return c.reboot(ctx, rebooter, node, nodeClaim, unhealthyNodeCondition, policy)
+		}
+		if escalationTime := attempt.Time.Add(policy.TolerationDuration); c.clock.Now().Before(escalationTime) {
+			return reconcile.Result{RequeueAfter: escalationTime.Sub(c.clock.Now())}, nil
+		}
+		log.FromContext(ctx).V(1).WithValues("condition", unhealthyNodeCondition.Type).Info("node is still unhealthy after reboot, replacing")
+	}
+
 	// For unhealthy past the tolerationDisruption window we can forcefully terminate the node
 	if err := c.annotateTerminationGracePeriod(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
+	if err := c.recordRepairAttempt(ctx, nodeClaim, v1.RepairActionReplace, unhealthyNodeCondition); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
 	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
@@ -133,11 +167,49 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 	return reconcile.Result{}, nil
 }
 
-// Find a node with a condition that matches one of the unhealthy conditions defined by the cloud provider
+// repairPolicies merges the repair policies of the cloud provider with the repair policies of the NodePool. A NodePool
+// policy overrides the cloud provider policy with the same condition type and status.
+func (c *Controller) repairPolicies(nodePool *v1.NodePool) []cloudprovider.RepairPolicy {
+	policies := slices.Clone(c.cloudProvider.RepairPolicies())
+	if nodePool == nil {
+		return policies
+	}
+	for _, policy := range nodePool.Spec.Repair.Policies {
+		override := cloudprovider.RepairPolicy{
+			ConditionType:      policy.ConditionType,
+			ConditionStatus:    policy.ConditionStatus,
+			TolerationDuration: policy.TolerationDuration.Duration,
+			Action:             policy.Action,
+		}
+		_, i, found := lo.FindIndexOf(policies, func(p cloudprovider.RepairPolicy) bool {
+			return p.ConditionType == policy.ConditionType && p.ConditionStatus == policy.ConditionStatus
+		})
+		if found {
+			policies[i] = override
+		} else {
+			policies = append(policies, override)
+		}
+	}
+	return policies
+}
+
+// maxUnhealthy returns the maximum number or percentage of nodes that can be unhealthy before repair is blocked. The
+// NodePool has already been validated, so invalid values fall back to the default.
+func maxUnhealthy(nodePool *v1.NodePool) intstr.IntOrString {
+	if nodePool == nil || nodePool.Spec.Repair.MaxUnhealthy == nil {
+		return intstr.FromString(v1.DefaultMaxUnhealthy)
+	}
+	if _, err := nodePool.Spec.Repair.GetMaxUnhealthy(1); err != nil {
+		return intstr.FromString(v1.DefaultMaxUnhealthy)
+	}
+	return v1.GetIntStrFromValue(*nodePool.Spec.Repair.MaxUnhealthy)
+}
+
+// Find a node with a condition that matches one of the unhealthy conditions defined by the repair policies
 // If there are multiple unhealthy status condition we will requeue based on the condition closest to its terminationDuration
-func (c *Controller) findUnhealthyConditions(node *corev1.Node) (nc *corev1.NodeCondition, cpTerminationDuration time.Duration) {
+func findUnhealthyConditions(node *corev1.Node, policies []cloudprovider.RepairPolicy) (nc *corev1.NodeCondition, matched cloudprovider.RepairPolicy) {
 	requeueTime := time.Time{}
-	for _, policy := range c.cloudProvider.RepairPolicies() {
+	for _, policy := range policies {
 		// check the status and the type on the condition
 		nodeCondition := nodeutils.GetCondition(node, policy.ConditionType)
 		if nodeCondition.Status == policy.ConditionStatus {
@@ -145,12 +217,56 @@ func (c *Controller) findUnhealthyConditions(node *corev1.Node) (nc *corev1.Node
 			// Determine requeue time
 			if requeueTime.IsZero() || requeueTime.After(terminationTime) {
 				nc = lo.ToPtr(nodeCondition)
-				cpTerminationDuration = policy.TolerationDuration
+				matched = policy
 				requeueTime = terminationTime
 			}
 		}
 	}
-	return nc, cpTerminationDuration
+	return nc, matched
+}
+
+// lastRebootAttempt returns the last reboot of the node for the unhealthy condition within the toleration window. A
+// reboot restarts the kubelet, which moves the transition time of the condition forward, so reboots up to the
+// toleration duration before the condition last transitioned still count.
+func lastRebootAttempt(nodeClaim *v1.NodeClaim, condition *corev1.NodeCondition, policy cloudprovider.RepairPolicy) (v1.RepairAttempt, bool) {
+	windowStart := condition.LastTransitionTime.Add(-policy.TolerationDuration)
+	attempt, _, ok := lo.FindLastIndexOf(nodeClaim.Status.RepairAttempts, func(attempt v1.RepairAttempt) bool {
+		return attempt.Action == v1.RepairActionReboot &&
+			attempt.ConditionType == condition.Type &&
+			attempt.ConditionStatus == condition.Status &&
+			!attempt.Time.Time.Before(windowStart)
+	})
+	return attempt, ok
+}
+
+func (c *Controller) reboot(ctx context.Context, rebooter cloudprovider.Rebooter, node *corev1.Node, nodeClaim *v1.NodeClaim,
+	condition *corev1.NodeCondition, policy cloudprovider.RepairPolicy) (reconcile.Result, error) {
+	// Record the attempt first, so that the node isn't rebooted twice if the attempt can't be recorded. A reboot that
+	// fails is escalated to a replacement like a reboot that doesn't repair the node.
+	if err := c.recordRepairAttempt(ctx, nodeClaim, v1.RepairActionReboot, condition); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if err := rebooter.Reboot(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("rebooting node, %w", err))
+	}
+	log.FromContext(ctx).V(1).WithValues("condition", condition.Type).Info("rebooted unhealthy node")
+	c.recorder.Publish(NodeRebooted(node, nodeClaim, condition.Type)...)
+	return reconcile.Result{RequeueAfter: policy.TolerationDuration}, nil
+}
+
+// recordRepairAttempt records the attempt on the NodeClaim status, keeping the most recent attempts
+func (c *Controller) recordRepairAttempt(ctx context.Context, nodeClaim *v1.NodeClaim, action v1.RepairAction, condition *corev1.NodeCondition) error {
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Status.RepairAttempts = append(nodeClaim.Status.RepairAttempts, v1.RepairAttempt{
+		Action:          action,
+		ConditionType:   condition.Type,
+		ConditionStatus: condition.Status,
+		Time:            metav1.NewTime(c.clock.Now()),
+	})
+	if len(nodeClaim.Status.RepairAttempts) > v1.MaxRepairAttempts {
+		nodeClaim.Status.RepairAttempts = nodeClaim.Status.RepairAttempts[len(nodeClaim.Status.RepairAttempts)-v1.MaxRepairAttempts:]
+	}
+	return c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored))
 }
 
 func (c *Controller) annotateTerminationGracePeriod(ctx context.Context, nodeClaim *v1.NodeClaim) error {
@@ -165,46 +281,37 @@ func (c *Controller) annotateTerminationGracePeriod(ctx context.Context, nodeCla
 }
 
 // isNodePoolHealthy checks if the number of unhealthy nodes managed by the given NodePool exceeds the health threshold.
-// defined by the cloud provider
-// Up to 20% of Nodes may be unhealthy before the NodePool becomes unhealthy (or the nearest whole number, rounding up).
+// defined by the NodePool
+// By default, up to 20% of Nodes may be unhealthy before the NodePool becomes unhealthy (or the nearest whole number, rounding up).
 // For example, given a NodePool with three nodes, one may be unhealthy without rendering the NodePool unhealthy, even though that's 33% of the total nodes.
 // This is analogous to how minAvailable and maxUnavailable work for PodDisruptionBudgets: https://kubernetes.io/docs/tasks/run-application/configure-pdb/#rounding-logic-when-specifying-percentages.
-func (c *Controller) isNodePoolHealthy(ctx context.Context, nodePoolName string) (bool, error) {
+func (c *Controller) isNodePoolHealthy(ctx context.Context, nodePoolName string, policies []cloudprovider.RepairPolicy, maxUnhealthy intstr.IntOrString) (bool, error) {
 	nodeList := &corev1.NodeList{}
 	if err := c.kubeClient.List(ctx, nodeList, client.MatchingLabels(map[string]string{v1.NodePoolLabelKey: nodePoolName})); err != nil {
 		return false, err
 	}
 
-	return c.isHealthyForNodes(nodeList.Items), nil
+	return isHealthyForNodes(nodeList.Items, policies, maxUnhealthy), nil
 }
 
-func (c *Controller) isClusterHealthy(ctx context.Context) (bool, error) {
+func (c *Controller) isClusterHealthy(ctx context.Context, policies []cloudprovider.RepairPolicy, maxUnhealthy intstr.IntOrString) (bool, error) {
 	nodeList := &corev1.NodeList{}
 	if err := c.kubeClient.List(ctx, nodeList); err != nil {
 		return false, err
 	}
 
-	return c.isHealthyForNodes(nodeList.Items), nil
+	return isHealthyForNodes(nodeList.Items, policies, maxUnhealthy), nil
 }
 
-func (c *Controller) isHealthyForNodes(nodes []corev1.Node) bool {
+func isHealthyForNodes(nodes []corev1.Node, policies []cloudprovider.RepairPolicy, maxUnhealthy intstr.IntOrString) bool {
 	unhealthyNodeCount := lo.CountBy(nodes, func(node corev1.Node) bool {
-		_, found := lo.Find(c.cloudProvider.RepairPolicies(), func(policy cloudprovider.RepairPolicy) bool {
+		_, found := lo.Find(policies, func(policy cloudprovider.RepairPolicy) bool {
 			nodeCondition := nodeutils.GetCondition(lo.ToPtr(node), policy.ConditionType)
 			return nodeCondition.Status == policy.ConditionStatus
 		})
 		return found
 	})
 
-	threshold := lo.Must(intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(allowedUnhealthyPercent), len(nodes), true))
+	threshold := lo.Must(intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(maxUnhealthy), len(nodes), true))
 	return unhealthyNodeCount <= threshold
 }
-
-func (c *Controller) publishNodePoolHealthEvent(ctx context.Context, node *corev1.Node, nodeClaim *v1.NodeClaim, npName string) error {
-	np := &v1.NodePool{}
-	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: npName}, np); err != nil {
-		return client.IgnoreNotFound(err)
-	}
-	c.recorder.Publish(NodeRepairBlocked(node, nodeClaim, np, fmt.Sprintf("more then %s nodes are unhealthy in the nodepool", allowedUnhealthyPercent.String()))...)
-	return nil
-}
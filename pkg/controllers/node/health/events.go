@@ -17,6 +17,7 @@ limitations under the License.
 package health
 
 import (
+	"fmt"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -74,3 +75,22 @@ func NodeRepairBlockedUnmanagedNodeClaim(node *corev1.Node, nodeClaim *v1.NodeCl
 		},
 	}
 }
+
+func NodeRebooted(node *corev1.Node, nodeClaim *v1.NodeClaim, condition corev1.NodeConditionType) []events.Event {
+	return []events.Event{
+		{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeWarning,
+			Reason:         "NodeRebooted",
+			Message:        fmt.Sprintf("Rebooted unhealthy node, condition %s", condition),
+			DedupeValues:   []string{string(node.UID)},
+		},
+		{
+			InvolvedObject: nodeClaim,
+			Type:           corev1.EventTypeWarning,
+			Reason:         "NodeRebooted",
+			Message:        fmt.Sprintf("Rebooted unhealthy node, condition %s", condition),
+			DedupeValues:   []string{string(nodeClaim.UID)},
+		},
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clock "k8s.io/utils/clock/testing"
@@ -326,6 +327,273 @@ var _ = Describe("Node Health", func() {
 			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
 		})
 	})
+	Context("NodePool Repair", func() {
+		It("should delete nodes that are unhealthy by a nodepool repair policy", func() {
+			nodePool.Spec.Repair.Policies = []v1.RepairPolicy{{
+				ConditionType:      "KernelDeadlock",
+				ConditionStatus:    corev1.ConditionTrue,
+				TolerationDuration: metav1.Duration{Duration: 10 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "KernelDeadlock",
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should delete nodes that are unhealthy by a nodepool repair policy when the cloud provider has no repair policies", func() {
+			cloudProvider.RepairPolicy = nil
+			nodePool.Spec.Repair.Policies = []v1.RepairPolicy{{
+				ConditionType:      "KernelDeadlock",
+				ConditionStatus:    corev1.ConditionTrue,
+				TolerationDuration: metav1.Duration{Duration: 10 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "KernelDeadlock",
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should not repair nodes when neither the cloud provider nor the nodepool have repair policies", func() {
+			cloudProvider.RepairPolicy = nil
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(60 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+		})
+		It("should not delete nodes that are unhealthy by another nodepool's repair policy", func() {
+			otherNodePool := test.NodePool()
+			otherNodePool.Spec.Repair.Policies = []v1.RepairPolicy{{
+				ConditionType:      "KernelDeadlock",
+				ConditionStatus:    corev1.ConditionTrue,
+				TolerationDuration: metav1.Duration{Duration: 10 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "KernelDeadlock",
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, otherNodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+		})
+		It("should override the toleration duration of a cloud provider repair policy", func() {
+			nodePool.Spec.Repair.Policies = []v1.RepairPolicy{{
+				ConditionType:      "BadNode",
+				ConditionStatus:    corev1.ConditionFalse,
+				TolerationDuration: metav1.Duration{Duration: 2 * time.Hour},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(60 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			result := ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute*60, time.Second))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			// The cloud provider policy must not be modified by the override
+			Expect(cloudProvider.RepairPolicies()[0].TolerationDuration).To(Equal(30 * time.Minute))
+		})
+		DescribeTable("should respect the maxUnhealthy of the nodepool",
+			func(maxUnhealthy string, unhealthy int, expectDeleted bool) {
+				nodePool.Spec.Repair.MaxUnhealthy = lo.ToPtr(maxUnhealthy)
+				ExpectApplied(ctx, env.Client, nodePool)
+				nodeClaims := []*v1.NodeClaim{}
+				nodes := []*corev1.Node{}
+				for i := range 10 {
+					nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{v1.TerminationFinalizer}}})
+					if i < unhealthy {
+						node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+							Type:               "BadNode",
+							Status:             corev1.ConditionFalse,
+							LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+						})
+					}
+					node.Labels[v1.NodePoolLabelKey] = nodePool.Name
+					nodeClaim.Labels[v1.NodePoolLabelKey] = nodePool.Name
+					nodeClaims = append(nodeClaims, nodeClaim)
+					nodes = append(nodes, node)
+					ExpectApplied(ctx, env.Client, nodeClaim, node)
+				}
+				fakeClock.Step(60 * time.Minute)
+
+				ExpectObjectReconciled(ctx, env.Client, healthController, nodes[0])
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaims[0])
+				Expect(nodeClaim.DeletionTimestamp != nil).To(Equal(expectDeleted))
+			},
+			Entry("percentage above the unhealthy nodes", "50%", 3, true),
+			Entry("percentage below the unhealthy nodes", "10%", 3, false),
+			Entry("count equal to the unhealthy nodes", "3", 3, true),
+			Entry("count below the unhealthy nodes", "2", 3, false),
+			Entry("zero", "0", 1, false),
+		)
+		It("should use the nodepool maxUnhealthy in the blocked event", func() {
+			nodePool.Spec.Repair.MaxUnhealthy = lo.ToPtr("0")
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(60 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(recorder.DetectedEvent("more then 0 nodes are unhealthy in the nodepool")).To(BeTrue())
+		})
+	})
+	Context("Reboot", func() {
+		var rebooter *rebootingCloudProvider
+		var rebootController *health.Controller
+		BeforeEach(func() {
+			rebooter = &rebootingCloudProvider{CloudProvider: cloudProvider}
+			rebootController = health.NewController(env.Client, rebooter, fakeClock, recorder)
+			recorder.Reset()
+			nodePool.Spec.Repair.Policies = []v1.RepairPolicy{{
+				ConditionType:      "BadNode",
+				ConditionStatus:    corev1.ConditionFalse,
+				TolerationDuration: metav1.Duration{Duration: 10 * time.Minute},
+				Action:             v1.RepairActionReboot,
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+		})
+		It("should reboot unhealthy nodes and record the attempt", func() {
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			result := ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+
+			Expect(rebooter.rebooted).To(ConsistOf(nodeClaim.Name))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(nodeClaim.Status.RepairAttempts).To(HaveLen(1))
+			Expect(nodeClaim.Status.RepairAttempts[0].Action).To(Equal(v1.RepairActionReboot))
+			Expect(nodeClaim.Status.RepairAttempts[0].ConditionType).To(Equal(corev1.NodeConditionType("BadNode")))
+			Expect(nodeClaim.Status.RepairAttempts[0].ConditionStatus).To(Equal(corev1.ConditionFalse))
+			Expect(recorder.DetectedEvent("Rebooted unhealthy node, condition BadNode")).To(BeTrue())
+		})
+		It("should replace nodes that are still unhealthy after the toleration duration following the reboot", func() {
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+
+			// The node isn't rebooted again, nor replaced, until the toleration duration has passed since the reboot
+			fakeClock.Step(5 * time.Minute)
+			result := ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(result.RequeueAfter).To(BeNumerically("~", 5*time.Minute, time.Second))
+			Expect(rebooter.rebooted).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+			fakeClock.Step(6 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(rebooter.rebooted).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+			Expect(lo.Map(nodeClaim.Status.RepairAttempts, func(attempt v1.RepairAttempt, _ int) v1.RepairAction {
+				return attempt.Action
+			})).To(Equal([]v1.RepairAction{v1.RepairActionReboot, v1.RepairActionReplace}))
+		})
+		It("should reboot nodes again when the condition transitions after the reboot", func() {
+			nodeClaim.Status.RepairAttempts = []v1.RepairAttempt{{
+				Action:          v1.RepairActionReboot,
+				ConditionType:   "BadNode",
+				ConditionStatus: corev1.ConditionFalse,
+				Time:            metav1.Time{Time: fakeClock.Now().Add(-time.Hour)},
+			}}
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+
+			Expect(rebooter.rebooted).To(ConsistOf(nodeClaim.Name))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(nodeClaim.Status.RepairAttempts).To(HaveLen(2))
+		})
+		It("should replace nodes if the cloud provider can't reboot them", func() {
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+			Expect(nodeClaim.Status.RepairAttempts).To(HaveLen(1))
+			Expect(nodeClaim.Status.RepairAttempts[0].Action).To(Equal(v1.RepairActionReplace))
+		})
+		It("should escalate to replacing nodes when the reboot moves the transition time of the condition forward", func() {
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(rebooter.rebooted).To(HaveLen(1))
+
+			// The kubelet restarts and reports the condition again
+			fakeClock.Step(time.Minute)
+			node = ExpectExists(ctx, env.Client, node)
+			node.Status.Conditions = []corev1.NodeCondition{{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			}}
+			ExpectApplied(ctx, env.Client, node)
+
+			fakeClock.Step(11 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(rebooter.rebooted).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should record the attempt and not reboot again if the reboot fails", func() {
+			rebooter.err = fmt.Errorf("reboot failed")
+			fakeClock.Step(15 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, rebootController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(nodeClaim.Status.RepairAttempts).To(HaveLen(1))
+			Expect(nodeClaim.Status.RepairAttempts[0].Action).To(Equal(v1.RepairActionReboot))
+
+			// The failed reboot is escalated to a replacement after the toleration duration
+			rebooter.err = nil
+			fakeClock.Step(5 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			Expect(rebooter.rebooted).To(BeEmpty())
+			fakeClock.Step(6 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, rebootController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+	})
 	Context("Metrics", func() {
 		It("should fire a karpenter_nodeclaims_disrupted_total metric when unhealthy", func() {
 			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
@@ -347,3 +615,18 @@ var _ = Describe("Node Health", func() {
 		})
 	})
 })
+
+// rebootingCloudProvider is a cloud provider that implements the Rebooter extension
+type rebootingCloudProvider struct {
+	*fake.CloudProvider
+	rebooted []string
+	err      error
+}
+
+func (c *rebootingCloudProvider) Reboot(_ context.Context, nodeClaim *v1.NodeClaim) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.rebooted = append(c.rebooted, nodeClaim.Name)
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
 )
 
 const (
@@ -138,7 +139,72 @@ func areStaticFieldsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) clou
 	if nodePoolHashVersion != nodeClaimHashVersion {
 		return ""
 	}
-	return lo.Ternary(nodePoolHash != nodeClaimHash, NodePoolDrifted, "")
+	if nodePoolHash == nodeClaimHash {
+		return ""
+	}
+	// Metadata that can be updated in place isn't considered drift, the propagation controller updates it on the node
+	if nodePool.Spec.Disruption.MetadataUpdatePolicy == v1.MetadataUpdatePolicyInPlace && !ClassifyTemplateDrift(nodePool, nodeClaim).Replace {
+		return ""
+	}
+	return NodePoolDrifted
+}
+
+// TemplateDrift describes which fields of the NodePool template a NodeClaim has drifted from
+type TemplateDrift struct {
+	// Labels is true when the template labels differ from the labels that were applied to the NodeClaim
+	Labels bool
+	// Taints is true when the template taints differ from the taints that were applied to the NodeClaim
+	Taints bool
+	// Replace is true when fields that can't be updated in place have drifted
+	Replace bool
+}
+
+// InPlace returns true if the NodeClaim has only drifted from fields that can be updated in place
+func (t TemplateDrift) InPlace() bool {
+	return !t.Replace && (t.Labels || t.Taints)
+}
+
+// ClassifyTemplateDrift compares the NodeClaim against the template of its NodePool field by field. NodeClaims that
+// were launched without the replacement hash, or whose taints can't be determined, are classified as requiring replacement.
+func ClassifyTemplateDrift(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) TemplateDrift {
+	nodePoolReplacementHash, foundNodePoolReplacementHash := nodePool.Annotations[v1.NodePoolReplacementHashAnnotationKey]
+	nodeClaimReplacementHash, foundNodeClaimReplacementHash := nodeClaim.Annotations[v1.NodePoolReplacementHashAnnotationKey]
+	drift := TemplateDrift{
+		Labels: areTemplateLabelsDrifted(nodePool, nodeClaim),
+		Replace: !foundNodePoolReplacementHash || !foundNodeClaimReplacementHash ||
+			nodePoolReplacementHash != nodeClaimReplacementHash ||
+			nodePool.Annotations[v1.NodePoolHashVersionAnnotationKey] != nodeClaim.Annotations[v1.NodePoolHashVersionAnnotationKey],
+	}
+	taints, err := nodeclaimutils.TemplateTaints(nodeClaim)
+	if err != nil {
+		drift.Replace = true
+		return drift
+	}
+	drift.Taints = areTemplateTaintsDrifted(nodePool.Spec.Template.Spec.Taints, taints)
+	return drift
+}
+
+func areTemplateTaintsDrifted(templateTaints, nodeClaimTaints []corev1.Taint) bool {
+	if len(templateTaints) != len(nodeClaimTaints) {
+		return true
+	}
+	return lo.SomeBy(templateTaints, func(taint corev1.Taint) bool {
+		return !lo.ContainsBy(nodeClaimTaints, func(t corev1.Taint) bool {
+			return taint.MatchTaint(&t) && taint.Value == t.Value
+		})
+	})
+}
+
+func areTemplateLabelsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) bool {
+	for k, v := range nodePool.Spec.Template.Labels {
+		if value, ok := nodeClaim.Labels[k]; !ok || value != v {
+			return true
+		}
+	}
+	return lo.SomeBy(nodeclaimutils.TemplateLabelKeys(nodeClaim), func(k string) bool {
+		_, ok := nodePool.Spec.Template.Labels[k]
+		return !ok
+	})
 }
 
 func areRequirementsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) cloudprovider.DriftReason {
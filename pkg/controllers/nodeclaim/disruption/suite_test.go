@@ -18,6 +18,7 @@ package disruption_test
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -84,7 +85,7 @@ var _ = BeforeEach(func() {
 					corev1.LabelTopologyZone: "test-zone-1a",
 				}),
 				Price:     fake.PriceFromResources(resources),
-				Available: true,
+				Available: math.MaxInt,
 			},
 			{
 				Requirements: scheduling.NewLabelRequirements(map[string]string{
@@ -92,7 +93,7 @@ var _ = BeforeEach(func() {
 					corev1.LabelTopologyZone: "test-zone-1a",
 				}),
 				Price:     fake.PriceFromResources(resources),
-				Available: true,
+				Available: math.MaxInt,
 			},
 		},
 	})
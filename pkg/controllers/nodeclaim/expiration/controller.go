@@ -18,8 +18,11 @@ package expiration
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -34,7 +37,9 @@ import (
 	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
 )
 
-// Expiration is a nodeclaim controller that deletes expired nodeclaims based on expireAfter
+// Expiration is a nodeclaim controller that deletes expired nodeclaims based on expireAfter. If the nodepool of a
+// nodeclaim has an expiration grace period, the disruption controller replaces the expired nodeclaim gracefully and
+// this controller only deletes it once the grace period has elapsed.
 type Controller struct {
 	clock         clock.Clock
 	kubeClient    client.Client
@@ -57,7 +62,7 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 	if !nodeClaim.DeletionTimestamp.IsZero() {
 		return reconcile.Result{}, nil
 	}
-	// From here there are three scenarios to handle:
+	// From here there are four scenarios to handle:
 	// 1. If ExpireAfter is not configured, exit expiration loop
 	if nodeClaim.Spec.ExpireAfter.Duration == nil {
 		return reconcile.Result{}, nil
@@ -68,27 +73,55 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 		// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
 		return reconcile.Result{RequeueAfter: expirationTime.Sub(c.clock.Now())}, nil
 	}
-	// 3. Otherwise, if the NodeClaim is expired we can forcefully expire the nodeclaim (by deleting it)
+	// 3. If the NodePool has an expiration grace period, the disruption controller replaces the NodeClaim during the
+	// grace period, so leave the reconcile loop until it has elapsed.
+	gracePeriod, err := c.expirationGracePeriod(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if gracePeriod != nil {
+		if forcefulExpirationTime := expirationTime.Add(gracePeriod.Duration); c.clock.Now().Before(forcefulExpirationTime) {
+			return reconcile.Result{RequeueAfter: forcefulExpirationTime.Sub(c.clock.Now())}, nil
+		}
+	}
+	// 4. Otherwise, if the NodeClaim is expired we can forcefully expire the nodeclaim (by deleting it)
 	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
-	// 4. The deletion timestamp has successfully been set for the NodeClaim, update relevant metrics.
+	// 5. The deletion timestamp has successfully been set for the NodeClaim, update relevant metrics.
 	log.FromContext(ctx).V(1).Info("deleting expired nodeclaim")
 	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
 		metrics.ReasonLabel:       metrics.ExpiredReason,
 		metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
 		metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
 	})
-	// We sleep here after the delete operation since we want to ensure that we are able to read our own writes so that
-	// we avoid duplicating metrics and log lines due to quick re-queues.
-	// USE CAUTION when determining whether to increase this timeout or remove this line
-	time.Sleep(time.Second)
-	return reconcile.Result{}, nil
+	// We requeue after the delete operation instead of blocking the worker so that the next reconcile reads our own
+	// write, sees the deletion timestamp and doesn't duplicate metrics and log lines.
+	return reconcile.Result{RequeueAfter: time.Second}, nil
+}
+
+// expirationGracePeriod returns the expiration grace period of the NodePool of the NodeClaim. NodeClaims without a
+// NodePool are expired forcefully.
+func (c *Controller) expirationGracePeriod(ctx context.Context, nodeClaim *v1.NodeClaim) (*metav1.Duration, error) {
+	nodePoolName, ok := nodeClaim.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return nil, nil
+	}
+	nodePool := &v1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePoolName}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting nodepool, %w", err)
+	}
+	return nodePool.Spec.Disruption.ExpirationGracePeriod, nil
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodeclaim.expiration").
 		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		// The expiration grace period of a NodePool changes when its NodeClaims are deleted forcefully
+		Watches(&v1.NodePool{}, nodeclaimutils.NodePoolEventHandler(c.kubeClient, c.cloudProvider)).
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
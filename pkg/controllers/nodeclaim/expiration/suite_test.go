@@ -169,6 +169,15 @@ var _ = Describe("Expiration", func() {
 
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
+	It("should requeue after deleting an expired nodeClaim", func() {
+		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("30s")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		// step forward to make the node expired
+		fakeClock.Step(60 * time.Second)
+		result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+		Expect(result.RequeueAfter).To(Equal(time.Second))
+	})
 	It("should return the requeue interval for the time between now and when the nodeClaim expires", func() {
 		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("200s")
 		ExpectApplied(ctx, env.Client, nodeClaim, node)
@@ -178,6 +187,39 @@ var _ = Describe("Expiration", func() {
 		result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
 		Expect(result.RequeueAfter).To(BeNumerically("~", time.Second*100, time.Second))
 	})
+	Context("Expiration Grace Period", func() {
+		BeforeEach(func() {
+			nodePool.Spec.Disruption.ExpirationGracePeriod = &metav1.Duration{Duration: 5 * time.Minute}
+		})
+		It("should not remove expired NodeClaims during the expiration grace period", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			// The grace period elapses 5 minutes after the nodeClaim expires
+			Expect(result.RequeueAfter).To(BeNumerically("~", 4*time.Minute+30*time.Second, time.Second))
+		})
+		It("should remove expired NodeClaims once the expiration grace period has elapsed", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+			fakeClock.Step(6 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: metrics.ExpiredReason,
+				"nodepool":          nodePool.Name,
+			})
+		})
+		It("should remove expired NodeClaims as soon as they expire if their NodePool doesn't exist", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim)
+
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+	})
 	It("shouldn't expire the same NodeClaim multiple times", func() {
 		nodeClaim.ObjectMeta.Finalizers = append(nodeClaim.ObjectMeta.Finalizers, "test-finalizer")
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
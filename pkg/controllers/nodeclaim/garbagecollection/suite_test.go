@@ -33,6 +33,7 @@ import (
 
 	"github.com/dcoppa/karpenter/pkg/apis"
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
 	nodeclaimgarbagecollection "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/garbagecollection"
 	nodeclaimlifcycle "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/lifecycle"
@@ -63,7 +64,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	cloudProvider = fake.NewCloudProvider()
 	garbageCollectionController = nodeclaimgarbagecollection.NewController(fakeClock, env.Client, cloudProvider)
-	nodeClaimController = nodeclaimlifcycle.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}))
+	nodeClaimController = nodeclaimlifcycle.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), cloudprovider.NewUnavailableOfferings())
 })
 
 var _ = AfterSuite(func() {
@@ -0,0 +1,300 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	"github.com/dcoppa/karpenter/pkg/events"
+	"github.com/dcoppa/karpenter/pkg/metrics"
+	"github.com/dcoppa/karpenter/pkg/operator/injection"
+	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
+	"github.com/dcoppa/karpenter/pkg/utils/pretty"
+)
+
+const (
+	// drainBudget is the time that is left to drain the node of an interrupted nodeclaim before the deadline of the
+	// notice. The nodeclaim is deleted once its replacements are initialized, or at the deadline minus the drain
+	// budget at the latest.
+	drainBudget = 2 * time.Minute
+	// replacementPollInterval is how often the replacements of an interrupted nodeclaim are checked for initialization
+	replacementPollInterval = 5 * time.Second
+)
+
+// Controller is a nodeclaim controller that reacts to the interruption notices of the cloud provider. It cordons the
+// node of an interrupted nodeclaim, launches the replacement capacity of its pods through the provisioner and deletes
+// the nodeclaim once the replacements are initialized so that the node is drained before the deadline of the notice.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	notifier      cloudprovider.InterruptionNotifier
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	recorder      events.Recorder
+
+	mu           sync.RWMutex
+	notices      map[string]cloudprovider.InterruptionNotice // keyed by provider id
+	replacements map[string][]string                         // names of the launched replacements, keyed by provider id
+	events       chan event.GenericEvent
+}
+
+// NewController constructs a nodeclaim interruption controller
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, notifier cloudprovider.InterruptionNotifier,
+	cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		notifier:      notifier,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		recorder:      recorder,
+		notices:       map[string]cloudprovider.InterruptionNotice{},
+		replacements:  map[string][]string{},
+		events:        make(chan event.GenericEvent, 100),
+	}
+}
+
+// Notify records the interruption notice so that the nodeclaim with its provider id is interrupted when it's reconciled
+func (c *Controller) Notify(notice cloudprovider.InterruptionNotice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Keep the earliest deadline if the cloud provider sends multiple notices for the same instance
+	if existing, ok := c.notices[notice.ProviderID]; ok && existing.Deadline.Before(notice.Deadline) {
+		return
+	}
+	c.notices[notice.ProviderID] = notice
+}
+
+func (c *Controller) notice(providerID string) (cloudprovider.InterruptionNotice, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	notice, ok := c.notices[providerID]
+	return notice, ok
+}
+
+func (c *Controller) launched(providerID string) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names, ok := c.replacements[providerID]
+	return names, ok
+}
+
+func (c *Controller) setLaunched(providerID string, names []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replacements[providerID] = names
+}
+
+func (c *Controller) forget(providerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.notices, providerID)
+	delete(c.replacements, providerID)
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.interruption")
+
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) || nodeClaim.Status.ProviderID == "" {
+		return reconcile.Result{}, nil
+	}
+	notice, ok := c.notice(nodeClaim.Status.ProviderID)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	// The nodeclaim is already being terminated, so its node is already being drained
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		c.forget(nodeClaim.Status.ProviderID)
+		return reconcile.Result{}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("interruption-reason", notice.Reason, "deadline", notice.Deadline.Format(time.RFC3339)))
+
+	stateNode, found := lo.Find(c.cluster.Nodes(), func(n *state.StateNode) bool {
+		return n.ProviderID() == nodeClaim.Status.ProviderID
+	})
+	var node *corev1.Node
+	if found && stateNode.Node != nil {
+		node = stateNode.Node
+		// 1. Cordon the node so that no new pods schedule to it
+		if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, true, stateNode); err != nil {
+			return reconcile.Result{}, fmt.Errorf("tainting node with %s, %w", pretty.Taint(v1.DisruptedNoScheduleTaint), err)
+		}
+		// 2. Launch the replacement capacity of its pods before it's drained. Marking the node for deletion keeps the
+		// provisioner from scheduling to it and launching capacity for its pods again.
+		names, ok := c.launched(nodeClaim.Status.ProviderID)
+		if !ok {
+			c.cluster.MarkForDeletion(nodeClaim.Status.ProviderID)
+			var err error
+			if names, err = c.launchReplacements(ctx, stateNode); err != nil {
+				c.cluster.UnmarkForDeletion(nodeClaim.Status.ProviderID)
+				return reconcile.Result{}, err
+			}
+			c.setLaunched(nodeClaim.Status.ProviderID, names)
+		}
+		// 3. Wait for the replacements to be initialized before the node is drained, unless the node has to be drained
+		// now to be drained before the deadline
+		if drainStart := notice.Deadline.Add(-drainBudget); c.clock.Now().Before(drainStart) {
+			initialized, err := c.areInitialized(ctx, names)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !initialized {
+				return reconcile.Result{RequeueAfter: lo.Min([]time.Duration{replacementPollInterval, drainStart.Sub(c.clock.Now())})}, nil
+			}
+		}
+	}
+	// 4. Delete the nodeclaim, the termination controller drains the node and forcefully terminates it at the deadline
+	if err := c.annotateTerminationTimestamp(ctx, nodeClaim, notice.Deadline); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	log.FromContext(ctx).Info("deleting interrupted nodeclaim")
+	c.recorder.Publish(Interrupted(node, nodeClaim, notice)...)
+	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+		metrics.ReasonLabel:       metrics.InterruptedReason,
+		metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+	})
+	c.forget(nodeClaim.Status.ProviderID)
+	return reconcile.Result{}, nil
+}
+
+// launchReplacements schedules the reschedulable pods of the node against the rest of the cluster and launches the
+// nodeclaims that they need. It returns the names of the launched nodeclaims.
+func (c *Controller) launchReplacements(ctx context.Context, stateNode *state.StateNode) ([]string, error) {
+	pods, err := stateNode.ReschedulablePods(ctx, c.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("listing reschedulable pods, %w", err)
+	}
+	if len(pods) == 0 {
+		return nil, nil
+	}
+	s, err := c.provisioner.NewScheduler(ctx, pods, c.cluster.Nodes().Active())
+	if err != nil {
+		return nil, fmt.Errorf("creating scheduler, %w", err)
+	}
+	results := s.Solve(ctx, pods).TruncateInstanceTypes(scheduling.MaxInstanceTypes)
+	if len(results.NewNodeClaims) == 0 {
+		return nil, nil
+	}
+	names, err := c.provisioner.CreateNodeClaims(ctx, results.NewNodeClaims, provisioning.WithReason(metrics.InterruptedReason))
+	if err != nil {
+		return nil, fmt.Errorf("launching replacement nodeclaims, %w", err)
+	}
+	// Nominate the replacements so that the pods of the node wait on them once they're evicted
+	results.Record(ctx, c.recorder, c.cluster)
+	return names, nil
+}
+
+// areInitialized returns true if all the replacements are initialized. Replacements that were deleted, for example
+// because they failed to launch, aren't waited on.
+func (c *Controller) areInitialized(ctx context.Context, names []string) (bool, error) {
+	for _, name := range names {
+		replacement := &v1.NodeClaim{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: name}, replacement); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, fmt.Errorf("getting replacement nodeclaim, %w", err)
+		}
+		if !replacement.StatusConditions().Get(v1.ConditionTypeInitialized).IsTrue() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// annotateTerminationTimestamp sets the termination timestamp of the nodeclaim to the deadline of the notice, unless
+// the nodeclaim already has to be terminated earlier
+func (c *Controller) annotateTerminationTimestamp(ctx context.Context, nodeClaim *v1.NodeClaim, deadline time.Time) error {
+	if value, ok := nodeClaim.Annotations[v1.NodeClaimTerminationTimestampAnnotationKey]; ok {
+		if existing, err := time.Parse(time.RFC3339, value); err == nil && !existing.After(deadline) {
+			return nil
+		}
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: deadline.Format(time.RFC3339)})
+	return c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored))
+}
+
+// watch streams the interruption notices of the cloud provider and enqueues the nodeclaims that they interrupt
+func (c *Controller) watch(ctx context.Context) error {
+	notices, err := c.notifier.InterruptionNotices(ctx)
+	if err != nil {
+		return fmt.Errorf("watching interruption notices, %w", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notice, ok := <-notices:
+			if !ok {
+				return nil
+			}
+			nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider, nodeclaimutils.ForProviderID(notice.ProviderID))
+			if err != nil {
+				log.FromContext(ctx).WithValues("provider-id", notice.ProviderID).Error(err, "failed listing nodeclaims for interruption notice")
+				continue
+			}
+			// The instance isn't managed by Karpenter, so there is nothing to interrupt
+			if len(nodeClaims) == 0 {
+				continue
+			}
+			c.Notify(notice)
+			for _, nodeClaim := range nodeClaims {
+				c.events <- event.GenericEvent{Object: nodeClaim}
+			}
+		}
+	}
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	if err := m.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		return c.watch(injection.WithControllerName(ctx, "nodeclaim.interruption"))
+	})); err != nil {
+		return err
+	}
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.interruption").
+		WatchesRawSource(source.Channel(c.events, &handler.EnqueueRequestForObject{})).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
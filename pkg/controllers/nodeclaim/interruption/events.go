@@ -0,0 +1,48 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/events"
+)
+
+func Interrupted(node *corev1.Node, nodeClaim *v1.NodeClaim, notice cloudprovider.InterruptionNotice) (evs []events.Event) {
+	message := fmt.Sprintf("Interrupted by the cloud provider (%s), draining before %s", notice.Reason, notice.Deadline.Format(time.RFC3339))
+	if node != nil {
+		evs = append(evs, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Interrupted",
+			Message:        message,
+			DedupeValues:   []string{string(node.UID)},
+		})
+	}
+	return append(evs, events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Interrupted",
+		Message:        message,
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	})
+}
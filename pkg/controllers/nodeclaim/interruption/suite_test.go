@@ -0,0 +1,282 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/dcoppa/karpenter/pkg/apis"
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
+	"github.com/dcoppa/karpenter/pkg/controllers/disruption"
+	"github.com/dcoppa/karpenter/pkg/controllers/disruption/orchestration"
+	"github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/interruption"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	"github.com/dcoppa/karpenter/pkg/controllers/state/informer"
+	"github.com/dcoppa/karpenter/pkg/metrics"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
+	"github.com/dcoppa/karpenter/pkg/test"
+	. "github.com/dcoppa/karpenter/pkg/test/expectations"
+	"github.com/dcoppa/karpenter/pkg/test/v1alpha1"
+	. "github.com/dcoppa/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var interruptionController *interruption.Controller
+var disruptionController *disruption.Controller
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+var nodeStateController *informer.NodeController
+var nodeClaimStateController *informer.NodeClaimController
+var recorder *test.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Interruption")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(
+		test.WithCRDs(apis.CRDs...),
+		test.WithCRDs(v1alpha1.CRDs...),
+		test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx), test.NodeProviderIDFieldIndexer(ctx)),
+	)
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	recorder = test.NewEventRecorder()
+	prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, cloudprovider.NewUnavailableOfferings())
+	interruptionController = interruption.NewController(fakeClock, env.Client, cloudProvider, nil, cluster, prov, recorder)
+	disruptionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster,
+		orchestration.NewQueue(env.Client, recorder, cluster, fakeClock, prov))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock.SetTime(time.Now())
+	cloudProvider.Reset()
+	cloudProvider.InstanceTypes = fake.InstanceTypesAssorted()
+	recorder.Reset()
+	cluster.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+
+	// Reset the metrics collectors
+	metrics.NodeClaimsDisruptedTotal.Reset()
+})
+
+var _ = Describe("Interruption", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:     nodePool.Name,
+					v1.CapacityTypeLabelKey: v1.CapacityTypeSpot,
+				},
+				Finalizers: []string{v1.TerminationFinalizer},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+	})
+	It("should cordon, replace and drain an interrupted nodeclaim before the deadline", func() {
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		deadline := fakeClock.Now().Add(10 * time.Minute).Truncate(time.Second)
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Reason: "SpotReclamation", Deadline: deadline})
+		result := ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		// The node is cordoned
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+
+		// A replacement is launched for the pod of the node
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(2))
+		replacement, ok := lo.Find(nodeClaims, func(nc *v1.NodeClaim) bool { return nc.Name != nodeClaim.Name })
+		Expect(ok).To(BeTrue())
+
+		// The nodeclaim isn't deleted until the replacement is initialized
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+		replacement.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
+		ExpectApplied(ctx, env.Client, replacement)
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		// The nodeclaim is deleted and is terminated at the deadline at the latest
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTerminationTimestampAnnotationKey, deadline.Format(time.RFC3339)))
+		Expect(recorder.Calls("Interrupted")).To(Equal(2))
+		ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+			metrics.ReasonLabel:       metrics.InterruptedReason,
+			metrics.NodePoolLabel:     nodePool.Name,
+			metrics.CapacityTypeLabel: v1.CapacityTypeSpot,
+		})
+	})
+	It("should delete the nodeclaim at the deadline minus the drain budget if the replacements aren't initialized", func() {
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: fakeClock.Now().Add(10 * time.Minute)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+		fakeClock.Step(8 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		// The replacement is only launched once
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+	})
+	It("should keep the node cordoned while the disruption controller runs", func() {
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: fakeClock.Now().Add(10 * time.Minute)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(2))
+		replacement, ok := lo.Find(nodeClaims, func(nc *v1.NodeClaim) bool { return nc.Name != nodeClaim.Name })
+		Expect(ok).To(BeTrue())
+		ExpectReconcileSucceeded(ctx, nodeClaimStateController, client.ObjectKeyFromObject(replacement))
+
+		// The disruption controller doesn't remove the taint of a node that is waiting on its replacements
+		ExpectSingletonReconciled(ctx, disruptionController)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+		replacement.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
+		ExpectApplied(ctx, env.Client, replacement)
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.DisruptedNoScheduleTaint))
+	})
+	It("should not launch a replacement for a nodeclaim without pods", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: fakeClock.Now().Add(10 * time.Minute)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+	})
+	It("should not interrupt a nodeclaim without an interruption notice", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: "fake:///other", Deadline: fakeClock.Now().Add(2 * time.Minute)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+	})
+	It("should keep the earliest deadline when the instance is notified multiple times", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		deadline := fakeClock.Now().Add(time.Minute).Truncate(time.Second)
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: deadline})
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: deadline.Add(time.Hour)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTerminationTimestampAnnotationKey, deadline.Format(time.RFC3339)))
+	})
+	It("should keep an earlier termination timestamp of the nodeclaim", func() {
+		terminationTimestamp := fakeClock.Now().Add(time.Minute).Truncate(time.Second).Format(time.RFC3339)
+		nodeClaim.Annotations = map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: terminationTimestamp}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		interruptionController.Notify(cloudprovider.InterruptionNotice{ProviderID: nodeClaim.Status.ProviderID, Deadline: fakeClock.Now().Add(time.Hour)})
+		ExpectObjectReconciled(ctx, env.Client, interruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTerminationTimestampAnnotationKey, terminationTimestamp))
+	})
+})
@@ -36,10 +36,11 @@ import (
 )
 
 type Launch struct {
-	kubeClient    client.Client
-	cloudProvider cloudprovider.CloudProvider
-	cache         *cache.Cache // exists due to eventual consistency on the cache
-	recorder      events.Recorder
+	kubeClient           client.Client
+	cloudProvider        cloudprovider.CloudProvider
+	cache                *cache.Cache // exists due to eventual consistency on the cache
+	recorder             events.Recorder
+	unavailableOfferings *cloudprovider.UnavailableOfferings
 }
 
 func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
@@ -77,6 +78,9 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 		case cloudprovider.IsInsufficientCapacityError(err):
 			l.recorder.Publish(InsufficientCapacityErrorEvent(nodeClaim, err))
 			log.FromContext(ctx).Error(err, "failed launching nodeclaim")
+			for _, offering := range unavailableOfferings(nodeClaim, err) {
+				l.unavailableOfferings.MarkUnavailable(ctx, "InsufficientCapacityError", offering)
+			}
 
 			if err = l.kubeClient.Delete(ctx, nodeClaim); err != nil {
 				return nil, client.IgnoreNotFound(err)
@@ -117,6 +121,28 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 	return created, nil
 }
 
+// unavailableOfferings returns the offerings that failed to launch due to insufficient capacity. If the CloudProvider
+// didn't report the offerings, the offering is inferred from the NodeClaim when its requirements only allow a single
+// instance type, zone and capacity type.
+func unavailableOfferings(nodeClaim *v1.NodeClaim, err error) []cloudprovider.OfferingKey {
+	var icErr *cloudprovider.InsufficientCapacityError
+	if errors.As(err, &icErr) && len(icErr.Offerings) > 0 {
+		return icErr.Offerings
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	instanceTypes := requirements.Get(corev1.LabelInstanceTypeStable)
+	zones := requirements.Get(corev1.LabelTopologyZone)
+	capacityTypes := requirements.Get(v1.CapacityTypeLabelKey)
+	if instanceTypes.Len() != 1 || zones.Len() != 1 || capacityTypes.Len() != 1 {
+		return nil
+	}
+	return []cloudprovider.OfferingKey{{
+		InstanceType: instanceTypes.Any(),
+		Zone:         zones.Any(),
+		CapacityType: capacityTypes.Any(),
+	}}
+}
+
 func PopulateNodeClaimDetails(nodeClaim, retrieved *v1.NodeClaim) *v1.NodeClaim {
 	// These are ordered in priority order so that user-defined nodeClaim labels and requirements trump retrieved labels
 	// or the static nodeClaim labels
@@ -30,6 +30,7 @@ import (
 
 	"github.com/dcoppa/karpenter/pkg/apis"
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
 	nodeclaimlifecycle "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	"github.com/dcoppa/karpenter/pkg/events"
@@ -45,6 +46,7 @@ var nodeClaimController *nodeclaimlifecycle.Controller
 var env *test.Environment
 var fakeClock *clock.FakeClock
 var cloudProvider *fake.CloudProvider
+var unavailableOfferings *cloudprovider.UnavailableOfferings
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -58,7 +60,8 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 
 	cloudProvider = fake.NewCloudProvider()
-	nodeClaimController = nodeclaimlifecycle.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}))
+	unavailableOfferings = cloudprovider.NewUnavailableOfferings()
+	nodeClaimController = nodeclaimlifecycle.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), unavailableOfferings)
 })
 
 var _ = AfterSuite(func() {
@@ -69,6 +72,7 @@ var _ = AfterEach(func() {
 	fakeClock.SetTime(time.Now())
 	ExpectCleanedUp(ctx, env.Client)
 	cloudProvider.Reset()
+	unavailableOfferings.Flush()
 })
 
 var _ = Describe("Finalizer", func() {
@@ -0,0 +1,162 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/disruption"
+	"github.com/dcoppa/karpenter/pkg/operator/injection"
+	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "github.com/dcoppa/karpenter/pkg/utils/nodepool"
+)
+
+// Controller propagates changes to the labels and taints of a NodePool template onto the existing Nodes and NodeClaims
+// of NodePools that update metadata in place, rather than replacing them through drift.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", klog.KRef(nodeClaim.Namespace, nodeClaim.Name)))
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) || !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	// The node must have been registered for the metadata of the NodeClaim to have been applied to it
+	if !nodeClaim.StatusConditions().Get(v1.ConditionTypeRegistered).IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	nodePoolName, ok := nodeClaim.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	nodePool := &v1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePoolName}, nodePool); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if nodePool.Spec.Disruption.MetadataUpdatePolicy != v1.MetadataUpdatePolicyInPlace {
+		return reconcile.Result{}, nil
+	}
+	nodePoolHash, ok := nodePool.Annotations[v1.NodePoolHashAnnotationKey]
+	if !ok || nodeClaim.Annotations[v1.NodePoolHashAnnotationKey] == nodePoolHash {
+		return reconcile.Result{}, nil
+	}
+	// NodeClaims that have drifted from fields that can't be updated in place are replaced by drift instead
+	if disruption.ClassifyTemplateDrift(nodePool, nodeClaim).Replace {
+		return reconcile.Result{}, nil
+	}
+	previousTaints, err := nodeclaimutils.TemplateTaints(nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	removedLabelKeys := lo.Reject(nodeclaimutils.TemplateLabelKeys(nodeClaim), func(k string, _ int) bool {
+		_, ok := nodePool.Spec.Template.Labels[k]
+		return ok
+	})
+	annotations := map[string]string{
+		v1.NodePoolHashAnnotationKey:            nodePoolHash,
+		v1.NodePoolHashVersionAnnotationKey:     nodePool.Annotations[v1.NodePoolHashVersionAnnotationKey],
+		v1.NodePoolReplacementHashAnnotationKey: nodePool.Annotations[v1.NodePoolReplacementHashAnnotationKey],
+	}
+
+	// Update the node first, so that a failure leaves the NodeClaim with the previous hash and the update is retried
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		if nodeclaimutils.IsNodeNotFoundError(err) || nodeclaimutils.IsDuplicateNodeError(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting node for nodeclaim, %w", err)
+	}
+	storedNode := node.DeepCopy()
+	node.Labels = lo.Assign(lo.OmitByKeys(node.Labels, removedLabelKeys), nodePool.Spec.Template.Labels)
+	node.Annotations = lo.Assign(node.Annotations, annotations)
+	node.Spec.Taints = updateTaints(node.Spec.Taints, previousTaints, nodePool.Spec.Template.Spec.Taints)
+	if !equality.Semantic.DeepEqual(storedNode, node) {
+		if err = c.kubeClient.Patch(ctx, node, client.MergeFrom(storedNode)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Labels = lo.Assign(lo.OmitByKeys(nodeClaim.Labels, removedLabelKeys), nodePool.Spec.Template.Labels)
+	nodeClaim.Annotations = lo.Assign(lo.OmitByKeys(nodeClaim.Annotations, []string{v1.NodeClaimTemplateLabelsAnnotationKey}), annotations, map[string]string{
+		v1.NodeClaimTemplateTaintsAnnotationKey: nodeclaimutils.TemplateTaintsAnnotationValue(nodePool.Spec.Template.Spec.Taints),
+	})
+	if len(nodePool.Spec.Template.Labels) > 0 {
+		nodeClaim.Annotations[v1.NodeClaimTemplateLabelsAnnotationKey] = nodeclaimutils.TemplateLabelKeysAnnotationValue(nodePool.Spec.Template.Labels)
+	}
+	if !equality.Semantic.DeepEqual(stored, nodeClaim) {
+		if err = c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	log.FromContext(ctx).WithValues("Node", klog.KObj(node)).V(1).Info("updated labels and taints in place")
+	return reconcile.Result{}, nil
+}
+
+// updateTaints removes the taints that were previously applied from the template and the taints that are replaced by
+// the template, then adds the taints of the template. Taints that were added to the node by other means are kept.
+func updateTaints(taints, previous, template []corev1.Taint) []corev1.Taint {
+	replaced := lo.Flatten([][]corev1.Taint{previous, template})
+	kept := lo.Reject(taints, func(taint corev1.Taint, _ int) bool {
+		return lo.ContainsBy(replaced, func(t corev1.Taint) bool {
+			return taint.MatchTaint(&t)
+		})
+	})
+	return append(kept, template...)
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.propagation"
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodePool{}, nodeclaimutils.NodePoolEventHandler(c.kubeClient, c.cloudProvider), builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 100,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dcoppa/karpenter/pkg/apis"
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
+	"github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/propagation"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
+	"github.com/dcoppa/karpenter/pkg/test"
+	. "github.com/dcoppa/karpenter/pkg/test/expectations"
+	"github.com/dcoppa/karpenter/pkg/test/v1alpha1"
+	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
+	. "github.com/dcoppa/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var propagationController *propagation.Controller
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Propagation")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+
+	cloudProvider = fake.NewCloudProvider()
+	propagationController = propagation.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Propagation", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	taint := corev1.Taint{Key: "dedicated", Value: "team-a", Effect: corev1.TaintEffectNoSchedule}
+	otherTaint := corev1.Taint{Key: "other", Effect: corev1.TaintEffectNoSchedule}
+
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					MetadataUpdatePolicy: v1.MetadataUpdatePolicyInPlace,
+				},
+				Template: v1.NodeClaimTemplate{
+					ObjectMeta: v1.ObjectMeta{
+						Labels: map[string]string{"team": "a", "tier": "gold"},
+					},
+					Spec: v1.NodeClaimTemplateSpec{
+						Taints: []corev1.Taint{taint},
+					},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+					"team":              "a",
+					"tier":              "gold",
+				},
+				Annotations: map[string]string{
+					v1.NodePoolHashAnnotationKey:            nodePool.Hash(),
+					v1.NodePoolHashVersionAnnotationKey:     v1.NodePoolHashVersion,
+					v1.NodePoolReplacementHashAnnotationKey: nodePool.ReplacementHash(),
+					v1.NodeClaimTemplateLabelsAnnotationKey: "team,tier",
+				},
+			},
+			Spec: v1.NodeClaimSpec{
+				Taints: []corev1.Taint{taint},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeRegistered)
+		node.Spec.Taints = []corev1.Taint{taint, otherTaint}
+	})
+	// updateNodePool changes the template of the NodePool and sets the annotations that the hash controller would set
+	updateNodePool := func(update func(*v1.NodePool)) {
+		update(nodePool)
+		nodePool.Annotations = map[string]string{
+			v1.NodePoolHashAnnotationKey:            nodePool.Hash(),
+			v1.NodePoolHashVersionAnnotationKey:     v1.NodePoolHashVersion,
+			v1.NodePoolReplacementHashAnnotationKey: nodePool.ReplacementHash(),
+		}
+	}
+
+	It("should update the labels of the Node and the NodeClaim in place", func() {
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Template.Labels = map[string]string{"team": "b", "zone-group": "east"}
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("team", "b"))
+		Expect(node.Labels).To(HaveKeyWithValue("zone-group", "east"))
+		Expect(node.Labels).ToNot(HaveKey("tier"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+		Expect(node.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue("team", "b"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue("zone-group", "east"))
+		Expect(nodeClaim.Labels).ToNot(HaveKey("tier"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		Expect(nodeclaimutils.TemplateLabelKeys(nodeClaim)).To(ConsistOf("team", "zone-group"))
+	})
+	It("should update the taints of the Node in place and keep the taints that weren't applied from the template", func() {
+		updatedTaint := corev1.Taint{Key: "dedicated", Value: "team-b", Effect: corev1.TaintEffectNoSchedule}
+		addedTaint := corev1.Taint{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule}
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Template.Spec.Taints = []corev1.Taint{updatedTaint, addedTaint}
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(otherTaint, updatedTaint, addedTaint))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		taints, err := nodeclaimutils.TemplateTaints(nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(taints).To(ConsistOf(updatedTaint, addedTaint))
+
+		// Removing a taint from the template removes it from the node, based on the taints recorded on the NodeClaim
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Template.Spec.Taints = []corev1.Taint{addedTaint}
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(otherTaint, addedTaint))
+	})
+	It("should not update the Node if the NodePool replaces nodes on metadata changes", func() {
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Disruption.MetadataUpdatePolicy = v1.MetadataUpdatePolicyReplace
+			np.Spec.Template.Labels = map[string]string{"team": "b"}
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("team", "a"))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+	})
+	It("should not update the Node if fields that can't be updated in place have changed", func() {
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Template.Labels = map[string]string{"team": "b"}
+			np.Spec.Template.Spec.ExpireAfter = v1.MustParseNillableDuration("100m")
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("team", "a"))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+	})
+	It("should not update the Node if the NodeClaim isn't registered", func() {
+		nodeClaim.StatusConditions().SetUnknown(v1.ConditionTypeRegistered)
+		updateNodePool(func(np *v1.NodePool) {
+			np.Spec.Template.Labels = map[string]string{"team": "b"}
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, propagationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("team", "a"))
+	})
+})
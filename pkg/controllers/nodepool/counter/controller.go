@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -81,6 +82,8 @@ func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reco
 	stored := nodePool.DeepCopy()
 	// Determine resource usage and update nodepool.status.resources
 	nodePool.Status.Resources = c.resourceCountsFor(v1.NodePoolLabelKey, nodePool.Name)
+	// Determine the progress of the drift rollout and update nodepool.status.drift
+	nodePool.Status.Drift = driftStatusFor(stored.Status.Drift, c.driftedCountFor(nodePool.Name))
 	if !equality.Semantic.DeepEqual(stored, nodePool) {
 		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
 			return reconcile.Result{}, client.IgnoreNotFound(err)
@@ -111,6 +114,34 @@ func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string) core
 	return res
 }
 
+// driftedCountFor returns the number of nodes of the nodepool that are drifted, including the nodes that are being
+// replaced
+func (c *Controller) driftedCountFor(nodePoolName string) int64 {
+	var drifted int64
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.NodeClaim != nil && n.Labels()[v1.NodePoolLabelKey] == nodePoolName && n.NodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue() {
+			drifted++
+		}
+		return true
+	})
+	return drifted
+}
+
+// driftStatusFor returns the drift status given the previous status and the number of nodes that are drifted now. The
+// nodes that stopped being drifted since the previous status are counted as replaced, and nodes that drift while the
+// rollout is in progress are added to it.
+func driftStatusFor(previous *v1.DriftStatus, remaining int64) *v1.DriftStatus {
+	if previous == nil || previous.Remaining == 0 {
+		// Keep the status of the last rollout until the next one starts
+		if remaining == 0 {
+			return previous
+		}
+		return &v1.DriftStatus{Drifted: remaining, Remaining: remaining}
+	}
+	replaced := previous.Replaced + lo.Max([]int64{previous.Remaining - remaining, 0})
+	return &v1.DriftStatus{Drifted: replaced + remaining, Replaced: replaced, Remaining: remaining}
+}
+
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodepool.counter").
@@ -263,4 +263,51 @@ var _ = Describe("Counter", func() {
 		expected = counter.BaseResources.DeepCopy()
 		Expect(nodePool.Status.Resources).To(BeComparableTo(expected))
 	})
+	Context("Drift", func() {
+		It("should not set the drift status when no nodes are drifted", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Drift).To(BeNil())
+		})
+		It("should report the progress of the drift rollout", func() {
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+			nodeClaim2.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+			ExpectApplied(ctx, env.Client, node, nodeClaim, node2, nodeClaim2)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Drift).To(Equal(&v1.DriftStatus{Drifted: 2, Replaced: 0, Remaining: 2}))
+
+			// The first drifted node is replaced
+			ExpectDeleted(ctx, env.Client, node, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Drift).To(Equal(&v1.DriftStatus{Drifted: 2, Replaced: 1, Remaining: 1}))
+
+			// The last drifted node is replaced, and the status of the completed rollout is kept
+			ExpectDeleted(ctx, env.Client, node2, nodeClaim2)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node2))
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim2))
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Drift).To(Equal(&v1.DriftStatus{Drifted: 2, Replaced: 2, Remaining: 0}))
+		})
+		It("should start a new drift rollout once the previous one has completed", func() {
+			nodePool.Status.Drift = &v1.DriftStatus{Drifted: 5, Replaced: 5, Remaining: 0}
+			ExpectApplied(ctx, env.Client, nodePool)
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+			ExpectApplied(ctx, env.Client, node, nodeClaim)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Status.Drift).To(Equal(&v1.DriftStatus{Drifted: 1, Replaced: 0, Remaining: 1}))
+		})
+	})
 })
@@ -65,8 +65,9 @@ func (c *Controller) Reconcile(ctx context.Context, np *v1.NodePool) (reconcile.
 		}
 	}
 	np.Annotations = lo.Assign(np.Annotations, map[string]string{
-		v1.NodePoolHashAnnotationKey:        np.Hash(),
-		v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+		v1.NodePoolHashAnnotationKey:            np.Hash(),
+		v1.NodePoolHashVersionAnnotationKey:     v1.NodePoolHashVersion,
+		v1.NodePoolReplacementHashAnnotationKey: np.ReplacementHash(),
 	})
 
 	if !equality.Semantic.DeepEqual(stored, np) {
@@ -108,9 +109,19 @@ func (c *Controller) updateNodeClaimHash(ctx context.Context, np *v1.NodePool) e
 			// Since the hashing mechanism has changed we will not be able to determine if the drifted status of the NodeClaim has changed
 			if nc.StatusConditions().Get(v1.ConditionTypeDrifted) == nil {
 				nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-					v1.NodePoolHashAnnotationKey: np.Hash(),
+					v1.NodePoolHashAnnotationKey:            np.Hash(),
+					v1.NodePoolReplacementHashAnnotationKey: np.ReplacementHash(),
 				})
 			}
+			// NodeClaims that were launched before the keys of the template labels were recorded would keep the labels
+			// that are removed from the template, so the keys are backfilled with the template labels that the NodeClaim has
+			if _, ok := nc.Annotations[v1.NodeClaimTemplateLabelsAnnotationKey]; !ok {
+				if labels := lo.PickBy(np.Spec.Template.Labels, func(k, v string) bool { return nc.Labels[k] == v }); len(labels) > 0 {
+					nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
+						v1.NodeClaimTemplateLabelsAnnotationKey: nodeclaimutils.TemplateLabelKeysAnnotationValue(labels),
+					})
+				}
+			}
 
 			if !equality.Semantic.DeepEqual(stored, nc) {
 				if err := c.kubeClient.Patch(ctx, nc, client.MergeFrom(stored)); err != nil {
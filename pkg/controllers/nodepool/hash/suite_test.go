@@ -198,6 +198,40 @@ var _ = Describe("Static Drift Hash", func() {
 		Expect(nodeClaimTwo.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, expectedHash))
 		Expect(nodeClaimTwo.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, v1.NodePoolHashVersion))
 	})
+	It("should backfill the template label keys on nodeclaims when the hash versions don't match the controller hash version", func() {
+		nodePool.Annotations = map[string]string{
+			v1.NodePoolHashAnnotationKey:        "abceduefed",
+			v1.NodePoolHashVersionAnnotationKey: "test",
+		}
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name, "keyLabel": "valueLabel", "otherLabel": "otherValue"},
+				Annotations: map[string]string{
+					v1.NodePoolHashAnnotationKey:        "123456",
+					v1.NodePoolHashVersionAnnotationKey: "test",
+				},
+			},
+		})
+		labeled := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name, "keyLabel": "valueLabel"},
+				Annotations: map[string]string{
+					v1.NodePoolHashAnnotationKey:            "123456",
+					v1.NodePoolHashVersionAnnotationKey:     "test",
+					v1.NodeClaimTemplateLabelsAnnotationKey: "removedLabel",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, labeled)
+
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		labeled = ExpectExists(ctx, env.Client, labeled)
+
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTemplateLabelsAnnotationKey, "keyLabel"))
+		// The recorded keys aren't overwritten
+		Expect(labeled.Annotations).To(HaveKeyWithValue(v1.NodeClaimTemplateLabelsAnnotationKey, "removedLabel"))
+	})
 	It("should not update nodepool hash on all nodeclaims when the hash versions match the controller hash version", func() {
 		nodePool.Annotations = map[string]string{
 			v1.NodePoolHashAnnotationKey:        "abceduefed",
@@ -0,0 +1,154 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	"github.com/dcoppa/karpenter/pkg/metrics"
+	"github.com/dcoppa/karpenter/pkg/operator/injection"
+	nodepoolutils "github.com/dcoppa/karpenter/pkg/utils/nodepool"
+)
+
+// Controller keeps the number of NodeClaims of static NodePools at their replicas. It launches NodeClaims from the
+// template of the NodePool when NodeClaims are missing and removes the extra NodeClaims when the NodePool scales down.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+	}
+}
+
+// Reconcile a control loop for the resource
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.static")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) || !nodePool.IsStatic() || !nodePool.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	if !nodePool.StatusConditions().IsTrue(status.ConditionReady) {
+		return reconcile.Result{}, nil
+	}
+	// We need to ensure that our internal cluster state mechanism is synced before we proceed
+	// Otherwise, we may launch NodeClaims for a NodePool that already has its replicas on startup
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool)))
+
+	nodes := c.activeNodes(nodePool)
+	replicas := int(lo.FromPtr(nodePool.Spec.Replicas))
+	switch {
+	case len(nodes) < replicas:
+		if err := c.scaleUp(ctx, nodePool, replicas-len(nodes)); err != nil {
+			return reconcile.Result{}, err
+		}
+	case len(nodes) > replicas:
+		if err := c.scaleDown(ctx, nodes, len(nodes)-replicas); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	// NodeClaims can be lost without an event on the NodePool, so we periodically recheck the count
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// activeNodes returns the nodes of the NodePool that aren't leaving the cluster. Nodes that are being disrupted are
+// leaving, so the replacements that disruption launches for them aren't counted as extra replicas.
+func (c *Controller) activeNodes(nodePool *v1.NodePool) []*state.StateNode {
+	return lo.Filter(c.cluster.Nodes().Active(), func(n *state.StateNode, _ int) bool {
+		if !n.Managed() || n.Labels()[v1.NodePoolLabelKey] != nodePool.Name {
+			return false
+		}
+		return n.Node == nil || !lo.ContainsBy(n.Node.Spec.Taints, func(t corev1.Taint) bool {
+			return t.MatchTaint(&v1.DisruptedNoScheduleTaint)
+		})
+	})
+}
+
+func (c *Controller) scaleUp(ctx context.Context, nodePool *v1.NodePool, count int) error {
+	nodeClaims := make([]*scheduling.NodeClaim, count)
+	for i := range nodeClaims {
+		nodeClaim, err := c.provisioner.NewStaticNodeClaim(ctx, nodePool)
+		if err != nil {
+			return fmt.Errorf("creating nodeclaim for static nodepool, %w", err)
+		}
+		nodeClaims[i] = nodeClaim
+	}
+	log.FromContext(ctx).WithValues("count", count, "replicas", lo.FromPtr(nodePool.Spec.Replicas)).Info("scaling up static nodepool")
+	if _, err := c.provisioner.CreateNodeClaims(ctx, nodeClaims, provisioning.WithReason(metrics.StaticReason)); err != nil {
+		return fmt.Errorf("launching nodeclaims for static nodepool, %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) scaleDown(ctx context.Context, nodes []*state.StateNode, count int) error {
+	// We remove the nodes that haven't initialized first, then the newest nodes, so that we keep the nodes that are
+	// already serving pods the longest
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Initialized() != nodes[j].Initialized() {
+			return !nodes[i].Initialized()
+		}
+		return nodes[i].NodeClaim.CreationTimestamp.After(nodes[j].NodeClaim.CreationTimestamp.Time)
+	})
+	log.FromContext(ctx).WithValues("count", count).Info("scaling down static nodepool")
+	var errs error
+	for _, n := range nodes[:count] {
+		if err := c.kubeClient.Delete(ctx, n.NodeClaim); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting nodeclaim, %w", err))
+		}
+	}
+	return errs
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.static").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package static_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/dcoppa/karpenter/pkg/apis"
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
+	"github.com/dcoppa/karpenter/pkg/controllers/nodepool/static"
+	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	"github.com/dcoppa/karpenter/pkg/controllers/state/informer"
+	"github.com/dcoppa/karpenter/pkg/events"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
+	"github.com/dcoppa/karpenter/pkg/test"
+	. "github.com/dcoppa/karpenter/pkg/test/expectations"
+	"github.com/dcoppa/karpenter/pkg/test/v1alpha1"
+	. "github.com/dcoppa/karpenter/pkg/utils/testing"
+)
+
+var staticController *static.Controller
+var nodeClaimController *informer.NodeClaimController
+var nodeController *informer.NodeController
+var ctx context.Context
+var env *test.Environment
+var cluster *state.Cluster
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Static")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeClaimController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeController = informer.NewNodeController(env.Client, cluster)
+	prov := provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, cloudprovider.NewUnavailableOfferings())
+	staticController = static.NewController(env.Client, cloudProvider, cluster, prov)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+	cloudProvider.Reset()
+})
+
+var nodePool *v1.NodePool
+
+var _ = Describe("Static", func() {
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Replicas: lo.ToPtr(int64(3))}})
+		ExpectApplied(ctx, env.Client, nodePool)
+	})
+	It("should launch NodeClaims up to the replicas of the NodePool", func() {
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(3))
+		for _, nodeClaim := range nodeClaims {
+			Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+			Expect(nodeClaim.OwnerReferences).To(ContainElement(HaveField("Name", nodePool.Name)))
+		}
+	})
+	It("should not launch NodeClaims again once the NodePool has its replicas", func() {
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+	})
+	It("should not launch NodeClaims for NodePools that aren't static", func() {
+		nodePool.Spec.Replicas = nil
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+	})
+	It("should replace NodeClaims that are lost", func() {
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(3))
+		// Cluster state waits for the NodeClaims to launch before it's synced
+		for _, nodeClaim := range nodeClaims {
+			nodeClaim.Status.ProviderID = test.RandomProviderID()
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		}
+
+		ExpectDeleted(ctx, env.Client, nodeClaims[0])
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaims[0]))
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		nodeClaims = ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(3))
+	})
+	It("should remove the NodeClaims that haven't initialized first when scaling down", func() {
+		nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+		ExpectApplied(ctx, env.Client, nodePool)
+		initialized, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+		})
+		uninitialized := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+		})
+		ExpectApplied(ctx, env.Client, initialized, node, uninitialized)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{initialized})
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(uninitialized))
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		ExpectExists(ctx, env.Client, initialized)
+		ExpectNotFound(ctx, env.Client, uninitialized)
+	})
+	It("should not count NodeClaims that are being disrupted towards the replicas", func() {
+		nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+		ExpectApplied(ctx, env.Client, nodePool)
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+		})
+		node.Spec.Taints = append(node.Spec.Taints, v1.DisruptedNoScheduleTaint)
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectObjectReconciled(ctx, env.Client, staticController, nodePool)
+
+		ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+})
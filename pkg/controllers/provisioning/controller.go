@@ -112,3 +112,39 @@ func (c *NodeController) Register(_ context.Context, m manager.Manager) error {
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
+
+// NodePoolController triggers the provisioner for NodePools with headroom or capacity floors
+type NodePoolController struct {
+	kubeClient  client.Client
+	provisioner *Provisioner
+}
+
+// NewNodePoolController constructs a controller instance
+func NewNodePoolController(kubeClient client.Client, provisioner *Provisioner) *NodePoolController {
+	return &NodePoolController{
+		kubeClient:  kubeClient,
+		provisioner: provisioner,
+	}
+}
+
+// Reconcile the resource
+func (c *NodePoolController) Reconcile(ctx context.Context, np *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "provisioner.trigger.nodepool") //nolint:ineffassign,staticcheck
+
+	if (np.Spec.Headroom == nil && len(np.Spec.CapacityFloors) == 0) || !np.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	c.provisioner.Trigger()
+	// Continue to requeue while the NodePool has headroom or capacity floors. Pods that schedule to the nodes of the
+	// NodePool take up the headroom without triggering the provisioner, and capacity floors become active on a
+	// schedule, so we periodically provision to restore them.
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+func (c *NodePoolController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("provisioner.trigger.nodepool").
+		For(&v1.NodePool{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	scheduler "github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/metrics"
+	"github.com/dcoppa/karpenter/pkg/operator/injection"
+)
+
+// recordUnschedulableReasons breaks down the unschedulable pods of the scheduling simulation by the NodePool that
+// rejected them and the reason
+func recordUnschedulableReasons(ctx context.Context, results scheduler.Results) {
+	controllerName := injection.GetControllerName(ctx)
+	counts := map[scheduler.Explanation]int{}
+	for _, err := range results.PodErrors {
+		for _, explanation := range scheduler.Explain(err) {
+			// The key is omitted to bound the cardinality of the metric
+			counts[scheduler.Explanation{NodePool: explanation.NodePool, Reason: explanation.Reason}]++
+		}
+	}
+	scheduler.UnschedulablePodsByReasonCount.DeletePartialMatch(map[string]string{scheduler.ControllerLabel: controllerName})
+	for explanation, count := range counts {
+		scheduler.UnschedulablePodsByReasonCount.Set(float64(count), map[string]string{
+			scheduler.ControllerLabel: controllerName,
+			metrics.NodePoolLabel:     explanation.NodePool,
+			metrics.ReasonLabel:       string(explanation.Reason),
+		})
+	}
+}
+
+// explainPendingPods sets the Provisionable condition of the pending pods, explaining why each NodePool rejected the
+// pods that couldn't schedule. Pods that were previously explained have their condition set to True once they can
+// schedule, while pods that always scheduled aren't patched to avoid writes for every pod that Karpenter provisions.
+func (p *Provisioner) explainPendingPods(ctx context.Context, results scheduler.Results, pendingPods []*corev1.Pod) {
+	workqueue.ParallelizeUntil(ctx, 20, len(pendingPods), func(i int) {
+		pod := pendingPods[i]
+		condition := corev1.PodCondition{
+			Type:   v1.PodConditionTypeProvisionable,
+			Status: corev1.ConditionTrue,
+			Reason: "Provisionable",
+		}
+		if err, ok := results.PodErrors[pod]; ok {
+			explanations := scheduler.Explain(err)
+			condition.Status = corev1.ConditionFalse
+			condition.Reason = explanations.Reason()
+			condition.Message = explanations.String()
+		}
+		if err := p.patchProvisionableCondition(ctx, pod, condition); err != nil {
+			log.FromContext(ctx).WithValues("Pod", klog.KRef(pod.Namespace, pod.Name)).Error(err, "failed updating provisionable condition")
+		}
+	})
+}
+
+func (p *Provisioner) patchProvisionableCondition(ctx context.Context, pod *corev1.Pod, condition corev1.PodCondition) error {
+	current, existing := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool { return c.Type == v1.PodConditionTypeProvisionable })
+	if !existing && condition.Status == corev1.ConditionTrue {
+		return nil
+	}
+	if existing && current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		return nil
+	}
+	stored := pod.DeepCopy()
+	updated := pod.DeepCopy()
+	condition.LastTransitionTime = metav1.NewTime(p.clock.Now())
+	if existing && current.Status == condition.Status {
+		condition.LastTransitionTime = current.LastTransitionTime
+	}
+	updated.Status.Conditions = append(lo.Reject(updated.Status.Conditions, func(c corev1.PodCondition, _ int) bool {
+		return c.Type == v1.PodConditionTypeProvisionable
+	}), condition)
+	// We use a strategic merge patch so that we don't overwrite the conditions of the pod that are owned by other components
+	return client.IgnoreNotFound(p.kubeClient.Status().Patch(ctx, updated, client.StrategicMergeFrom(stored)))
+}
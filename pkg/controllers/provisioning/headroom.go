@@ -0,0 +1,153 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/dcoppa/karpenter/pkg/apis"
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/controllers/state"
+	nodepoolutils "github.com/dcoppa/karpenter/pkg/utils/nodepool"
+	"github.com/dcoppa/karpenter/pkg/utils/resources"
+)
+
+// headroomPodAnnotationKey is set on the virtual pods that keep room for the headroom or the capacity floor of a
+// NodePool, to the name of the NodePool. The virtual pods only exist in scheduling simulations.
+const headroomPodAnnotationKey = apis.Group + "/headroom"
+
+// capacityFloorPodUnits are the largest amounts of each resource that a virtual pod of a capacity floor requests, so
+// that the virtual pods are small enough to schedule to any node. Other resources are split into units of one.
+var capacityFloorPodUnits = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("1"),
+	corev1.ResourceMemory: resource.MustParse("1Gi"),
+}
+
+// IsHeadroomPod returns true if the pod is a virtual pod that keeps room for the headroom or the capacity floor of a
+// NodePool
+func IsHeadroomPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[headroomPodAnnotationKey]
+	return ok
+}
+
+// HeadroomPodNodePool returns the name of the NodePool that the virtual pod keeps room for, or an empty string if the
+// pod isn't a virtual pod
+func HeadroomPodNodePool(pod *corev1.Pod) string {
+	return pod.Annotations[headroomPodAnnotationKey]
+}
+
+// GetHeadroomPods returns the virtual pods that keep room for the headroom and the active capacity floors of the
+// NodePools. Scheduling the virtual pods alongside the pending pods reserves room for them on the existing nodes, or
+// launches nodes for them when the existing nodes don't have enough room. The virtual pods have the lowest possible
+// priority, so they never take room from or preempt real pods.
+func (p *Provisioner) GetHeadroomPods(ctx context.Context, nodes state.StateNodes) ([]*corev1.Pod, error) {
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	var pods []*corev1.Pod
+	for _, nodePool := range nodePools {
+		if (nodePool.Spec.Headroom == nil && len(nodePool.Spec.CapacityFloors) == 0) || nodePool.IsStatic() || !nodePool.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// The usage of the NodePool excludes daemonsets, since they are launched with every node
+		usage := resources.Merge(lo.FilterMap(nodes, func(n *state.StateNode, _ int) (corev1.ResourceList, bool) {
+			return resources.Subtract(n.PodRequests(), n.DaemonSetRequests()), n.Labels()[v1.NodePoolLabelKey] == nodePool.Name
+		})...)
+		if nodePool.Spec.Headroom != nil {
+			count, err := nodePool.Spec.Headroom.GetPods(usage)
+			if err != nil {
+				log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool)).Error(err, "failed computing headroom")
+			}
+			for i := range count {
+				pods = append(pods, headroomPod(nodePool, fmt.Sprintf("%s-headroom-%d", nodePool.Name, i), nodePool.Spec.Headroom.Resources))
+			}
+		}
+		floor, err := nodePool.GetCapacityFloor(p.clock)
+		if err != nil {
+			log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool)).Error(err, "failed computing capacity floor")
+			continue
+		}
+		pods = append(pods, capacityFloorPods(nodePool, floor, usage)...)
+	}
+	return pods, nil
+}
+
+// capacityFloorPods splits the resources that the usage of the NodePool is short of its capacity floor into virtual
+// pods of the same shape
+func capacityFloorPods(nodePool *v1.NodePool, floor, usage corev1.ResourceList) []*corev1.Pod {
+	shortfall := corev1.ResourceList{}
+	count := 0
+	for name, quantity := range floor {
+		used := usage[name]
+		if quantity.Cmp(used) <= 0 {
+			continue
+		}
+		quantity = quantity.DeepCopy()
+		quantity.Sub(used)
+		shortfall[name] = quantity
+		unit, ok := capacityFloorPodUnits[name]
+		if !ok {
+			unit = resource.MustParse("1")
+		}
+		count = lo.Max([]int{count, int(math.Ceil(quantity.AsApproximateFloat64() / unit.AsApproximateFloat64()))})
+	}
+	requests := lo.MapValues(shortfall, func(quantity resource.Quantity, _ corev1.ResourceName) resource.Quantity {
+		return *resource.NewMilliQuantity(int64(math.Ceil(float64(quantity.MilliValue())/float64(count))), quantity.Format)
+	})
+	return lo.Times(count, func(i int) *corev1.Pod {
+		return headroomPod(nodePool, fmt.Sprintf("%s-capacity-floor-%d", nodePool.Name, i), requests)
+	})
+}
+
+func headroomPod(nodePool *v1.NodePool, name string, requests corev1.ResourceList) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			UID:         types.UID(fmt.Sprintf("%s-%s", nodePool.UID, name)),
+			Annotations: map[string]string{headroomPodAnnotationKey: nodePool.Name},
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			// The headroom is kept for any pod that can schedule to the NodePool, so the virtual pods tolerate its taints
+			Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Priority:    lo.ToPtr(int32(math.MinInt32)),
+			Containers: []corev1.Container{{
+				Name:      "headroom",
+				Resources: corev1.ResourceRequirements{Requests: requests},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: corev1.ConditionFalse,
+				Reason: corev1.PodReasonUnschedulable,
+			}},
+		},
+	}
+}
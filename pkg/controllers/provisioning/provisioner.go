@@ -80,21 +80,24 @@ type Provisioner struct {
 	recorder       events.Recorder
 	cm             *pretty.ChangeMonitor
 	clock          clock.Clock
+
+	unavailableOfferings *cloudprovider.UnavailableOfferings
 }
 
 func NewProvisioner(kubeClient client.Client, recorder events.Recorder,
 	cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster,
-	clock clock.Clock,
+	clock clock.Clock, unavailableOfferings *cloudprovider.UnavailableOfferings,
 ) *Provisioner {
 	p := &Provisioner{
-		batcher:        NewBatcher(),
-		cloudProvider:  cloudProvider,
-		kubeClient:     kubeClient,
-		volumeTopology: scheduler.NewVolumeTopology(kubeClient),
-		cluster:        cluster,
-		recorder:       recorder,
-		cm:             pretty.NewChangeMonitor(),
-		clock:          clock,
+		batcher:              NewBatcher(),
+		cloudProvider:        cloudProvider,
+		kubeClient:           kubeClient,
+		volumeTopology:       scheduler.NewVolumeTopology(kubeClient),
+		cluster:              cluster,
+		recorder:             recorder,
+		cm:                   pretty.NewChangeMonitor(),
+		clock:                clock,
+		unavailableOfferings: unavailableOfferings,
 	}
 	return p
 }
@@ -246,6 +249,8 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 			continue
 		}
 
+		// Offerings that recently failed to launch due to insufficient capacity are masked so that we don't select them again
+		its = p.unavailableOfferings.Apply(its)
 		instanceTypes[np.Name] = its
 
 		// Construct Topology Domains
@@ -328,6 +333,12 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 		return scheduler.Results{}, err
 	}
 	pods := append(pendingPods, deletingNodePods...)
+	// Get the virtual pods that keep room for the headroom of the NodePools
+	headroomPods, err := p.GetHeadroomPods(ctx, nodes.Active())
+	if err != nil {
+		return scheduler.Results{}, err
+	}
+	pods = append(pods, headroomPods...)
 	// nothing to schedule, so just return success
 	if len(pods) == 0 {
 		return scheduler.Results{}, nil
@@ -342,17 +353,31 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	}
 	// ACK the pending pods at the start of the scheduling loop so that we can emit metrics on when we actually first try to schedule it.
 	p.cluster.AckPods(pendingPods...)
-	results := s.Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	results := s.Solve(ctx, pods, scheduler.AllowPreemption).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	// The virtual pods only reserve room, so they aren't reported, nominated or bound
+	results = results.OmitPods(headroomPods...)
 	scheduler.UnschedulablePodsCount.Set(float64(len(results.PodErrors)), map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})
+	recordUnschedulableReasons(ctx, results)
 	if len(results.NewNodeClaims) > 0 {
 		log.FromContext(ctx).WithValues("Pods", pretty.Slice(lo.Map(pods, func(p *corev1.Pod, _ int) string { return klog.KRef(p.Namespace, p.Name).String() }), 5), "duration", time.Since(start)).Info("found provisionable pod(s)")
 	}
 	// Mark in memory when these pods were marked as schedulable or when we made a decision on the pods
 	p.cluster.MarkPodSchedulingDecisions(results.PodErrors, pendingPods...)
 	results.Record(ctx, p.recorder, p.cluster)
+	p.explainPendingPods(ctx, results, pendingPods)
 	return results, nil
 }
 
+// NewStaticNodeClaim returns a NodeClaim that can be launched from the template of a static NodePool
+func (p *Provisioner) NewStaticNodeClaim(ctx context.Context, nodePool *v1.NodePool) (*scheduler.NodeClaim, error) {
+	its, err := p.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance types, %w", err)
+	}
+	// Offerings that recently failed to launch due to insufficient capacity are masked so that we don't select them again
+	return scheduler.NewStaticNodeClaim(nodePool, p.unavailableOfferings.Apply(its))
+}
+
 func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...option.Function[LaunchOptions]) (string, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", klog.KRef("", n.NodePoolName)))
 	options := option.Resolve(opts...)
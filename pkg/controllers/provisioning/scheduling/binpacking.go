@@ -0,0 +1,113 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+)
+
+// PackingPolicy decides how the scheduler packs pods onto the in-flight NodeClaims of a NodePool. Pods are always
+// tried against existing nodes first, and a new NodeClaim is only created when no in-flight NodeClaim accepts the pod.
+type PackingPolicy interface {
+	// Name returns the name of the strategy as it is configured on the NodePool
+	Name() v1.BinPackingStrategy
+	// CanAdd returns an error if the strategy doesn't allow the pod to be added to the in-flight NodeClaim
+	CanAdd(nodeClaim *NodeClaim, pod *corev1.Pod) error
+	// Less returns true if the in-flight NodeClaim a should be tried before b
+	Less(a, b *NodeClaim) bool
+}
+
+// NewPackingPolicy returns the packing policy for the bin-packing strategy that is configured on a NodePool
+func NewPackingPolicy(binPacking v1.BinPacking) PackingPolicy {
+	switch binPacking.Strategy {
+	case v1.BinPackingStrategySpread:
+		return SpreadBinPacking{MaxPods: int(lo.FromPtr(binPacking.MaxPodsPerNodeClaim))}
+	case v1.BinPackingStrategyBinpackDense:
+		return DenseBinPacking{}
+	default:
+		return DefaultBinPacking{}
+	}
+}
+
+// DefaultBinPacking tries the in-flight NodeClaims with the fewest pods first
+type DefaultBinPacking struct{}
+
+func (DefaultBinPacking) Name() v1.BinPackingStrategy { return v1.BinPackingStrategyDefault }
+
+func (DefaultBinPacking) CanAdd(*NodeClaim, *corev1.Pod) error { return nil }
+
+func (DefaultBinPacking) Less(a, b *NodeClaim) bool { return len(a.Pods) < len(b.Pods) }
+
+// SpreadBinPacking packs at most MaxPods pods onto each NodeClaim, trying the in-flight NodeClaims with the fewest pods
+// first. This trades a higher number of nodes for fewer pods that are impacted when a node fails or is disrupted.
+type SpreadBinPacking struct {
+	MaxPods int
+}
+
+func (SpreadBinPacking) Name() v1.BinPackingStrategy { return v1.BinPackingStrategySpread }
+
+func (s SpreadBinPacking) CanAdd(nodeClaim *NodeClaim, _ *corev1.Pod) error {
+	if s.MaxPods > 0 && len(nodeClaim.Pods) >= s.MaxPods {
+		return fmt.Errorf("nodeclaim already has %d pods, the maximum of the %s bin-packing strategy", len(nodeClaim.Pods), v1.BinPackingStrategySpread)
+	}
+	return nil
+}
+
+func (SpreadBinPacking) Less(a, b *NodeClaim) bool { return len(a.Pods) < len(b.Pods) }
+
+// DenseBinPacking tries the in-flight NodeClaims with the least remaining room first, so that pods fill up the
+// NodeClaims that are the closest to being full before spilling over to the emptier ones
+type DenseBinPacking struct{}
+
+func (DenseBinPacking) Name() v1.BinPackingStrategy { return v1.BinPackingStrategyBinpackDense }
+
+func (DenseBinPacking) CanAdd(*NodeClaim, *corev1.Pod) error { return nil }
+
+func (DenseBinPacking) Less(a, b *NodeClaim) bool { return remainingRoom(a) < remainingRoom(b) }
+
+// remainingRoom returns the fraction of the cpu and memory of the largest instance type option of the NodeClaim that
+// isn't requested yet, averaged over both resources
+func remainingRoom(nodeClaim *NodeClaim) float64 {
+	var room float64
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		allocatable := lo.Max(lo.Map(nodeClaim.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) float64 {
+			return lo.ToPtr(it.Allocatable()[resourceName]).AsApproximateFloat64()
+		}))
+		if allocatable <= 0 {
+			continue
+		}
+		requested := lo.ToPtr(nodeClaim.Spec.Resources.Requests[resourceName]).AsApproximateFloat64()
+		room += (allocatable - requested) / allocatable / 2
+	}
+	return room
+}
+
+// lessNodeClaim orders the in-flight NodeClaims by the bin-packing strategy of their NodePool first, and then with the
+// packing policy of that strategy. Ordering by strategy first keeps the order transitive when the NodeClaims of
+// NodePools with different strategies are sorted together.
+func lessNodeClaim(a, b *NodeClaim) bool {
+	if a.PackingPolicy.Name() != b.PackingPolicy.Name() {
+		return a.PackingPolicy.Name() < b.PackingPolicy.Name()
+	}
+	return a.PackingPolicy.Less(a, b)
+}
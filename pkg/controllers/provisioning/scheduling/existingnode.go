@@ -19,12 +19,18 @@ package scheduling
 import (
 	"context"
 	"fmt"
+	"sort"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/dcoppa/karpenter/pkg/controllers/state"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	"github.com/dcoppa/karpenter/pkg/utils/pdb"
+	podutils "github.com/dcoppa/karpenter/pkg/utils/pod"
 	"github.com/dcoppa/karpenter/pkg/utils/resources"
 )
 
@@ -32,14 +38,20 @@ type ExistingNode struct {
 	*state.StateNode
 	cachedAvailable v1.ResourceList // Cache so we don't have to re-subtract resources on the StateNode every time
 	cachedTaints    []v1.Taint      // Cache so we don't hae to re-construct the taints each time we attempt to schedule a pod
+	devices         v1.ResourceList // Devices that are attached to the node, resolved from its instance type
+	deviceClaims    scheduling.DeviceClaims
 
-	Pods         []*v1.Pod
+	Pods []*v1.Pod
+	// Preemptions are the lower priority pods bound to the node that would be preempted by kube-scheduler to make room
+	// for the pods that we simulated scheduling to the node, keyed by the preempting pod
+	Preemptions  map[*v1.Pod][]*v1.Pod
+	preempted    sets.Set[types.UID]
 	topology     *Topology
 	requests     v1.ResourceList
 	requirements scheduling.Requirements
 }
 
-func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint, daemonResources v1.ResourceList) *ExistingNode {
+func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint, daemonResources, devices v1.ResourceList) *ExistingNode {
 	// The state node passed in here must be a deep copy from cluster state as we modify it
 	// the remaining daemonResources to schedule are the total daemonResources minus what has already scheduled
 	remainingDaemonResources := resources.Subtract(daemonResources, n.DaemonSetRequests())
@@ -56,16 +68,19 @@ func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint,
 		StateNode:       n,
 		cachedAvailable: n.Available(),
 		cachedTaints:    taints,
+		devices:         devices,
 		topology:        topology,
 		requests:        remainingDaemonResources,
 		requirements:    scheduling.NewLabelRequirements(n.Labels()),
+		Preemptions:     map[*v1.Pod][]*v1.Pod{},
+		preempted:       sets.New[types.UID](),
 	}
 	node.requirements.Add(scheduling.NewRequirement(v1.LabelHostname, v1.NodeSelectorOpIn, n.HostName()))
 	topology.Register(v1.LabelHostname, n.HostName())
 	return node
 }
 
-func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v1.Pod, podRequests v1.ResourceList) error {
+func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v1.Pod, podRequests v1.ResourceList, podDeviceClaims scheduling.DeviceClaims) error {
 	// Check Taints
 	if err := scheduling.Taints(n.cachedTaints).Tolerates(pod); err != nil {
 		return err
@@ -83,6 +98,11 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	if err = n.HostPortUsage().Conflicts(pod, hostPorts); err != nil {
 		return fmt.Errorf("checking host port usage, %w", err)
 	}
+	// determine the devices that will be allocated to the node's resource claims if the pod schedules
+	deviceClaims, err := n.deviceUsage(ctx, kubeClient, podDeviceClaims)
+	if err != nil {
+		return fmt.Errorf("checking device usage, %w", err)
+	}
 
 	// check resource requests first since that's a pretty likely reason the pod won't schedule on an in-flight
 	// node, which at this point can't be increased in size
@@ -108,7 +128,7 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	}
 
 	// Check Topology Requirements
-	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeRequirements, pod)
+	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeRequirements, pod, n.cachedTaints)
 	if err != nil {
 		return err
 	}
@@ -121,8 +141,146 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	n.Pods = append(n.Pods, pod)
 	n.requests = requests
 	n.requirements = nodeRequirements
-	n.topology.Record(pod, nodeRequirements)
+	n.topology.Record(pod, n.cachedTaints, nodeRequirements)
 	n.HostPortUsage().Add(pod, hostPorts)
 	n.VolumeUsage().Add(pod, volumes)
+	n.deviceClaims = deviceClaims
 	return nil
 }
+
+// deviceUsage returns the device claims of the node if the pod's device claims were added to it, or an error if the
+// node doesn't have enough devices for them. The device claims of the pods that are already bound to the node are only
+// resolved the first time that we try to add a pod that requests devices.
+func (n *ExistingNode) deviceUsage(ctx context.Context, kubeClient client.Client, podDeviceClaims scheduling.DeviceClaims) (scheduling.DeviceClaims, error) {
+	if len(podDeviceClaims) == 0 {
+		return n.deviceClaims, nil
+	}
+	if n.deviceClaims == nil {
+		pods, err := n.StateNode.Pods(ctx, kubeClient)
+		if err != nil {
+			return nil, fmt.Errorf("listing pods on node, %w", err)
+		}
+		n.deviceClaims = scheduling.DeviceClaims{}
+		for _, p := range lo.Filter(pods, func(p *v1.Pod, _ int) bool { return podutils.IsActive(p) }) {
+			claims, err := scheduling.GetDeviceClaims(ctx, kubeClient, p)
+			if err != nil {
+				n.deviceClaims = nil
+				return nil, err
+			}
+			n.deviceClaims = n.deviceClaims.Union(claims)
+		}
+	}
+	deviceClaims := n.deviceClaims.Union(podDeviceClaims)
+	if err := deviceClaims.ExceedsCapacity(n.devices); err != nil {
+		return nil, err
+	}
+	return deviceClaims, nil
+}
+
+// Preempt simulates scheduling the pod to the node by preempting lower priority pods that are bound to the node. As in
+// kube-scheduler, all lower priority pods are considered as victims and then as many of them as possible are reprieved,
+// starting from the highest priority, so that we only preempt the pods that are needed to make room for the pod. Pods
+// that their pod disruption budgets don't allow to evict aren't considered as victims, and the victims use the
+// disruptions of their pod disruption budgets so that the preemptions of a scheduling simulation don't disrupt more
+// pods than the budgets allow.
+func (n *ExistingNode) Preempt(ctx context.Context, kubeClient client.Client, pdbs pdb.Limits, pod *v1.Pod, podRequests v1.ResourceList, podDeviceClaims scheduling.DeviceClaims) error {
+	pods, err := n.StateNode.Pods(ctx, kubeClient)
+	if err != nil {
+		return fmt.Errorf("listing pods on node, %w", err)
+	}
+	candidates := lo.Filter(pods, func(p *v1.Pod, _ int) bool {
+		if !podutils.IsActive(p) || podutils.IsOwnedByDaemonSet(p) || podutils.IsOwnedByNode(p) || n.preempted.Has(p.UID) || !podutils.CanPreempt(pod, p) {
+			return false
+		}
+		_, evictable := pdbs.CanEvictPods([]*v1.Pod{p})
+		return evictable
+	})
+	if len(candidates) == 0 {
+		return fmt.Errorf("no lower priority pods to preempt")
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return podutils.Priority(candidates[i]) > podutils.Priority(candidates[j])
+	})
+	requests := resources.Merge(n.requests, podRequests)
+	available := resources.Merge(n.cachedAvailable, resources.RequestsForPods(candidates...))
+	if !resources.Fits(requests, available) {
+		return fmt.Errorf("exceeds node resources after preempting lower priority pods")
+	}
+	volumes, err := scheduling.GetVolumes(ctx, kubeClient, pod)
+	if err != nil {
+		return err
+	}
+	hostPorts := scheduling.GetHostPorts(pod)
+	// Release the host ports and volumes of all the candidates, so that only the candidates that don't conflict with the
+	// pod are reprieved
+	hostPortUsage, volumeUsage := n.HostPortUsage().DeepCopy(), n.VolumeUsage().DeepCopy()
+	restore := func() {
+		*n.HostPortUsage() = *hostPortUsage
+		*n.VolumeUsage() = *volumeUsage
+	}
+	for _, candidate := range candidates {
+		n.HostPortUsage().DeletePod(client.ObjectKeyFromObject(candidate))
+		n.VolumeUsage().DeletePod(client.ObjectKeyFromObject(candidate))
+	}
+	var victims []*v1.Pod
+	for _, candidate := range candidates {
+		if reprieved := resources.Subtract(available, resources.RequestsForPods(candidate)); resources.Fits(requests, reprieved) {
+			ok, err := n.reprieve(ctx, kubeClient, candidate, pod, hostPorts, volumes)
+			if err != nil {
+				restore()
+				return err
+			}
+			if ok {
+				available = reprieved
+				continue
+			}
+		}
+		if pdbKey, ok := pdbs.Disrupt(candidate); !ok {
+			restore()
+			for _, victim := range victims {
+				pdbs.Release(victim)
+			}
+			return fmt.Errorf("preempting pod %s would violate pdb %s", client.ObjectKeyFromObject(candidate), pdbKey)
+		}
+		victims = append(victims, candidate)
+	}
+	// Release the topology counts of the victims
+	for _, victim := range victims {
+		n.topology.Unrecord(victim, n.cachedTaints, n.requirements)
+	}
+
+	// Check the remaining scheduling constraints with the resources that are freed by preempting the victims
+	cachedAvailable := n.cachedAvailable
+	n.cachedAvailable = available
+	if err = n.Add(ctx, kubeClient, pod, podRequests, podDeviceClaims); err != nil {
+		n.cachedAvailable = cachedAvailable
+		restore()
+		for _, victim := range victims {
+			n.topology.Record(victim, n.cachedTaints, n.requirements)
+			pdbs.Release(victim)
+		}
+		return err
+	}
+	n.Preemptions[pod] = victims
+	for _, victim := range victims {
+		n.preempted.Insert(victim.UID)
+	}
+	return nil
+}
+
+// reprieve adds back the host ports and volumes of a preemption candidate to the node, and returns true if they don't
+// conflict with the host ports and volumes of the preempting pod. Otherwise, they are released again.
+func (n *ExistingNode) reprieve(ctx context.Context, kubeClient client.Client, candidate, pod *v1.Pod, hostPorts []scheduling.HostPort, volumes scheduling.Volumes) (bool, error) {
+	candidateVolumes, err := scheduling.GetVolumes(ctx, kubeClient, candidate)
+	if err != nil {
+		return false, err
+	}
+	n.HostPortUsage().Add(candidate, scheduling.GetHostPorts(candidate))
+	n.VolumeUsage().Add(candidate, candidateVolumes)
+	if n.HostPortUsage().Conflicts(pod, hostPorts) != nil || n.VolumeUsage().ExceedsLimits(volumes) != nil {
+		n.HostPortUsage().DeletePod(client.ObjectKeyFromObject(candidate))
+		n.VolumeUsage().DeletePod(client.ObjectKeyFromObject(candidate))
+		return false, nil
+	}
+	return true, nil
+}
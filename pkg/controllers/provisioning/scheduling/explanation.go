@@ -0,0 +1,192 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+	"github.com/dcoppa/karpenter/pkg/scheduling"
+)
+
+// UnschedulableReason is the category of the reason that a NodePool couldn't launch capacity for a pod
+type UnschedulableReason string
+
+const (
+	// UnschedulableReasonTaint means that the pod doesn't tolerate a taint of the NodePool
+	UnschedulableReasonTaint UnschedulableReason = "Taint"
+	// UnschedulableReasonRequirement means that the requirements of the pod aren't compatible with the NodePool
+	UnschedulableReasonRequirement UnschedulableReason = "Requirement"
+	// UnschedulableReasonLimits means that all of the instance types of the NodePool would exceed its limits
+	UnschedulableReasonLimits UnschedulableReason = "Limits"
+	// UnschedulableReasonDaemonSetOverhead means that an instance type would fit the pod, but not together with the
+	// daemonsets that would schedule to the node
+	UnschedulableReasonDaemonSetOverhead UnschedulableReason = "DaemonSetOverhead"
+	// UnschedulableReasonResources means that no instance type of the NodePool has enough resources or an available
+	// offering for the pod
+	UnschedulableReasonResources UnschedulableReason = "Resources"
+	// UnschedulableReasonMinValues means that the instance types that would fit the pod don't satisfy the minValues
+	// requirements of the NodePool
+	UnschedulableReasonMinValues UnschedulableReason = "MinValues"
+	// UnschedulableReasonTopology means that the topology spread or pod (anti-)affinity constraints of the pod can't be
+	// satisfied by the NodePool
+	UnschedulableReasonTopology UnschedulableReason = "Topology"
+	// UnschedulableReasonVolume means that the zone of the volumes of the pod isn't compatible with the NodePool
+	UnschedulableReasonVolume UnschedulableReason = "Volume"
+	// UnschedulableReasonHostPort means that the host ports of the pod conflict with other pods on the node
+	UnschedulableReasonHostPort UnschedulableReason = "HostPort"
+	// UnschedulableReasonBinPacking means that the bin-packing strategy of the NodePool doesn't allow another pod on
+	// the in-flight NodeClaims
+	UnschedulableReasonBinPacking UnschedulableReason = "BinPacking"
+	// UnschedulableReasonTimeout means that the scheduling simulation timed out before it considered the pod
+	UnschedulableReasonTimeout UnschedulableReason = "Timeout"
+	// UnschedulableReasonUnknown means that the pod couldn't schedule for a reason that isn't categorized
+	UnschedulableReasonUnknown UnschedulableReason = "Unknown"
+)
+
+// UnschedulableError is returned when a pod can't schedule to a NodePool. It categorizes the reason so that the
+// failure can be explained to users and tools, while its message stays the same as the error that it wraps.
+type UnschedulableError struct {
+	error
+	NodePool string
+	Reason   UnschedulableReason
+	// Key is the taint key, requirement key or topology key that the pod couldn't schedule because of, if any
+	Key string
+}
+
+func (e *UnschedulableError) Unwrap() error {
+	return e.error
+}
+
+func newUnschedulableError(reason UnschedulableReason, key string, err error) *UnschedulableError {
+	return &UnschedulableError{error: err, Reason: reason, Key: key}
+}
+
+// forNodePool returns an UnschedulableError for the NodePool that wraps err, keeping the reason and key of any
+// UnschedulableError that err wraps
+func forNodePool(nodePoolName string, err error) *UnschedulableError {
+	ret := &UnschedulableError{error: err, NodePool: nodePoolName, Reason: UnschedulableReasonUnknown}
+	var unschedulableErr *UnschedulableError
+	if errors.As(err, &unschedulableErr) {
+		ret.Reason = unschedulableErr.Reason
+		ret.Key = unschedulableErr.Key
+	}
+	return ret
+}
+
+// Explanation describes why a NodePool couldn't launch capacity for a pod
+type Explanation struct {
+	NodePool string              `json:"nodePool,omitempty"`
+	Reason   UnschedulableReason `json:"reason"`
+	Key      string              `json:"key,omitempty"`
+}
+
+// Explanations is the list of reasons that a pod couldn't schedule, with an entry for each NodePool that rejected it
+type Explanations []Explanation
+
+// Explain returns the explanations for the error of a pod that couldn't schedule, ordered by NodePool
+func Explain(err error) Explanations {
+	if err == nil {
+		return nil
+	}
+	explanations := explain(err)
+	sort.SliceStable(explanations, func(i, j int) bool { return explanations[i].NodePool < explanations[j].NodePool })
+	return lo.Uniq(explanations)
+}
+
+func explain(err error) Explanations {
+	if err == ErrSolveTimeout {
+		return Explanations{{Reason: UnschedulableReasonTimeout}}
+	}
+	if unschedulableErr, ok := err.(*UnschedulableError); ok {
+		return Explanations{{NodePool: unschedulableErr.NodePool, Reason: unschedulableErr.Reason, Key: unschedulableErr.Key}}
+	}
+	// Errors that combine multiple errors, like the errors of each of the NodePools that rejected the pod
+	if errs, ok := err.(interface{ Unwrap() []error }); ok {
+		return lo.FlatMap(errs.Unwrap(), func(e error, _ int) []Explanation { return explain(e) })
+	}
+	if e := errors.Unwrap(err); e != nil {
+		return explain(e)
+	}
+	return Explanations{{Reason: UnschedulableReasonUnknown}}
+}
+
+// Reason returns the reason shared by all of the explanations, or Multiple if the NodePools rejected the pod for
+// different reasons
+func (e Explanations) Reason() string {
+	reasons := lo.Uniq(lo.Map(e, func(explanation Explanation, _ int) UnschedulableReason { return explanation.Reason }))
+	switch len(reasons) {
+	case 0:
+		return string(UnschedulableReasonUnknown)
+	case 1:
+		return string(reasons[0])
+	default:
+		return "Multiple"
+	}
+}
+
+// String returns the explanations as JSON so that they can be parsed by tools
+func (e Explanations) String() string {
+	return string(lo.Must(json.Marshal(lo.Ternary(e == nil, Explanations{}, e))))
+}
+
+// untoleratedTaintKey returns the key of the first taint that the pod doesn't tolerate
+func untoleratedTaintKey(taints []corev1.Taint, pod *corev1.Pod) string {
+	taint, _ := lo.Find(taints, func(t corev1.Taint) bool {
+		return scheduling.Taints([]corev1.Taint{t}).Tolerates(pod) != nil
+	})
+	return taint.Key
+}
+
+// incompatibleRequirementKey returns the first requirement key, in alphabetical order, of the incoming requirements that
+// isn't compatible with the existing requirements
+func incompatibleRequirementKey(existing, incoming scheduling.Requirements) string {
+	keys := incoming.Keys().UnsortedList()
+	sort.Strings(keys)
+	key, _ := lo.Find(keys, func(key string) bool {
+		return existing.Compatible(scheduling.NewRequirements(incoming.Get(key)), scheduling.AllowUndefinedWellKnownLabels) != nil
+	})
+	return key
+}
+
+// unsatisfiedRequirementKey returns the first requirement key, in alphabetical order, that none of the instance types
+// are compatible with
+func unsatisfiedRequirementKey(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements) string {
+	keys := requirements.Keys().UnsortedList()
+	sort.Strings(keys)
+	key, _ := lo.Find(keys, func(key string) bool {
+		requirement := scheduling.NewRequirements(requirements.Get(key))
+		return lo.NoneBy(instanceTypes, func(it *cloudprovider.InstanceType) bool { return compatible(it, requirement) })
+	})
+	return key
+}
+
+// requirementReason categorizes an incompatible requirement. Karpenter injects the zones of the persistent volumes of
+// a pod into its requirements, so an incompatible zone for a pod with persistent volumes is caused by its volumes.
+func requirementReason(key string, pod *corev1.Pod) UnschedulableReason {
+	if key == corev1.LabelTopologyZone && lo.ContainsBy(pod.Spec.Volumes, func(v corev1.Volume) bool {
+		return v.PersistentVolumeClaim != nil || v.Ephemeral != nil
+	}) {
+		return UnschedulableReasonVolume
+	}
+	return UnschedulableReasonRequirement
+}
@@ -18,6 +18,7 @@ package scheduling_test
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 
 	"github.com/mitchellh/hashstructure/v2"
@@ -32,6 +33,7 @@ import (
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning/scheduling"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
 	scheduler "github.com/dcoppa/karpenter/pkg/scheduling"
 	"github.com/dcoppa/karpenter/pkg/test"
 	. "github.com/dcoppa/karpenter/pkg/test/expectations"
@@ -608,8 +610,8 @@ var _ = Describe("Instance Type Selection", func() {
 					corev1.ResourceMemory: resource.MustParse("1Gi"),
 				},
 				Offerings: []cloudprovider.Offering{
-					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.0, Available: true},
-					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 0.2, Available: true},
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.0, Available: math.MaxInt},
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 0.2, Available: math.MaxInt},
 				},
 			}),
 			fake.NewInstanceType(fake.InstanceTypeOptions{
@@ -621,8 +623,8 @@ var _ = Describe("Instance Type Selection", func() {
 					corev1.ResourceMemory: resource.MustParse("1Gi"),
 				},
 				Offerings: []cloudprovider.Offering{
-					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.3, Available: true},
-					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 0.1, Available: true},
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.3, Available: math.MaxInt},
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 0.1, Available: math.MaxInt},
 				},
 			}),
 		}
@@ -642,6 +644,103 @@ var _ = Describe("Instance Type Selection", func() {
 		node := ExpectScheduled(ctx, env.Client, pod)
 		Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("test-instance1"))
 	})
+	Context("Capacity Reservations", func() {
+		var reservedInstanceType, onDemandInstanceType *cloudprovider.InstanceType
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{CapacityReservations: lo.ToPtr(true)}}))
+			reservedInstanceType = fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:             "reserved-instance",
+				Architecture:     v1.ArchitectureAmd64,
+				OperatingSystems: sets.New(string(corev1.Linux)),
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Offerings: []cloudprovider.Offering{
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeReserved, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 2.0, Available: 2},
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 2.0, Available: math.MaxInt},
+				},
+			})
+			onDemandInstanceType = fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:             "on-demand-instance",
+				Architecture:     v1.ArchitectureAmd64,
+				OperatingSystems: sets.New(string(corev1.Linux)),
+				Resources: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Offerings: []cloudprovider.Offering{
+					{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.0, Available: math.MaxInt},
+				},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{reservedInstanceType, onDemandInstanceType}
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+						Key:      v1.CapacityTypeLabelKey,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{v1.CapacityTypeReserved, v1.CapacityTypeOnDemand},
+					},
+				},
+			}
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should prioritize reserved offerings over cheaper offerings", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("reserved-instance"))
+			Expect(node.Labels[v1.CapacityTypeLabelKey]).To(Equal(v1.CapacityTypeReserved))
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(scheduler.NewNodeSelectorRequirementsWithMinValues(cloudProvider.CreateCalls[0].Spec.Requirements...).Get(v1.CapacityTypeLabelKey).Values()).To(ConsistOf(v1.CapacityTypeReserved))
+		})
+		It("should not launch more nodeclaims into a reserved offering than it has capacity for", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := lo.Times(3, func(_ int) *corev1.Pod {
+				return test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("0.8"),
+					}},
+				})
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			capacityTypes := lo.CountValues(lo.Map(pods, func(p *corev1.Pod, _ int) string {
+				return ExpectScheduled(ctx, env.Client, p).Labels[v1.CapacityTypeLabelKey]
+			}))
+			Expect(capacityTypes).To(Equal(map[string]int{v1.CapacityTypeReserved: 2, v1.CapacityTypeOnDemand: 1}))
+		})
+		It("should create a new nodeclaim rather than remove the reserved offerings from an in-flight nodeclaim", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			// The second pod can only schedule to the on-demand instance type, so it shouldn't be packed with the first pod.
+			// The first pod requests more resources so that it is scheduled first.
+			pod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("100m"),
+				}},
+			})
+			onDemandPod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "on-demand-instance"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod, onDemandPod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			onDemandNode := ExpectScheduled(ctx, env.Client, onDemandPod)
+			Expect(node.Name).ToNot(Equal(onDemandNode.Name))
+			Expect(node.Labels[v1.CapacityTypeLabelKey]).To(Equal(v1.CapacityTypeReserved))
+			Expect(onDemandNode.Labels[v1.CapacityTypeLabelKey]).To(Equal(v1.CapacityTypeOnDemand))
+		})
+		It("should not use reserved offerings when capacity reservations are disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{CapacityReservations: lo.ToPtr(false)}}))
+			reservedInstanceType.Offerings = lo.Filter(reservedInstanceType.Offerings, func(o cloudprovider.Offering, _ int) bool {
+				return o.CapacityType() == v1.CapacityTypeReserved
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("on-demand-instance"))
+		})
+	})
 	Context("MinValues", func() {
 		It("should schedule respecting the minValues from instance-type requirements", func() {
 			var instanceTypes []*cloudprovider.InstanceType
@@ -659,7 +758,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -675,7 +774,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceType(opts1))
@@ -737,7 +836,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -753,7 +852,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts3 := fake.InstanceTypeOptions{
@@ -769,7 +868,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 
@@ -833,7 +932,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -849,7 +948,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceTypeWithCustomRequirement(opts1, scheduler.NewRequirement(instanceGeneration, corev1.NodeSelectorOpIn, "2")))
@@ -922,7 +1021,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -938,7 +1037,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts3 := fake.InstanceTypeOptions{
@@ -954,7 +1053,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceTypeWithCustomRequirement(opts1, scheduler.NewRequirement(instanceGeneration, corev1.NodeSelectorOpIn, "2")))
@@ -1017,7 +1116,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1033,7 +1132,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceTypeWithCustomRequirement(opts1, scheduler.NewRequirement(instanceGeneration, corev1.NodeSelectorOpIn, "2")))
@@ -1086,7 +1185,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1102,7 +1201,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts3 := fake.InstanceTypeOptions{
@@ -1118,7 +1217,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceType(opts1))
@@ -1188,7 +1287,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1204,7 +1303,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts3 := fake.InstanceTypeOptions{
@@ -1220,7 +1319,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts4 := fake.InstanceTypeOptions{
@@ -1236,7 +1335,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.2,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceTypeWithCustomRequirement(opts1, scheduler.NewRequirement(instanceGeneration, corev1.NodeSelectorOpIn, "2")))
@@ -1334,7 +1433,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1350,7 +1449,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceType(opts1))
@@ -1409,7 +1508,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1425,7 +1524,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceType(opts1))
@@ -1494,7 +1593,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        0.52,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			opts2 := fake.InstanceTypeOptions{
@@ -1510,7 +1609,7 @@ var _ = Describe("Instance Type Selection", func() {
 				{
 					Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeSpot, corev1.LabelTopologyZone: "test-zone-1-spot"}),
 					Price:        1.0,
-					Available:    true,
+					Available:    math.MaxInt,
 				},
 			}
 			instanceTypes = append(instanceTypes, fake.NewInstanceType(opts1))
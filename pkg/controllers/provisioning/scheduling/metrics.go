@@ -79,6 +79,18 @@ var (
 		},
 		[]string{},
 	)
+	SolveTimeoutsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "solve_timeouts_total",
+			Help:      "Number of times a scheduling simulation has reached its timeout and returned partial results.",
+		},
+		[]string{
+			ControllerLabel,
+		},
+	)
 	UnschedulablePodsCount = opmetrics.NewPrometheusGauge(
 		crmetrics.Registry,
 		prometheus.GaugeOpts{
@@ -91,4 +103,18 @@ var (
 			ControllerLabel,
 		},
 	)
+	UnschedulablePodsByReasonCount = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "unschedulable_pods_by_reason_count",
+			Help:      "The number of unschedulable Pods, broken down by the NodePool that rejected them and the reason. Pods that were rejected by multiple NodePools are counted for each NodePool.",
+		},
+		[]string{
+			ControllerLabel,
+			metrics.NodePoolLabel,
+			metrics.ReasonLabel,
+		},
+	)
 )
@@ -17,6 +17,7 @@ limitations under the License.
 package scheduling
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -24,7 +25,9 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	karpv1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
 	"github.com/dcoppa/karpenter/pkg/utils/resources"
@@ -38,13 +41,18 @@ type NodeClaim struct {
 	Pods            []*v1.Pod
 	topology        *Topology
 	hostPortUsage   *scheduling.HostPortUsage
+	deviceClaims    scheduling.DeviceClaims
 	daemonResources v1.ResourceList
 	hostname        string
+
+	reservationManager *ReservationManager
+	reservedOfferings  sets.Set[string] // keys of the reserved offerings that this NodeClaim holds capacity for
 }
 
 var nodeID int64
 
-func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daemonResources v1.ResourceList, instanceTypes []*cloudprovider.InstanceType) *NodeClaim {
+func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daemonResources v1.ResourceList, instanceTypes []*cloudprovider.InstanceType,
+	reservationManager *ReservationManager) *NodeClaim {
 	// Copy the template, and add hostname
 	hostname := fmt.Sprintf("hostname-placeholder-%04d", atomic.AddInt64(&nodeID, 1))
 	topology.Register(v1.LabelHostname, hostname)
@@ -58,29 +66,57 @@ func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daem
 	return &NodeClaim{
 		NodeClaimTemplate: template,
 		hostPortUsage:     scheduling.NewHostPortUsage(),
+		deviceClaims:      scheduling.DeviceClaims{},
 		topology:          topology,
 		daemonResources:   daemonResources,
 		hostname:          hostname,
+
+		reservationManager: reservationManager,
+		reservedOfferings:  sets.New[string](),
+	}
+}
+
+// NewStaticNodeClaim returns a NodeClaim without pods for a static NodePool. Its instance type options are the instance
+// types that are compatible with the requirements of the NodePool and have an available offering.
+func NewStaticNodeClaim(nodePool *karpv1.NodePool, instanceTypes []*cloudprovider.InstanceType) (*NodeClaim, error) {
+	template := NewNodeClaimTemplate(nodePool)
+	template.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes, template.Requirements, v1.ResourceList{}, v1.ResourceList{}).remaining
+	if len(template.InstanceTypeOptions) == 0 {
+		return nil, fmt.Errorf("no instance type satisfied the requirements of nodepool %q", nodePool.Name)
+	}
+	n := &NodeClaim{
+		NodeClaimTemplate: *template,
+		hostPortUsage:     scheduling.NewHostPortUsage(),
+		deviceClaims:      scheduling.DeviceClaims{},
+		reservedOfferings: sets.New[string](),
 	}
+	n.FinalizeScheduling()
+	return n, nil
 }
 
-func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
+func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList, podDeviceClaims scheduling.DeviceClaims) error {
+	// Check that the bin-packing strategy of the NodePool allows another pod
+	if err := n.PackingPolicy.CanAdd(n, pod); err != nil {
+		return newUnschedulableError(UnschedulableReasonBinPacking, "", err)
+	}
+
 	// Check Taints
 	if err := scheduling.Taints(n.Spec.Taints).Tolerates(pod); err != nil {
-		return err
+		return newUnschedulableError(UnschedulableReasonTaint, untoleratedTaintKey(n.Spec.Taints, pod), err)
 	}
 
 	// exposed host ports on the node
 	hostPorts := scheduling.GetHostPorts(pod)
 	if err := n.hostPortUsage.Conflicts(pod, hostPorts); err != nil {
-		return fmt.Errorf("checking host port usage, %w", err)
+		return newUnschedulableError(UnschedulableReasonHostPort, "", fmt.Errorf("checking host port usage, %w", err))
 	}
 	nodeClaimRequirements := scheduling.NewRequirements(n.Requirements.Values()...)
 	podRequirements := scheduling.NewPodRequirements(pod)
 
 	// Check NodeClaim Affinity Requirements
 	if err := nodeClaimRequirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
-		return fmt.Errorf("incompatible requirements, %w", err)
+		key := incompatibleRequirementKey(nodeClaimRequirements, podRequirements)
+		return newUnschedulableError(requirementReason(key, pod), key, fmt.Errorf("incompatible requirements, %w", err))
 	}
 	nodeClaimRequirements.Add(podRequirements.Values()...)
 
@@ -91,38 +127,105 @@ func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
 		strictPodRequirements = scheduling.NewStrictPodRequirements(pod)
 	}
 	// Check Topology Requirements
-	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeClaimRequirements, pod, scheduling.AllowUndefinedWellKnownLabels)
+	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeClaimRequirements, pod, n.Spec.Taints, scheduling.AllowUndefinedWellKnownLabels)
 	if err != nil {
-		return err
+		var topologyErr topologyError
+		return newUnschedulableError(UnschedulableReasonTopology, lo.Ternary(errors.As(err, &topologyErr), topologyErr.topology.Key, ""), err)
 	}
 	if err = nodeClaimRequirements.Compatible(topologyRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
-		return err
+		return newUnschedulableError(UnschedulableReasonTopology, incompatibleRequirementKey(nodeClaimRequirements, topologyRequirements), err)
 	}
 	nodeClaimRequirements.Add(topologyRequirements.Values()...)
 
 	// Check instance type combinations
 	requests := resources.Merge(n.Spec.Resources.Requests, podRequests)
+	deviceClaims := n.deviceClaims.Union(podDeviceClaims)
 
-	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests)
+	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests, deviceClaims.Devices())
 
 	if len(filtered.remaining) == 0 {
 		// log the total resources being requested (daemonset + the pod)
 		cumulativeResources := resources.Merge(n.daemonResources, podRequests)
-		return fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(cumulativeResources), nodeClaimRequirements, filtered.FailureReason())
+		reason, key := n.instanceTypeFailureReason(pod, filtered, nodeClaimRequirements, requests, deviceClaims.Devices())
+		if len(podDeviceClaims) > 0 {
+			return newUnschedulableError(reason, key, fmt.Errorf("no instance type satisfied resources %s, devices %s and requirements %s (%s)", resources.String(cumulativeResources), resources.String(deviceClaims.Devices()), nodeClaimRequirements, filtered.FailureReason()))
+		}
+		return newUnschedulableError(reason, key, fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(cumulativeResources), nodeClaimRequirements, filtered.FailureReason()))
+	}
+	remaining, reservedOfferings, err := n.reserveOfferings(filtered.remaining, nodeClaimRequirements)
+	if err != nil {
+		return newUnschedulableError(UnschedulableReasonResources, "", err)
 	}
 
 	// Update node
 	n.Pods = append(n.Pods, pod)
-	n.InstanceTypeOptions = filtered.remaining
+	n.InstanceTypeOptions = remaining
+	n.reservedOfferings = reservedOfferings
 	n.Spec.Resources.Requests = requests
 	n.Requirements = nodeClaimRequirements
-	n.topology.Record(pod, nodeClaimRequirements, scheduling.AllowUndefinedWellKnownLabels)
+	n.topology.Record(pod, n.Spec.Taints, nodeClaimRequirements, scheduling.AllowUndefinedWellKnownLabels)
 	n.hostPortUsage.Add(pod, hostPorts)
+	n.deviceClaims = deviceClaims
 	return nil
 }
 
+// instanceTypeFailureReason categorizes why no instance type could launch the pod. If an instance type would have fit
+// the pod without the daemonsets that schedule to the node, the daemonset overhead is the reason.
+func (n *NodeClaim) instanceTypeFailureReason(pod *v1.Pod, filtered filterResults, requirements scheduling.Requirements, requests, devices v1.ResourceList) (UnschedulableReason, string) {
+	if filtered.minValuesIncompatibleErr != nil {
+		return UnschedulableReasonMinValues, ""
+	}
+	if !filtered.requirementsMet {
+		key := unsatisfiedRequirementKey(n.InstanceTypeOptions, requirements)
+		return requirementReason(key, pod), key
+	}
+	if !filtered.fits && len(n.daemonResources) > 0 &&
+		len(filterInstanceTypesByRequirements(n.InstanceTypeOptions, requirements, resources.Subtract(requests, n.daemonResources), devices).remaining) > 0 {
+		return UnschedulableReasonDaemonSetOverhead, ""
+	}
+	return UnschedulableReasonResources, ""
+}
+
+// reserveOfferings determines the instance types that remain launchable after taking the capacity of reserved offerings
+// into account. Reserved offerings that were exhausted by other NodeClaims in the simulation are treated as unavailable,
+// and capacity is reserved for every compatible reserved offering until the NodeClaim is finalized. If the NodeClaim
+// currently holds reserved capacity, we refuse to add a pod that would remove all of its reserved offerings. Since
+// reserved capacity is already paid for, it's better to launch a new NodeClaim for the pod than to give it up.
+func (n *NodeClaim) reserveOfferings(instanceTypes cloudprovider.InstanceTypes, requirements scheduling.Requirements) (cloudprovider.InstanceTypes, sets.Set[string], error) {
+	reserved := sets.New[string]()
+	var remaining cloudprovider.InstanceTypes
+	for _, it := range instanceTypes {
+		hasOffering := false
+		for _, o := range it.Offerings.Available().Compatible(requirements) {
+			if o.CapacityType() != karpv1.CapacityTypeReserved {
+				hasOffering = true
+				continue
+			}
+			key := reservationKey(it, o)
+			if n.reservedOfferings.Has(key) || n.reservationManager.Reserve(key) {
+				reserved.Insert(key)
+				hasOffering = true
+			}
+		}
+		if hasOffering {
+			remaining = append(remaining, it)
+		}
+	}
+	if len(remaining) == 0 || (n.reservedOfferings.Len() > 0 && reserved.Len() == 0) {
+		n.reservationManager.Release(reserved.Difference(n.reservedOfferings).UnsortedList()...)
+		if len(remaining) == 0 {
+			return nil, nil, fmt.Errorf("no instance type satisfied requirements %s (all compatible reserved offerings are exhausted)", requirements)
+		}
+		return nil, nil, fmt.Errorf("scheduling pod would remove all reserved offerings from nodeclaim")
+	}
+	n.reservationManager.Release(n.reservedOfferings.Difference(reserved).UnsortedList()...)
+	return remaining, reserved, nil
+}
+
 func (n *NodeClaim) Destroy() {
 	n.topology.Unregister(v1.LabelHostname, n.hostname)
+	n.reservationManager.Release(n.reservedOfferings.UnsortedList()...)
+	n.reservedOfferings.Clear()
 }
 
 // FinalizeScheduling is called once all scheduling has completed and allows the node to perform any cleanup
@@ -131,6 +234,31 @@ func (n *NodeClaim) FinalizeScheduling() {
 	// We need nodes to have hostnames for topology purposes, but we don't want to pass that node name on to consumers
 	// of the node as it will be displayed in error messages
 	delete(n.Requirements, v1.LabelHostname)
+	// If the NodeClaim holds reserved capacity, we constrain it to only launch into the reserved offerings that it
+	// holds so that we don't launch the NodeClaim into a different capacity type than the one we simulated. Otherwise,
+	// we ensure that it doesn't launch into reserved capacity that it didn't account for.
+	if n.reservedOfferings.Len() > 0 {
+		requirements := scheduling.NewRequirements(n.Requirements.Values()...)
+		requirements.Add(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, karpv1.CapacityTypeReserved))
+		instanceTypes := lo.Filter(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+			return lo.ContainsBy(it.Offerings.Available().Compatible(requirements), func(o cloudprovider.Offering) bool {
+				return n.reservedOfferings.Has(reservationKey(it, o))
+			})
+		})
+		// We can only constrain the NodeClaim to reserved capacity if doing so doesn't violate minValues
+		if _, err := instanceTypes.SatisfiesMinValues(requirements); err == nil {
+			n.Requirements = requirements
+			n.InstanceTypeOptions = instanceTypes
+			return
+		}
+		n.reservationManager.Release(n.reservedOfferings.UnsortedList()...)
+		n.reservedOfferings.Clear()
+	}
+	if lo.ContainsBy(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType) bool {
+		return it.Offerings.Available().Compatible(n.Requirements).HasCompatible(cloudprovider.ReservedRequirement)
+	}) {
+		n.Requirements.Add(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, v1.NodeSelectorOpNotIn, karpv1.CapacityTypeReserved))
+	}
 }
 
 func (n *NodeClaim) RemoveInstanceTypeOptionsByPriceAndMinValues(reqs scheduling.Requirements, maxPrice float64) (*NodeClaim, error) {
@@ -174,6 +302,7 @@ type filterResults struct {
 	fitsAndOffering          bool
 	minValuesIncompatibleErr error
 	requests                 v1.ResourceList
+	devices                  v1.ResourceList
 }
 
 // FailureReason returns a presentable string explaining why all instance types were filtered out
@@ -221,6 +350,9 @@ func (r filterResults) FailureReason() string {
 		if r.requests.Cpu().Cmp(resource.MustParse("1M")) >= 0 {
 			msg += " (CPU request >= 1 Million, m vs M typo?)"
 		}
+		if len(r.devices) > 0 {
+			msg += " and devices"
+		}
 		return msg
 	}
 
@@ -245,9 +377,10 @@ func (r filterResults) FailureReason() string {
 }
 
 //nolint:gocyclo
-func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests v1.ResourceList) filterResults {
+func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests, devices v1.ResourceList) filterResults {
 	results := filterResults{
 		requests:        requests,
+		devices:         devices,
 		requirementsMet: false,
 		fits:            false,
 		hasOffering:     false,
@@ -261,7 +394,7 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 		// the tradeoff to not short circuiting on the filtering is that we can report much better error messages
 		// about why scheduling failed
 		itCompat := compatible(it, requirements)
-		itFits := fits(it, requests)
+		itFits := fits(it, requests, devices)
 		itHasOffering := it.Offerings.Available().HasCompatible(requirements)
 
 		// track if any single instance type met a single criteria
@@ -296,6 +429,6 @@ func compatible(instanceType *cloudprovider.InstanceType, requirements schedulin
 	return instanceType.Requirements.Intersects(requirements) == nil
 }
 
-func fits(instanceType *cloudprovider.InstanceType, requests v1.ResourceList) bool {
-	return resources.Fits(requests, instanceType.Allocatable())
+func fits(instanceType *cloudprovider.InstanceType, requests, devices v1.ResourceList) bool {
+	return resources.Fits(requests, instanceType.Allocatable()) && resources.Fits(devices, instanceType.Devices)
 }
@@ -28,6 +28,7 @@ import (
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	nodeclaimutils "github.com/dcoppa/karpenter/pkg/utils/nodeclaim"
 )
 
 // MaxInstanceTypes is a constant that restricts the number of instance types to be sent for launch. Note that this
@@ -44,19 +45,27 @@ type NodeClaimTemplate struct {
 	NodePoolUUID        types.UID
 	InstanceTypeOptions cloudprovider.InstanceTypes
 	Requirements        scheduling.Requirements
+	PackingPolicy       PackingPolicy
 }
 
 func NewNodeClaimTemplate(nodePool *v1.NodePool) *NodeClaimTemplate {
 	nct := &NodeClaimTemplate{
-		NodeClaim:    *nodePool.Spec.Template.ToNodeClaim(),
-		NodePoolName: nodePool.Name,
-		NodePoolUUID: nodePool.UID,
-		Requirements: scheduling.NewRequirements(),
+		NodeClaim:     *nodePool.Spec.Template.ToNodeClaim(),
+		NodePoolName:  nodePool.Name,
+		NodePoolUUID:  nodePool.UID,
+		Requirements:  scheduling.NewRequirements(),
+		PackingPolicy: NewPackingPolicy(nodePool.Spec.BinPacking),
 	}
 	nct.Annotations = lo.Assign(nct.Annotations, map[string]string{
-		v1.NodePoolHashAnnotationKey:        nodePool.Hash(),
-		v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+		v1.NodePoolHashAnnotationKey:            nodePool.Hash(),
+		v1.NodePoolHashVersionAnnotationKey:     v1.NodePoolHashVersion,
+		v1.NodePoolReplacementHashAnnotationKey: nodePool.ReplacementHash(),
 	})
+	// Record the keys of the template labels so that they can be removed from the node if they're removed from the
+	// template and the NodePool updates metadata in place
+	if len(nodePool.Spec.Template.Labels) > 0 {
+		nct.Annotations[v1.NodeClaimTemplateLabelsAnnotationKey] = nodeclaimutils.TemplateLabelKeysAnnotationValue(nodePool.Spec.Template.Labels)
+	}
 	nct.Labels = lo.Assign(nct.Labels, map[string]string{
 		v1.NodePoolLabelKey: nodePool.Name,
 		v1.NodeClassLabelKey(nodePool.Spec.Template.Spec.NodeClassRef.GroupKind()): nodePool.Spec.Template.Spec.NodeClassRef.Name,
@@ -22,6 +22,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/dcoppa/karpenter/pkg/utils/pod"
 	"github.com/dcoppa/karpenter/pkg/utils/resources"
 )
 
@@ -33,9 +34,10 @@ type Queue struct {
 	lastLen map[types.UID]int
 }
 
-// NewQueue constructs a new queue given the input pods, sorting them to optimize for bin-packing into nodes.
+// NewQueue constructs a new queue given the input pods, sorting them by priority so that higher priority pods get the
+// first chance at capacity, and then to optimize for bin-packing into nodes.
 func NewQueue(pods []*v1.Pod, podRequests map[types.UID]v1.ResourceList) *Queue {
-	sort.Slice(pods, byCPUAndMemoryDescending(pods, podRequests))
+	sort.Slice(pods, byPriorityThenCPUAndMemoryDescending(pods, podRequests))
 	return &Queue{
 		pods:    pods,
 		lastLen: map[types.UID]int{},
@@ -73,11 +75,17 @@ func (q *Queue) List() []*v1.Pod {
 	return q.pods
 }
 
-func byCPUAndMemoryDescending(pods []*v1.Pod, podRequests map[types.UID]v1.ResourceList) func(i int, j int) bool {
+func byPriorityThenCPUAndMemoryDescending(pods []*v1.Pod, podRequests map[types.UID]v1.ResourceList) func(i int, j int) bool {
 	return func(i, j int) bool {
 		lhsPod := pods[i]
 		rhsPod := pods[j]
 
+		// Pods with a higher priority are scheduled first, so that they aren't starved of capacity by lower priority
+		// pods when the capacity that we can launch is limited
+		if lhsPriority, rhsPriority := pod.Priority(lhsPod), pod.Priority(rhsPod); lhsPriority != rhsPriority {
+			return lhsPriority > rhsPriority
+		}
+
 		lhs := podRequests[lhsPod.UID]
 		rhs := podRequests[rhsPod.UID]
 
@@ -0,0 +1,72 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	"github.com/dcoppa/karpenter/pkg/cloudprovider"
+)
+
+// ReservationManager tracks the remaining capacity of reserved offerings during a scheduling simulation. Unlike spot
+// and on-demand offerings, reserved offerings have a constrained capacity, so we need to ensure that we never simulate
+// more NodeClaims into a reserved offering than the offering has capacity for.
+type ReservationManager struct {
+	remaining map[string]int // offering key -> remaining capacity of the reserved offering
+}
+
+func NewReservationManager(instanceTypes map[string][]*cloudprovider.InstanceType, enabled bool) *ReservationManager {
+	rm := &ReservationManager{remaining: map[string]int{}}
+	// If capacity reservations aren't enabled, we don't track any capacity which means that no reserved offering
+	// can be reserved by a NodeClaim
+	if !enabled {
+		return rm
+	}
+	// The same instance types can be returned for multiple NodePools, so we only need to track each offering once
+	for _, its := range instanceTypes {
+		for _, it := range its {
+			for _, o := range it.Offerings.Available().Reserved() {
+				rm.remaining[reservationKey(it, o)] = o.Available
+			}
+		}
+	}
+	return rm
+}
+
+// Reserve attempts to reserve capacity from the reserved offering, returning true if the offering had remaining capacity
+func (rm *ReservationManager) Reserve(key string) bool {
+	if rm.remaining[key] <= 0 {
+		return false
+	}
+	rm.remaining[key]--
+	return true
+}
+
+// Release returns previously reserved capacity back to the reserved offerings
+func (rm *ReservationManager) Release(keys ...string) {
+	for _, key := range keys {
+		if _, ok := rm.remaining[key]; ok {
+			rm.remaining[key]++
+		}
+	}
+}
+
+// reservationKey returns a key which uniquely identifies a reserved offering of an instance type. Unlike
+// cloudprovider.OfferingKey, it distinguishes the reservations of an instance type in the same zone.
+func reservationKey(it *cloudprovider.InstanceType, o cloudprovider.Offering) string {
+	return fmt.Sprintf("%s/%s", it.Name, o.Requirements.String())
+}
@@ -19,14 +19,17 @@ package scheduling
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
 
+	"github.com/awslabs/operatorpkg/option"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -39,8 +42,11 @@ import (
 	"github.com/dcoppa/karpenter/pkg/events"
 	"github.com/dcoppa/karpenter/pkg/metrics"
 	"github.com/dcoppa/karpenter/pkg/operator/injection"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	"github.com/dcoppa/karpenter/pkg/utils/pdb"
 	"github.com/dcoppa/karpenter/pkg/utils/pod"
+	"github.com/dcoppa/karpenter/pkg/utils/pretty"
 	"github.com/dcoppa/karpenter/pkg/utils/resources"
 )
 
@@ -61,8 +67,13 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 	}
 	// Pre-filter instance types eligible for NodePools to reduce work done during scheduling loops for pods
 	templates := lo.FilterMap(nodePools, func(np *v1.NodePool, _ int) (*NodeClaimTemplate, bool) {
+		// Static NodePools keep a fixed number of NodeClaims, so we never launch NodeClaims for pods from them. Pods can
+		// still schedule to the existing nodes of a static NodePool.
+		if np.IsStatic() {
+			return nil, false
+		}
 		nct := NewNodeClaimTemplate(np)
-		nct.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes[np.Name], nct.Requirements, corev1.ResourceList{}).remaining
+		nct.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes[np.Name], nct.Requirements, corev1.ResourceList{}, corev1.ResourceList{}).remaining
 		if len(nct.InstanceTypeOptions) == 0 {
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Info("skipping, nodepool requirements filtered out all instance types")
 			return nil, false
@@ -77,14 +88,16 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 		cluster:            cluster,
 		daemonOverhead:     getDaemonOverhead(templates, daemonSetPods),
 		cachedPodRequests:  map[types.UID]corev1.ResourceList{}, // cache pod requests to avoid having to continually recompute this total
+		cachedPodDevices:   map[types.UID]scheduling.DeviceClaims{},
 		recorder:           recorder,
 		preferences:        &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
 		remainingResources: lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, corev1.ResourceList) {
 			return np.Name, corev1.ResourceList(np.Spec.Limits)
 		}),
-		clock: clock,
+		reservationManager: NewReservationManager(instanceTypes, options.FromContext(ctx).FeatureGates.CapacityReservations),
+		clock:              clock,
 	}
-	s.calculateExistingNodeClaims(stateNodes, daemonSetPods)
+	s.calculateExistingNodeClaims(stateNodes, daemonSetPods, instanceTypes)
 	return s
 }
 
@@ -95,13 +108,16 @@ type Scheduler struct {
 	nodeClaimTemplates []*NodeClaimTemplate
 	remainingResources map[string]corev1.ResourceList // (NodePool name) -> remaining resources for that NodePool
 	daemonOverhead     map[*NodeClaimTemplate]corev1.ResourceList
-	cachedPodRequests  map[types.UID]corev1.ResourceList // (Pod Namespace/Name) -> calculated resource requests for the pod
+	cachedPodRequests  map[types.UID]corev1.ResourceList     // (Pod Namespace/Name) -> calculated resource requests for the pod
+	cachedPodDevices   map[types.UID]scheduling.DeviceClaims // (Pod UID) -> devices requested by the pod's resource claims
+	reservationManager *ReservationManager
 	preferences        *Preferences
 	topology           *Topology
 	cluster            *state.Cluster
 	recorder           events.Recorder
 	kubeClient         client.Client
 	clock              clock.Clock
+	pdbs               *pdb.Limits // The limits of the pod disruption budgets, listed when pods need to preempt
 }
 
 // Results contains the results of the scheduling operation
@@ -124,6 +140,11 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 		if len(existing.Pods) > 0 {
 			cluster.NominateNodeForPod(ctx, existing.ProviderID())
 		}
+		for p, victims := range existing.Preemptions {
+			log.FromContext(ctx).WithValues("Pod", klog.KRef(p.Namespace, p.Name), "Node", klog.KRef("", existing.Name()), "victims", pretty.Slice(lo.Map(victims, func(v *corev1.Pod, _ int) string {
+				return klog.KRef(v.Namespace, v.Name).String()
+			}), 5)).Info("pod will preempt lower priority pods, NodePool limits have been reached")
+		}
 		for _, p := range existing.Pods {
 			recorder.Publish(NominatePodEvent(p, existing.Node, existing.NodeClaim))
 		}
@@ -150,6 +171,24 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 	log.FromContext(ctx).Info(fmt.Sprintf("computed %d unready node(s) will fit %d pod(s)", inflightCount, existingCount))
 }
 
+// OmitPods removes the pods from the results. NodeClaims that only had the removed pods are kept, so that the capacity
+// that was reserved for the pods is still launched.
+func (r Results) OmitPods(pods ...*corev1.Pod) Results {
+	if len(pods) == 0 {
+		return r
+	}
+	omitted := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) types.UID { return p.UID })...)
+	isOmitted := func(p *corev1.Pod, _ int) bool { return omitted.Has(p.UID) }
+	for _, nodeClaim := range r.NewNodeClaims {
+		nodeClaim.Pods = lo.Reject(nodeClaim.Pods, isOmitted)
+	}
+	for _, node := range r.ExistingNodes {
+		node.Pods = lo.Reject(node.Pods, isOmitted)
+	}
+	r.PodErrors = lo.OmitBy(r.PodErrors, func(p *corev1.Pod, _ error) bool { return omitted.Has(p.UID) })
+	return r
+}
+
 // AllNonPendingPodsScheduled returns true if all pods scheduled.
 // We don't care if a pod was pending before consolidation and will still be pending after. It may be a pod that we can't
 // schedule at all and don't want it to block consolidation.
@@ -194,7 +233,11 @@ func (r Results) TruncateInstanceTypes(maxInstanceTypes int) Results {
 			// Check if the truncated InstanceTypeOptions in each NewNodeClaim from the results still satisfy the minimum requirements
 			// If number of InstanceTypes in the NodeClaim cannot satisfy the minimum requirements, add its Pods to error map with reason.
 			for _, pod := range newNodeClaim.Pods {
-				r.PodErrors[pod] = fmt.Errorf("pod didn’t schedule because NodePool %q couldn’t meet minValues requirements, %w", newNodeClaim.NodeClaimTemplate.NodePoolName, err)
+				r.PodErrors[pod] = &UnschedulableError{
+					error:    fmt.Errorf("pod didn’t schedule because NodePool %q couldn’t meet minValues requirements, %w", newNodeClaim.NodeClaimTemplate.NodePoolName, err),
+					NodePool: newNodeClaim.NodeClaimTemplate.NodePoolName,
+					Reason:   UnschedulableReasonMinValues,
+				}
 			}
 		} else {
 			validNewNodeClaims = append(validNewNodeClaims, newNodeClaim)
@@ -204,17 +247,55 @@ func (r Results) TruncateInstanceTypes(maxInstanceTypes int) Results {
 	return r
 }
 
-func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
+// ErrSolveTimeout is returned as the pod error for pods that weren't scheduled before the scheduling simulation timed out
+var ErrSolveTimeout = errors.New("scheduling simulation timed out")
+
+// SolveOptions are the set of options that can be used to configure a scheduling simulation
+type SolveOptions struct {
+	Timeout         time.Duration
+	AllowPreemption bool
+}
+
+// WithTimeout bounds the scheduling simulation by a timeout which is stricter than the configured solve timeout. A
+// timeout which is longer than the configured solve timeout has no effect.
+func WithTimeout(timeout time.Duration) func(*SolveOptions) {
+	return func(o *SolveOptions) { o.Timeout = timeout }
+}
+
+// AllowPreemption allows the scheduling simulation to preempt lower priority pods on existing nodes when pods can't be
+// scheduled to new NodeClaims because NodePools have reached their limits. This should only be used when simulating
+// the decisions of kube-scheduler for pending pods, and never when simulating disruption.
+func AllowPreemption(o *SolveOptions) {
+	o.AllowPreemption = true
+}
+
+func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod, opts ...option.Function[SolveOptions]) Results {
 	defer metrics.Measure(DurationSeconds, map[string]string{ControllerLabel: injection.GetControllerName(ctx)})()
+	o := option.Resolve(opts...)
+	timeout := options.FromContext(ctx).SolveTimeout
+	if o.Timeout > 0 && o.Timeout < timeout {
+		timeout = o.Timeout
+	}
 	// We loop trying to schedule unschedulable pods as long as we are making progress.  This solves a few
 	// issues including pods with affinity to another pod in the batch. We could topo-sort to solve this, but it wouldn't
 	// solve the problem of scheduling pods where a particular order is needed to prevent a max-skew violation. E.g. if we
 	// had 5xA pods and 5xB pods were they have a zonal topology spread, but A can only go in one zone and B in another.
 	// We need to schedule them alternating, A, B, A, B, .... and this solution also solves that as well.
-	errors := map[*corev1.Pod]error{}
+	podErrors := map[*corev1.Pod]error{}
 	// Reset the metric for the controller, so we don't keep old ids around
 	UnschedulablePodsCount.DeletePartialMatch(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
 	QueueDepth.DeletePartialMatch(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
+	// Resolve the devices that pods request through their resource claims, pods with resource claims that can't be
+	// resolved can't be scheduled until the resource claims are created
+	pods = lo.Filter(pods, func(p *corev1.Pod, _ int) bool {
+		deviceClaims, err := scheduling.GetDeviceClaims(ctx, s.kubeClient, p)
+		if err != nil {
+			podErrors[p] = fmt.Errorf("resolving resource claims, %w", err)
+			return false
+		}
+		s.cachedPodDevices[p.UID] = deviceClaims
+		return true
+	})
 	for _, p := range pods {
 		s.cachedPodRequests[p.UID] = resources.RequestsForPods(p)
 	}
@@ -231,6 +312,20 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 			log.FromContext(ctx).WithValues("pods-scheduled", batchSize-len(q.pods), "pods-remaining", len(q.pods), "duration", s.clock.Since(startTime).Truncate(time.Second), "scheduling-id", string(s.id)).Info("computing pod scheduling...")
 			lastLogTime = s.clock.Now()
 		}
+		// If we've run out of time, we return the NodeClaims that we've computed so far. The pods that we haven't been
+		// able to schedule yet are reported as timed out so that they are retried in a later scheduling loop.
+		if s.clock.Since(startTime) > timeout {
+			for _, p := range q.List() {
+				if err, ok := podErrors[p]; ok {
+					podErrors[p] = fmt.Errorf("%w, %w", ErrSolveTimeout, err)
+				} else {
+					podErrors[p] = ErrSolveTimeout
+				}
+			}
+			SolveTimeoutsTotal.Inc(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
+			log.FromContext(ctx).WithValues("pods-scheduled", batchSize-len(q.pods), "pods-remaining", len(q.pods), "timeout", timeout, "scheduling-id", string(s.id)).Info("scheduling simulation timed out, returning partial results")
+			break
+		}
 		// Try the next pod
 		pod, ok := q.Pop()
 		if !ok {
@@ -238,8 +333,8 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 		}
 
 		// Schedule to existing nodes or create a new node
-		if errors[pod] = s.add(ctx, pod); errors[pod] == nil {
-			delete(errors, pod)
+		if podErrors[pod] = s.add(ctx, pod, o.AllowPreemption); podErrors[pod] == nil {
+			delete(podErrors, pod)
 			continue
 		}
 
@@ -260,25 +355,25 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	return Results{
 		NewNodeClaims: s.newNodeClaims,
 		ExistingNodes: s.existingNodes,
-		PodErrors:     errors,
+		PodErrors:     podErrors,
 	}
 }
 
-func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
+func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod, allowPreemption bool) error {
 	// first try to schedule against an in-flight real node
 	for _, node := range s.existingNodes {
-		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID]); err == nil {
+		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID], s.cachedPodDevices[pod.UID]); err == nil {
 			log.FromContext(ctx).WithValues("Pod", klog.KObj(pod)).Info("Scheduled on existing node")
 			return nil
 		}
 	}
 
 	// Consider using https://pkg.go.dev/container/heap
-	sort.Slice(s.newNodeClaims, func(a, b int) bool { return len(s.newNodeClaims[a].Pods) < len(s.newNodeClaims[b].Pods) })
+	sort.Slice(s.newNodeClaims, func(a, b int) bool { return lessNodeClaim(s.newNodeClaims[a], s.newNodeClaims[b]) })
 
 	// Pick existing node that we are about to create
 	for _, nodeClaim := range s.newNodeClaims {
-		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err == nil {
+		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID], s.cachedPodDevices[pod.UID]); err == nil {
 			log.FromContext(ctx).WithValues("Pod", klog.KObj(pod)).Info("Scheduled on in-progress nodeClaim")
 			return nil
 		}
@@ -286,6 +381,7 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 
 	// Create new node
 	var errs error
+	limited := false
 	for _, nodeClaimTemplate := range s.nodeClaimTemplates {
 		instanceTypes := nodeClaimTemplate.InstanceTypeOptions
 		// if limits have been applied to the nodepool, ensure we filter instance types to avoid violating those limits
@@ -294,21 +390,28 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 			instanceTypes = filterByRemainingResources(ctx, nodeClaimTemplate.NodePoolName, instanceTypes, remaining)
 			if len(instanceTypes) == 0 {
 				log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodeClaimTemplate.NodePoolName)).Info("WARNING - All available instance types exceed limits for nodepool")
-				errs = multierr.Append(errs, fmt.Errorf("all available instance types exceed limits for nodepool: %q", nodeClaimTemplate.NodePoolName))
+				errs = multierr.Append(errs, &UnschedulableError{
+					error:    fmt.Errorf("all available instance types exceed limits for nodepool: %q", nodeClaimTemplate.NodePoolName),
+					NodePool: nodeClaimTemplate.NodePoolName,
+					Reason:   UnschedulableReasonLimits,
+				})
+				limited = true
 				continue
 			} else if len(nodeClaimTemplate.InstanceTypeOptions) != len(instanceTypes) {
 				log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodeClaimTemplate.NodePoolName)).Info(fmt.Sprintf("%d out of %d instance types were excluded because they would breach limits",
 					len(nodeClaimTemplate.InstanceTypeOptions)-len(instanceTypes), len(nodeClaimTemplate.InstanceTypeOptions)))
 			}
 		}
-		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes)
-		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err != nil {
+		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes, s.reservationManager)
+		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID], s.cachedPodDevices[pod.UID]); err != nil {
 			nodeClaim.Destroy() // Ensure we cleanup any changes that we made while mocking out a NodeClaim
+			// the pod may have been able to schedule to one of the instance types that were excluded by the limits
+			limited = limited || len(nodeClaimTemplate.InstanceTypeOptions) != len(instanceTypes)
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodeClaimTemplate.NodePoolName)).Info("NodeClaim rejected pod due to incompatibility", "Error", err)
-			errs = multierr.Append(errs, fmt.Errorf("incompatible with nodepool %q, daemonset overhead=%s, %w",
+			errs = multierr.Append(errs, forNodePool(nodeClaimTemplate.NodePoolName, fmt.Errorf("incompatible with nodepool %q, daemonset overhead=%s, %w",
 				nodeClaimTemplate.NodePoolName,
 				resources.String(s.daemonOverhead[nodeClaimTemplate]),
-				err))
+				err)))
 			continue
 		}
 		// we will launch this nodeClaim and need to track its maximum possible resource usage against our remaining resources
@@ -317,11 +420,39 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 		s.remainingResources[nodeClaimTemplate.NodePoolName] = subtractMax(s.remainingResources[nodeClaimTemplate.NodePoolName], nodeClaim.InstanceTypeOptions)
 		return nil
 	}
+	// If we can't launch capacity for the pod because of NodePool limits, kube-scheduler may still be able to schedule
+	// the pod by preempting lower priority pods on existing nodes
+	if allowPreemption && limited {
+		pdbs, err := s.podDisruptionBudgets(ctx)
+		if err != nil {
+			return multierr.Append(errs, fmt.Errorf("listing pod disruption budgets, %w", err))
+		}
+		for _, node := range s.existingNodes {
+			if err := node.Preempt(ctx, s.kubeClient, pdbs, pod, s.cachedPodRequests[pod.UID], s.cachedPodDevices[pod.UID]); err == nil {
+				log.FromContext(ctx).WithValues("Pod", klog.KObj(pod)).Info("Scheduled on existing node by preempting lower priority pods")
+				return nil
+			}
+		}
+	}
 	log.FromContext(ctx).WithValues("Pod", klog.KObj(pod)).Info("WARNING - Could not schedule pod on any existing or new nodeClaim")
 	return errs
 }
 
-func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod) {
+// podDisruptionBudgets returns the limits of the pod disruption budgets of the cluster. They are only listed once per
+// scheduling simulation, the first time that a pod needs to preempt lower priority pods, so that the victims of all the
+// preemptions of the simulation share the disruptions that the budgets allow.
+func (s *Scheduler) podDisruptionBudgets(ctx context.Context) (pdb.Limits, error) {
+	if s.pdbs == nil {
+		pdbs, err := pdb.NewLimits(ctx, s.clock, s.kubeClient)
+		if err != nil {
+			return pdb.Limits{}, err
+		}
+		s.pdbs = &pdbs
+	}
+	return *s.pdbs, nil
+}
+
+func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod, instanceTypes map[string][]*cloudprovider.InstanceType) {
 	// create our existing nodes
 	for _, node := range stateNodes {
 		// Calculate any daemonsets that should schedule to the inflight node
@@ -336,7 +467,14 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 			}
 			daemons = append(daemons, p)
 		}
-		s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...)))
+		// Nodes that we can't resolve an instance type for don't have any devices that we know of
+		var devices corev1.ResourceList
+		if it, ok := lo.Find(instanceTypes[node.Labels()[v1.NodePoolLabelKey]], func(it *cloudprovider.InstanceType) bool {
+			return it.Name == node.Labels()[corev1.LabelInstanceTypeStable]
+		}); ok {
+			devices = it.Devices
+		}
+		s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...), devices))
 
 		// We don't use the status field and instead recompute the remaining resources to ensure we have a consistent view
 		// of the cluster during scheduling.  Depending on how node creation falls out, this will also work for cases where
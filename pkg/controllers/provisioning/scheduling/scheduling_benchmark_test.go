@@ -47,6 +47,7 @@ import (
 	"github.com/dcoppa/karpenter/pkg/controllers/state"
 	"github.com/dcoppa/karpenter/pkg/events"
 	operatorlogging "github.com/dcoppa/karpenter/pkg/operator/logging"
+	"github.com/dcoppa/karpenter/pkg/operator/options"
 	"github.com/dcoppa/karpenter/pkg/test"
 )
 
@@ -140,6 +141,7 @@ func TestSchedulingProfile(t *testing.T) {
 func benchmarkScheduler(b *testing.B, instanceCount, podCount int) {
 	// disable logging
 	ctx = ctrl.IntoContext(context.Background(), operatorlogging.NopLogger)
+	ctx = options.ToContext(ctx, test.Options())
 	nodePoolWithMinValues := test.NodePool(v1.NodePool{
 		Spec: v1.NodePoolSpec{
 			Template: v1.NodeClaimTemplate{
@@ -19,6 +19,7 @@ package scheduling_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -33,13 +34,18 @@ import (
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	nodev1 "k8s.io/api/node/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	cloudproviderapi "k8s.io/cloud-provider/api"
 	"k8s.io/csi-translation-lib/plugins"
+	realclock "k8s.io/utils/clock"
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -92,7 +98,7 @@ var _ = BeforeSuite(func() {
 	nodeStateController = informer.NewNodeController(env.Client, cluster)
 	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
 	podStateController = informer.NewPodController(env.Client, cluster)
-	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock)
+	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, cloudprovider.NewUnavailableOfferings())
 })
 
 var _ = AfterSuite(func() {
@@ -113,6 +119,8 @@ var _ = AfterEach(func() {
 	scheduling.QueueDepth.Reset()
 	scheduling.DurationSeconds.Reset()
 	scheduling.UnschedulablePodsCount.Reset()
+	scheduling.UnschedulablePodsByReasonCount.Reset()
+	scheduling.SolveTimeoutsTotal.Reset()
 })
 
 var _ = Context("Scheduling", func() {
@@ -1755,7 +1763,7 @@ var _ = Context("Scheduling", func() {
 								corev1.LabelTopologyZone: "test-zone-1a",
 							}),
 							Price:     3.00,
-							Available: true,
+							Available: math.MaxInt,
 						},
 					},
 				}),
@@ -1772,7 +1780,7 @@ var _ = Context("Scheduling", func() {
 								corev1.LabelTopologyZone: "test-zone-1a",
 							}),
 							Price:     2.00,
-							Available: true,
+							Available: math.MaxInt,
 						},
 					},
 				}),
@@ -1789,7 +1797,7 @@ var _ = Context("Scheduling", func() {
 								corev1.LabelTopologyZone: "test-zone-1a",
 							}),
 							Price:     1.00,
-							Available: true,
+							Available: math.MaxInt,
 						},
 					},
 				}),
@@ -1813,6 +1821,102 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Static NodePools", func() {
+		It("should not launch NodeClaims for pending pods from static nodepools", func() {
+			nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		})
+		It("should launch NodeClaims for pending pods from the nodepools that aren't static", func() {
+			nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+			nodePool.Spec.Weight = lo.ToPtr(int32(100))
+			dynamic := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool, dynamic)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, dynamic.Name))
+		})
+		It("should schedule pods to the existing nodes of static nodepools", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods[0])
+			node := ExpectScheduled(ctx, env.Client, pods[0])
+
+			nodePool.Spec.Replicas = lo.ToPtr(int64(1))
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods[1])
+			Expect(ExpectScheduled(ctx, env.Client, pods[1]).Name).To(Equal(node.Name))
+		})
+	})
+	Describe("Bin-Packing Strategy", func() {
+		var pods []*corev1.Pod
+		BeforeEach(func() {
+			pods = test.UnschedulablePods(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}}, 6)
+		})
+		scheduledNodes := func(pods []*corev1.Pod) sets.Set[string] {
+			return sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })...)
+		}
+		It("should pack pods onto as few NodeClaims as possible with the default strategy", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(scheduledNodes(pods).Len()).To(BeNumerically("<", 3))
+		})
+		It("should pack at most maxPodsPerNodeClaim pods onto each NodeClaim with the spread strategy", func() {
+			nodePool.Spec.BinPacking = v1.BinPacking{Strategy: v1.BinPackingStrategySpread, MaxPodsPerNodeClaim: lo.ToPtr(int32(2))}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(scheduledNodes(pods).Len()).To(Equal(3))
+			for _, count := range lo.CountValues(lo.Map(pods, func(p *corev1.Pod, _ int) string { return ExpectScheduled(ctx, env.Client, p).Name })) {
+				Expect(count).To(Equal(2))
+			}
+		})
+		It("should not limit the pods that schedule to existing nodes with the spread strategy", func() {
+			nodePool.Spec.BinPacking = v1.BinPacking{Strategy: v1.BinPackingStrategySpread, MaxPodsPerNodeClaim: lo.ToPtr(int32(1))}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods = test.UnschedulablePods(test.PodOptions{}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods[0])
+			node := ExpectScheduled(ctx, env.Client, pods[0])
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods[1])
+			Expect(ExpectScheduled(ctx, env.Client, pods[1]).Name).To(Equal(node.Name))
+		})
+		It("should try the in-flight NodeClaims with the least remaining room first with the binpack-dense strategy", func() {
+			instanceType := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:      "instance-type",
+				Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+			})
+			newNodeClaim := func(numPods int, cpu, memory string) *scheduling.NodeClaim {
+				nodeClaim := &scheduling.NodeClaim{
+					NodeClaimTemplate: scheduling.NodeClaimTemplate{InstanceTypeOptions: []*cloudprovider.InstanceType{instanceType}},
+					Pods:              test.Pods(numPods, test.PodOptions{}),
+				}
+				nodeClaim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+				return nodeClaim
+			}
+			// the full NodeClaim has more pods, but less remaining room than the empty NodeClaim
+			full := newNodeClaim(3, "3", "3Gi")
+			empty := newNodeClaim(1, "1", "1Gi")
+			Expect(scheduling.DenseBinPacking{}.Less(full, empty)).To(BeTrue())
+			Expect(scheduling.DenseBinPacking{}.Less(empty, full)).To(BeFalse())
+			Expect(scheduling.DefaultBinPacking{}.Less(full, empty)).To(BeFalse())
+		})
+		It("should return an unschedulable error when the bin-packing strategy doesn't allow another pod", func() {
+			nodeClaim := &scheduling.NodeClaim{
+				NodeClaimTemplate: scheduling.NodeClaimTemplate{PackingPolicy: scheduling.SpreadBinPacking{MaxPods: 1}},
+				Pods:              test.Pods(1, test.PodOptions{}),
+			}
+			err := nodeClaim.Add(test.Pod(), nil, nil)
+			var unschedulableErr *scheduling.UnschedulableError
+			Expect(errors.As(err, &unschedulableErr)).To(BeTrue())
+			Expect(unschedulableErr.Reason).To(Equal(scheduling.UnschedulableReasonBinPacking))
+		})
+	})
+
 	Describe("In-Flight Nodes", func() {
 		It("should not launch a second node if there is an in-flight node that can support the pod", func() {
 			opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
@@ -3641,6 +3745,424 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Dynamic Resource Allocation", func() {
+		var oneGPU, twoGPUs, threeGPUs *resourcev1alpha3.ResourceClaimTemplate
+		BeforeEach(func() {
+			oneGPU = test.ResourceClaimTemplate(test.ResourceClaimOptions{Devices: corev1.ResourceList{fake.DeviceClassGPU: resource.MustParse("1")}})
+			twoGPUs = test.ResourceClaimTemplate(test.ResourceClaimOptions{Devices: corev1.ResourceList{fake.DeviceClassGPU: resource.MustParse("2")}})
+			threeGPUs = test.ResourceClaimTemplate(test.ResourceClaimOptions{Devices: corev1.ResourceList{fake.DeviceClassGPU: resource.MustParse("3")}})
+			ExpectApplied(ctx, env.Client, nodePool, oneGPU, twoGPUs, threeGPUs)
+		})
+		AfterEach(func() {
+			ExpectDeleted(ctx, env.Client, oneGPU, twoGPUs, threeGPUs)
+		})
+		It("should schedule a pod to an instance type with the requested devices", func() {
+			pod := test.UnschedulablePod(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr(oneGPU.Name)}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "gpu-device-instance-type"))
+		})
+		It("should not schedule a pod that requests more devices than any instance type has", func() {
+			pod := test.UnschedulablePod(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr(threeGPUs.Name)}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not schedule a pod when its resource claim template doesn't exist", func() {
+			pod := test.UnschedulablePod(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr("does-not-exist")}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should pack pods onto nodes based on the devices that they request", func() {
+			pods := test.UnschedulablePods(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr(oneGPU.Name)}}}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeNames := sets.New[string]()
+			for _, p := range pods {
+				nodeNames.Insert(ExpectScheduled(ctx, env.Client, p).Name)
+			}
+			// every node has two devices, so three pods need two nodes
+			Expect(nodeNames).To(HaveLen(2))
+		})
+		It("should only count the devices of a resource claim that is shared by pods once", func() {
+			claim := test.ResourceClaim(test.ResourceClaimOptions{Devices: corev1.ResourceList{fake.DeviceClassGPU: resource.MustParse("2")}})
+			ExpectApplied(ctx, env.Client, claim)
+			pods := test.UnschedulablePods(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimName: lo.ToPtr(claim.Name)}}}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectScheduled(ctx, env.Client, pods[0]).Name).To(Equal(ExpectScheduled(ctx, env.Client, pods[1]).Name))
+			ExpectDeleted(ctx, env.Client, claim)
+		})
+		It("should not schedule a pod to an existing node whose devices are allocated", func() {
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: "gpu-device-instance-type",
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10"),
+						corev1.ResourceMemory: resource.MustParse("10Gi"),
+						corev1.ResourcePods:   resource.MustParse("110"),
+					},
+				},
+			})
+			existing := test.Pod(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr(twoGPUs.Name)}}})
+			ExpectApplied(ctx, env.Client, nodeClaim, node, existing)
+			ExpectManualBinding(ctx, env.Client, existing, node)
+			ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeClaimStateController, client.ObjectKeyFromObject(nodeClaim))
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			// a pod without resource claims can still schedule to the node
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+
+			pod = test.UnschedulablePod(test.PodOptions{ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimTemplateName: lo.ToPtr(oneGPU.Name)}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			Expect(ExpectScheduled(ctx, env.Client, pod).Name).ToNot(Equal(node.Name))
+		})
+	})
+
+	Describe("Priority and Preemption", func() {
+		var highPriority, lowPriority, highPriorityNonPreempting *schedulingv1.PriorityClass
+		BeforeEach(func() {
+			highPriority = &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: test.RandomName()}, Value: 1000}
+			lowPriority = &schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: test.RandomName()}, Value: 10}
+			highPriorityNonPreempting = &schedulingv1.PriorityClass{
+				ObjectMeta:       metav1.ObjectMeta{Name: test.RandomName()},
+				Value:            1000,
+				PreemptionPolicy: lo.ToPtr(corev1.PreemptNever),
+			}
+			ExpectApplied(ctx, env.Client, highPriority, lowPriority, highPriorityNonPreempting)
+		})
+		AfterEach(func() {
+			ExpectDeleted(ctx, env.Client, highPriority, lowPriority, highPriorityNonPreempting)
+		})
+		It("should schedule higher priority pods first when capacity is limited", func() {
+			nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")})
+			ExpectApplied(ctx, env.Client, nodePool)
+			// the low priority pod is larger, so it would be scheduled first if we only sorted by resource requests
+			lowPriorityPod := test.UnschedulablePod(test.PodOptions{
+				PriorityClassName:    lowPriority.Name,
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.75")}},
+			})
+			highPriorityPod := test.UnschedulablePod(test.PodOptions{
+				PriorityClassName:    highPriority.Name,
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, lowPriorityPod, highPriorityPod)
+			ExpectScheduled(ctx, env.Client, highPriorityPod)
+			ExpectNotScheduled(ctx, env.Client, lowPriorityPod)
+		})
+		Context("Preemption", func() {
+			var nodeClaim *v1.NodeClaim
+			var node *corev1.Node
+			var lowPriorityPods []*corev1.Pod
+			BeforeEach(func() {
+				nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey: nodePool.Name,
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Capacity: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("10"),
+							corev1.ResourceMemory: resource.MustParse("10Gi"),
+							corev1.ResourcePods:   resource.MustParse("110"),
+						},
+						Allocatable: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("10"),
+							corev1.ResourceMemory: resource.MustParse("10Gi"),
+							corev1.ResourcePods:   resource.MustParse("110"),
+						},
+					},
+				})
+				lowPriorityPods = test.Pods(2, test.PodOptions{
+					PriorityClassName:    lowPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectApplied(ctx, env.Client, nodeClaim, node)
+				for _, p := range lowPriorityPods {
+					ExpectApplied(ctx, env.Client, p)
+					ExpectManualBinding(ctx, env.Client, p, node)
+				}
+				ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+				ExpectMakeNodesInitialized(ctx, env.Client, node)
+				ExpectReconcileSucceeded(ctx, nodeClaimStateController, client.ObjectKeyFromObject(nodeClaim))
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			})
+			It("should schedule a higher priority pod to an existing node by preempting lower priority pods when limits are reached", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    highPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+				Expect(cloudProvider.CreateCalls).To(BeEmpty())
+			})
+			It("should not preempt lower priority pods when the pod has a PreemptNever preemption policy", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    highPriorityNonPreempting.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should not preempt lower priority pods that a pod disruption budget doesn't allow to evict", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				labels := map[string]string{"app": "test"}
+				for _, p := range lowPriorityPods {
+					p.Labels = labels
+				}
+				pdb := test.PodDisruptionBudget(test.PDBOptions{
+					Labels:         labels,
+					MaxUnavailable: lo.ToPtr(intstr.FromInt32(0)),
+					Status: &policyv1.PodDisruptionBudgetStatus{
+						ObservedGeneration: 1,
+						DisruptionsAllowed: 0,
+						CurrentHealthy:     2,
+						DesiredHealthy:     2,
+						ExpectedPods:       2,
+					},
+				})
+				ExpectApplied(ctx, env.Client, nodePool, pdb, lowPriorityPods[0], lowPriorityPods[1])
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    highPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should not preempt more lower priority pods than a pod disruption budget allows to evict", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				labels := map[string]string{"app": "test"}
+				for _, p := range lowPriorityPods {
+					p.Labels = labels
+				}
+				pdb := test.PodDisruptionBudget(test.PDBOptions{
+					Labels:         labels,
+					MaxUnavailable: lo.ToPtr(intstr.FromInt32(1)),
+					Status: &policyv1.PodDisruptionBudgetStatus{
+						ObservedGeneration: 1,
+						DisruptionsAllowed: 1,
+						CurrentHealthy:     2,
+						DesiredHealthy:     1,
+						ExpectedPods:       2,
+					},
+				})
+				ExpectApplied(ctx, env.Client, nodePool, pdb, lowPriorityPods[0], lowPriorityPods[1])
+				// Each pod needs to preempt one of the lower priority pods, but the budget only allows to evict one
+				pods := test.UnschedulablePods(test.PodOptions{
+					PriorityClassName:    highPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				}, 2)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				Expect(lo.CountBy(pods, func(p *corev1.Pod) bool { return ExpectExists(ctx, env.Client, p).Spec.NodeName == node.Name })).To(Equal(1))
+				Expect(cloudProvider.CreateCalls).To(BeEmpty())
+			})
+			It("should preempt the lower priority pod that uses the host port of the pod", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				hostPortPod := test.Pod(test.PodOptions{
+					PriorityClassName:    lowPriority.Name,
+					HostPorts:            []int32{80},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				})
+				ExpectApplied(ctx, env.Client, hostPortPod)
+				ExpectManualBinding(ctx, env.Client, hostPortPod, node)
+				ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(hostPortPod))
+				// The pod fits in the resources that are freed by preempting the host port pod alone, but it can only
+				// schedule if the host port that it uses is released too
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    highPriority.Name,
+					HostPorts:            []int32{80},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+			})
+			It("should not preempt pods with an equal priority", func() {
+				nodePool.Spec.Limits = v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    lowPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should launch a new node instead of preempting lower priority pods when limits aren't reached", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					PriorityClassName:    highPriority.Name,
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(ExpectScheduled(ctx, env.Client, pod).Name).ToNot(Equal(node.Name))
+			})
+		})
+	})
+
+	Describe("Solve Timeout", func() {
+		var pods []*corev1.Pod
+		var newScheduler func(context.Context) *scheduling.Scheduler
+		var schedulerClock realclock.Clock
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods = test.UnschedulablePods(test.PodOptions{}, 10)
+			// Use a real clock so that time passes while the scheduler is computing the scheduling simulation
+			schedulerClock = &realclock.RealClock{}
+			newScheduler = func(ctx context.Context) *scheduling.Scheduler {
+				its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).To(BeNil())
+				topology, err := scheduling.NewTopology(ctx, env.Client, cluster, map[string]sets.Set[string]{}, pods)
+				Expect(err).To(BeNil())
+				return scheduling.NewScheduler(ctx, env.Client, []*v1.NodePool{nodePool}, cluster, nil, topology,
+					map[string][]*cloudprovider.InstanceType{nodePool.Name: its}, nil, events.NewRecorder(&record.FakeRecorder{}), schedulerClock)
+			}
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should report the remaining pods as timed out when the solve timeout is exceeded", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SolveTimeout: lo.ToPtr(time.Nanosecond)}))
+			results := newScheduler(ctx).Solve(injection.WithControllerName(ctx, "provisioner"), pods)
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(len(pods)))
+			for _, err := range results.PodErrors {
+				Expect(err).To(MatchError(scheduling.ErrSolveTimeout))
+			}
+			m, ok := FindMetricWithLabelValues("karpenter_scheduler_solve_timeouts_total", map[string]string{"controller": "provisioner"})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.Counter.Value)).To(BeNumerically(">", 0))
+		})
+		It("should return the nodeclaims that were computed before the solve timeout was exceeded", func() {
+			// Launch a NodeClaim for every pod, and time out after the third pod
+			nodePool.Spec.BinPacking = v1.BinPacking{Strategy: v1.BinPackingStrategySpread, MaxPodsPerNodeClaim: lo.ToPtr(int32(1))}
+			ExpectApplied(ctx, env.Client, nodePool)
+			schedulerClock = &steppingClock{FakeClock: clock.NewFakeClock(time.Now()), step: time.Second}
+			results := newScheduler(ctx).Solve(ctx, pods, scheduling.WithTimeout(10*time.Second))
+			Expect(results.NewNodeClaims).To(HaveLen(3))
+			for _, nodeClaim := range results.NewNodeClaims {
+				Expect(nodeClaim.Pods).To(HaveLen(1))
+			}
+			Expect(results.PodErrors).To(HaveLen(len(pods) - 3))
+			for _, err := range results.PodErrors {
+				Expect(err).To(MatchError(scheduling.ErrSolveTimeout))
+			}
+		})
+		It("should schedule all pods when the solve timeout isn't exceeded", func() {
+			results := newScheduler(ctx).Solve(ctx, pods)
+			Expect(results.NewNodeClaims).ToNot(BeEmpty())
+			Expect(results.PodErrors).To(BeEmpty())
+		})
+		It("should time out with a timeout that is stricter than the solve timeout", func() {
+			results := newScheduler(ctx).Solve(ctx, pods, scheduling.WithTimeout(time.Nanosecond))
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(len(pods)))
+		})
+		It("should not extend the solve timeout with a longer timeout", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SolveTimeout: lo.ToPtr(time.Nanosecond)}))
+			results := newScheduler(ctx).Solve(ctx, pods, scheduling.WithTimeout(time.Hour))
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(len(pods)))
+		})
+	})
+	Describe("Explanations", func() {
+		explain := func(pod *corev1.Pod) scheduling.Explanations {
+			results, err := prov.Schedule(injection.WithControllerName(ctx, "provisioner"))
+			Expect(err).ToNot(HaveOccurred())
+			unschedulable, ok := lo.FindKeyBy(results.PodErrors, func(p *corev1.Pod, _ error) bool { return p.UID == pod.UID })
+			Expect(ok).To(BeTrue())
+			return scheduling.Explain(results.PodErrors[unschedulable])
+		}
+		It("should explain a taint that isn't tolerated", func() {
+			nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			pod := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, nodePool, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonTaint, Key: "test-taint"}))
+		})
+		It("should explain an incompatible requirement", func() {
+			nodePool = test.NodePool()
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "unknown-zone"}})
+			ExpectApplied(ctx, env.Client, nodePool, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonRequirement, Key: corev1.LabelTopologyZone}))
+		})
+		It("should explain an incompatible zone of a pod with persistent volumes as a volume reason", func() {
+			nodePool = test.NodePool()
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{"test-claim"},
+				NodeRequirements: []corev1.NodeSelectorRequirement{
+					{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"unknown-zone"}},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonVolume, Key: corev1.LabelTopologyZone}))
+		})
+		It("should explain that no instance type has enough resources", func() {
+			nodePool = test.NodePool()
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10000")},
+			}})
+			ExpectApplied(ctx, env.Client, nodePool, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonResources}))
+		})
+		It("should explain that the daemonset overhead prevents the pod from fitting", func() {
+			nodePool = test.NodePool()
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "small-instance-type",
+					Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+				}),
+			}
+			daemonSet := test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			}}})
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+			}})
+			ExpectApplied(ctx, env.Client, nodePool, daemonSet, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonDaemonSetOverhead}))
+		})
+		It("should explain that the limits of the NodePool would be exceeded", func() {
+			nodePool = test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")})},
+			})
+			pod := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, nodePool, pod)
+			Expect(explain(pod)).To(ConsistOf(scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonLimits}))
+		})
+		It("should explain the reason of each NodePool that rejected the pod", func() {
+			nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			otherNodePool := test.NodePool()
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "unknown-zone"}})
+			ExpectApplied(ctx, env.Client, nodePool, otherNodePool, pod)
+			explanations := explain(pod)
+			Expect(explanations).To(ConsistOf(
+				scheduling.Explanation{NodePool: nodePool.Name, Reason: scheduling.UnschedulableReasonTaint, Key: "test-taint"},
+				scheduling.Explanation{NodePool: otherNodePool.Name, Reason: scheduling.UnschedulableReasonRequirement, Key: corev1.LabelTopologyZone},
+			))
+			Expect(explanations.Reason()).To(Equal("Multiple"))
+		})
+		It("should explain pods that weren't considered before the solve timed out", func() {
+			Expect(scheduling.Explain(fmt.Errorf("%w, %w", scheduling.ErrSolveTimeout, fmt.Errorf("incompatible requirements")))).To(ConsistOf(
+				scheduling.Explanation{Reason: scheduling.UnschedulableReasonTimeout},
+				scheduling.Explanation{Reason: scheduling.UnschedulableReasonUnknown},
+			))
+		})
+	})
 	Describe("Metrics", func() {
 		It("should surface the queueDepth metric while executing the scheduling loop", func() {
 			nodePool = test.NodePool()
@@ -3714,6 +4236,24 @@ var _ = Context("Scheduling", func() {
 			Expect(lo.FromPtr(m.Gauge.Value)).To(BeNumerically("==", 10))
 			Expect(err).To(BeNil())
 		})
+		It("should surface the UnschedulablePodsByReasonCount metric while executing the scheduling loop", func() {
+			nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			for _, pod := range test.UnschedulablePods(test.PodOptions{}, 3) {
+				ExpectApplied(ctx, env.Client, pod)
+			}
+			_, err := prov.Schedule(injection.WithControllerName(ctx, "provisioner"))
+			Expect(err).To(BeNil())
+			m, ok := FindMetricWithLabelValues("karpenter_scheduler_unschedulable_pods_by_reason_count", map[string]string{
+				"controller": "provisioner",
+				"nodepool":   nodePool.Name,
+				"reason":     string(scheduling.UnschedulableReasonTaint),
+			})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.Gauge.Value)).To(BeNumerically("==", 3))
+		})
 		It("should surface the schedulingDuration metric after executing a scheduling loop", func() {
 			nodePool = test.NodePool()
 			ExpectApplied(ctx, env.Client, nodePool)
@@ -3910,3 +4450,15 @@ func ExpectInstancesWithLabel(instanceTypes []*cloudprovider.InstanceType, label
 		}
 	}
 }
+
+// steppingClock is a fake clock that steps forward every time that the elapsed time is measured, so that a scheduling
+// simulation times out after a deterministic number of pods
+type steppingClock struct {
+	*clock.FakeClock
+	step time.Duration
+}
+
+func (c *steppingClock) Since(t time.Time) time.Duration {
+	c.Step(c.step)
+	return c.FakeClock.Since(t)
+}
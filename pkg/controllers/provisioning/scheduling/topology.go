@@ -133,11 +133,11 @@ func (t *Topology) Update(ctx context.Context, p *corev1.Pod) error {
 	return nil
 }
 
-// Record records the topology changes given that pod p schedule on a node with the given requirements
-func (t *Topology) Record(p *corev1.Pod, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) {
+// Record records the topology changes given that pod p schedule on a node with the given taints and requirements
+func (t *Topology) Record(p *corev1.Pod, taints []corev1.Taint, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) {
 	// once we've committed to a domain, we record the usage in every topology that cares about it
 	for _, tc := range t.topologies {
-		if tc.Counts(p, requirements, compatabilityOptions...) {
+		if tc.Counts(p, taints, requirements, compatabilityOptions...) {
 			domains := requirements.Get(tc.Key)
 			if tc.Type == TopologyTypePodAntiAffinity {
 				// for anti-affinity topologies we need to block out all possible domains that the pod could land in
@@ -159,13 +159,36 @@ func (t *Topology) Record(p *corev1.Pod, requirements scheduling.Requirements, c
 	}
 }
 
+// Unrecord removes the topology counts of pod p that is bound to a node with the given taints and requirements, as if
+// the pod was deleted
+func (t *Topology) Unrecord(p *corev1.Pod, taints []corev1.Taint, requirements scheduling.Requirements) {
+	if IgnoredForTopology(p) {
+		return
+	}
+	for _, tc := range t.topologies {
+		if tc.Counts(p, taints, requirements) {
+			domains := requirements.Get(tc.Key)
+			if tc.Type == TopologyTypePodAntiAffinity {
+				tc.Unrecord(domains.Values()...)
+			} else if domains.Len() == 1 {
+				tc.Unrecord(domains.Values()[0])
+			}
+		}
+	}
+	for _, tc := range t.inverseTopologies {
+		if tc.IsOwnedBy(p.UID) {
+			tc.Unrecord(requirements.Get(tc.Key).Values()...)
+		}
+	}
+}
+
 // AddRequirements tightens the input requirements by adding additional requirements that are being enforced by topology spreads
 // affinities, anti-affinities or inverse anti-affinities.  The nodeHostname is the hostname that we are currently considering
 // placing the pod on.  It returns these newly tightened requirements, or an error in the case of a set of requirements that
 // cannot be satisfied.
-func (t *Topology) AddRequirements(podRequirements, nodeRequirements scheduling.Requirements, p *corev1.Pod, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) (scheduling.Requirements, error) {
+func (t *Topology) AddRequirements(podRequirements, nodeRequirements scheduling.Requirements, p *corev1.Pod, taints []corev1.Taint, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) (scheduling.Requirements, error) {
 	requirements := scheduling.NewRequirements(nodeRequirements.Values()...)
-	for _, topology := range t.getMatchingTopologies(p, nodeRequirements, compatabilityOptions...) {
+	for _, topology := range t.getMatchingTopologies(p, taints, nodeRequirements, compatabilityOptions...) {
 		podDomains := scheduling.NewRequirement(topology.Key, corev1.NodeSelectorOpExists)
 		if podRequirements.Has(topology.Key) {
 			podDomains = podRequirements.Get(topology.Key)
@@ -245,7 +268,7 @@ func (t *Topology) updateInverseAntiAffinity(ctx context.Context, pod *corev1.Po
 			return err
 		}
 
-		tg := NewTopologyGroup(TopologyTypePodAntiAffinity, term.TopologyKey, pod, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[term.TopologyKey])
+		tg := NewTopologyGroup(TopologyTypePodAntiAffinity, term.TopologyKey, pod, namespaces, term.LabelSelector, math.MaxInt32, nil, nil, nil, t.domains[term.TopologyKey])
 
 		hash := tg.Hash()
 		if existing, ok := t.inverseTopologies[hash]; !ok {
@@ -311,7 +334,8 @@ func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
 			continue // Don't include pods if node doesn't contain domain https://kubernetes.io/docs/concepts/workloads/pods/pod-topology-spread-constraints/#conventions
 		}
 		// nodes may or may not be considered for counting purposes for topology spread constraints depending on if they
-		// are selected by the pod's node selectors and required node affinities.  If these are unset, the node always counts.
+		// are selected by the pod's node selectors and required node affinities, and if the pod tolerates their taints.
+		// This follows the nodeAffinityPolicy and nodeTaintsPolicy of the constraint.
 		if !tg.nodeFilter.Matches(node) {
 			continue
 		}
@@ -323,11 +347,32 @@ func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
 func (t *Topology) newForTopologies(p *corev1.Pod) []*TopologyGroup {
 	var topologyGroups []*TopologyGroup
 	for _, cs := range p.Spec.TopologySpreadConstraints {
-		topologyGroups = append(topologyGroups, NewTopologyGroup(TopologyTypeSpread, cs.TopologyKey, p, sets.New(p.Namespace), cs.LabelSelector, cs.MaxSkew, cs.MinDomains, t.domains[cs.TopologyKey]))
+		topologyGroups = append(topologyGroups, NewTopologyGroup(TopologyTypeSpread, cs.TopologyKey, p, sets.New(p.Namespace), topologySpreadSelector(p, cs), cs.MaxSkew, cs.MinDomains, cs.NodeTaintsPolicy, cs.NodeAffinityPolicy, t.domains[cs.TopologyKey]))
 	}
 	return topologyGroups
 }
 
+// topologySpreadSelector returns the label selector of the topology spread constraint with the matchLabelKeys folded
+// in. The values of the keys are looked up from the labels of the pod, so pods from different revisions of a
+// deployment (e.g. with a different pod-template-hash) are spread independently. As in kube-scheduler, matchLabelKeys
+// is ignored when the constraint has no label selector and keys that the pod doesn't have a label for are skipped.
+func topologySpreadSelector(p *corev1.Pod, cs corev1.TopologySpreadConstraint) *metav1.LabelSelector {
+	if cs.LabelSelector == nil || len(cs.MatchLabelKeys) == 0 {
+		return cs.LabelSelector
+	}
+	selector := cs.LabelSelector.DeepCopy()
+	for _, key := range cs.MatchLabelKeys {
+		if value, ok := p.Labels[key]; ok {
+			selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key:      key,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{value},
+			})
+		}
+	}
+	return selector
+}
+
 // newForAffinities returns a list of topology groups that have been constructed based on the input pod and required/preferred affinity terms
 func (t *Topology) newForAffinities(ctx context.Context, p *corev1.Pod) ([]*TopologyGroup, error) {
 	var topologyGroups []*TopologyGroup
@@ -360,7 +405,7 @@ func (t *Topology) newForAffinities(ctx context.Context, p *corev1.Pod) ([]*Topo
 			if err != nil {
 				return nil, err
 			}
-			topologyGroups = append(topologyGroups, NewTopologyGroup(topologyType, term.TopologyKey, p, namespaces, term.LabelSelector, math.MaxInt32, nil, t.domains[term.TopologyKey]))
+			topologyGroups = append(topologyGroups, NewTopologyGroup(topologyType, term.TopologyKey, p, namespaces, term.LabelSelector, math.MaxInt32, nil, nil, nil, t.domains[term.TopologyKey]))
 		}
 	}
 	return topologyGroups, nil
@@ -393,7 +438,7 @@ func (t *Topology) buildNamespaceList(ctx context.Context, namespace string, nam
 
 // getMatchingTopologies returns a sorted list of topologies that either control the scheduling of pod p, or for which
 // the topology selects pod p and the scheduling of p affects the count per topology domain
-func (t *Topology) getMatchingTopologies(p *corev1.Pod, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) []*TopologyGroup {
+func (t *Topology) getMatchingTopologies(p *corev1.Pod, taints []corev1.Taint, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) []*TopologyGroup {
 	var matchingTopologies []*TopologyGroup
 	for _, tc := range t.topologies {
 		if tc.IsOwnedBy(p.UID) {
@@ -401,7 +446,7 @@ func (t *Topology) getMatchingTopologies(p *corev1.Pod, requirements scheduling.
 		}
 	}
 	for _, tc := range t.inverseTopologies {
-		if tc.Counts(p, requirements, compatabilityOptions...) {
+		if tc.Counts(p, taints, requirements, compatabilityOptions...) {
 			matchingTopologies = append(matchingTopologies, tc)
 		}
 	}
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -1319,6 +1320,141 @@ var _ = Describe("Topology", func() {
 		})
 	})
 
+	// https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/#spread-constraint-definition
+	Context("Node Inclusion Policies", func() {
+		var taintedNode *corev1.Node
+		BeforeEach(func() {
+			taintedNode = test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}},
+				Taints:     []corev1.Taint{{Key: "example.com/taint", Effect: corev1.TaintEffectNoSchedule}},
+			})
+		})
+		It("should count pods on nodes with untolerated taints by default", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, taintedNode)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(taintedNode))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				append(
+					test.Pods(2, test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: taintedNode.Name}),
+					test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 3)...,
+				)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(2, 2, 1))
+		})
+		It("should not count pods on nodes with untolerated taints when honoring taints", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+				NodeTaintsPolicy:  lo.ToPtr(corev1.NodeInclusionPolicyHonor),
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, taintedNode)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(taintedNode))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				append(
+					test.Pods(2, test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: taintedNode.Name}),
+					test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 3)...,
+				)...,
+			)
+			// the pods on the tainted node aren't counted, so the new pods are spread evenly across the zones
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(3, 1, 1))
+		})
+		It("should limit spread options by nodeSelector by default", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{
+					ObjectMeta:                metav1.ObjectMeta{Labels: labels},
+					TopologySpreadConstraints: topology,
+					NodeSelector:              map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+				}, 3)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(3))
+		})
+		It("should not limit spread options by nodeSelector when ignoring node affinity", func() {
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:        corev1.LabelTopologyZone,
+				WhenUnsatisfiable:  corev1.DoNotSchedule,
+				LabelSelector:      &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:            1,
+				NodeAffinityPolicy: lo.ToPtr(corev1.NodeInclusionPolicyIgnore),
+			}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{
+				ObjectMeta:                metav1.ObjectMeta{Labels: labels},
+				TopologySpreadConstraints: topology,
+				NodeSelector:              map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+			}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			// the other zones are empty and count towards the min count, so only a single pod can schedule to test-zone-1
+			// without violating max skew
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1))
+		})
+	})
+	Context("MatchLabelKeys", func() {
+		It("should only count pods from the same revision", func() {
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}}})
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+				MatchLabelKeys:    []string{appsv1.DefaultDeploymentUniqueLabelKey},
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				append(
+					test.Pods(2, test.PodOptions{
+						ObjectMeta: metav1.ObjectMeta{Labels: lo.Assign(labels, map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "old"})},
+						NodeName:   node.Name,
+					}),
+					test.UnschedulablePods(test.PodOptions{
+						ObjectMeta:                metav1.ObjectMeta{Labels: lo.Assign(labels, map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "new"})},
+						TopologySpreadConstraints: topology,
+					}, 3)...,
+				)...,
+			)
+			// the pods from the old revision aren't counted, so the new pods are spread evenly across the zones
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(3, 1, 1))
+		})
+		It("should count pods from other revisions without matchLabelKeys", func() {
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}}})
+			topology := []corev1.TopologySpreadConstraint{{
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				MaxSkew:           1,
+			}}
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				append(
+					test.Pods(2, test.PodOptions{
+						ObjectMeta: metav1.ObjectMeta{Labels: lo.Assign(labels, map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "old"})},
+						NodeName:   node.Name,
+					}),
+					test.UnschedulablePods(test.PodOptions{
+						ObjectMeta:                metav1.ObjectMeta{Labels: lo.Assign(labels, map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "new"})},
+						TopologySpreadConstraints: topology,
+					}, 3)...,
+				)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(2, 2, 1))
+		})
+	})
+
 	// https://kubernetes.io/docs/concepts/workloads/pods/pod-topology-spread-constraints/#interaction-with-node-affinity-and-node-selectors
 	Context("Combined Capacity Type Topology and Node Affinity", func() {
 		It("should limit spread options by nodeSelector", func() {
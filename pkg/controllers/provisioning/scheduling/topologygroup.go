@@ -69,15 +69,15 @@ type TopologyGroup struct {
 	emptyDomains sets.Set[string]       // domains for which we know that no pod exists
 }
 
-func NewTopologyGroup(topologyType TopologyType, topologyKey string, pod *v1.Pod, namespaces sets.Set[string], labelSelector *metav1.LabelSelector, maxSkew int32, minDomains *int32, domains sets.Set[string]) *TopologyGroup {
+func NewTopologyGroup(topologyType TopologyType, topologyKey string, pod *v1.Pod, namespaces sets.Set[string], labelSelector *metav1.LabelSelector, maxSkew int32, minDomains *int32, taintPolicy, affinityPolicy *v1.NodeInclusionPolicy, domains sets.Set[string]) *TopologyGroup {
 	domainCounts := map[string]int32{}
 	for domain := range domains {
 		domainCounts[domain] = 0
 	}
-	// the nil *TopologyNodeFilter always passes which is what we need for affinity/anti-affinity
+	// the zero-value TopologyNodeFilter always passes which is what we need for affinity/anti-affinity
 	var nodeSelector TopologyNodeFilter
 	if topologyType == TopologyTypeSpread {
-		nodeSelector = MakeTopologyNodeFilter(pod)
+		nodeSelector = MakeTopologyNodeFilter(pod, taintPolicy, affinityPolicy)
 	}
 	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
 	if err != nil {
@@ -118,10 +118,24 @@ func (t *TopologyGroup) Record(domains ...string) {
 	}
 }
 
+// Unrecord removes a pod from the counts of the given domains
+func (t *TopologyGroup) Unrecord(domains ...string) {
+	for _, domain := range domains {
+		count, ok := t.domains[domain]
+		if !ok || count == 0 {
+			continue
+		}
+		t.domains[domain]--
+		if t.domains[domain] == 0 {
+			t.emptyDomains.Insert(domain)
+		}
+	}
+}
+
 // Counts returns true if the pod would count for the topology, given that it schedule to a node with the provided
-// requirements
-func (t *TopologyGroup) Counts(pod *v1.Pod, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) bool {
-	return t.selects(pod) && t.nodeFilter.MatchesRequirements(requirements, compatabilityOptions...)
+// taints and requirements
+func (t *TopologyGroup) Counts(pod *v1.Pod, taints []v1.Taint, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) bool {
+	return t.selects(pod) && t.nodeFilter.MatchesRequirements(taints, requirements, compatabilityOptions...)
 }
 
 // Register ensures that the topology is aware of the given domain names.
@@ -179,8 +193,13 @@ func (t *TopologyGroup) Hash() uint64 {
 // If there are no eligible domains, we return a `DoesNotExist` requirement, implying that we could not satisfy the topologySpread requirement.
 // nolint:gocyclo
 func (t *TopologyGroup) nextDomainTopologySpread(pod *v1.Pod, podDomains, nodeDomains *scheduling.Requirement) *scheduling.Requirement {
-	// min count is calculated across all domains
-	min := t.domainMinCount(podDomains)
+	// min count is calculated across all domains that the pod can schedule to. If the constraint ignores node affinity,
+	// kube-scheduler calculates the min count across all domains regardless of the pod's node selectors and affinities.
+	minDomains := podDomains
+	if t.nodeFilter.AffinityPolicy == v1.NodeInclusionPolicyIgnore {
+		minDomains = scheduling.NewRequirement(podDomains.Key, v1.NodeSelectorOpExists)
+	}
+	min := t.domainMinCount(minDomains)
 	selfSelecting := t.selects(pod)
 
 	minDomain := ""
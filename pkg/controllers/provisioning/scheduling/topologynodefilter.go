@@ -18,56 +18,88 @@ package scheduling
 
 import (
 	"github.com/awslabs/operatorpkg/option"
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/dcoppa/karpenter/pkg/scheduling"
 )
 
-// TopologyNodeFilter is used to determine if a given actual node or scheduling node matches the pod's node selectors
-// and required node affinity terms.  This is used with topology spread constraints to determine if the node should be
-// included for topology counting purposes. This is only used with topology spread constraints as affinities/anti-affinities
-// always count across all nodes. A nil or zero-value TopologyNodeFilter behaves well and the filter returns true for
-// all nodes.
-type TopologyNodeFilter []scheduling.Requirements
+// TopologyNodeFilter is used to determine if a given actual node or scheduling node matches the pod's node selectors,
+// required node affinity terms and tolerations. This is used with topology spread constraints to determine if the node
+// should be included for topology counting purposes, following the nodeAffinityPolicy and nodeTaintsPolicy of the
+// constraint. This is only used with topology spread constraints as affinities/anti-affinities always count across all
+// nodes. A zero-value TopologyNodeFilter behaves well and the filter returns true for all nodes.
+type TopologyNodeFilter struct {
+	Requirements   []scheduling.Requirements
+	AffinityPolicy v1.NodeInclusionPolicy
+	TaintPolicy    v1.NodeInclusionPolicy
+	Tolerations    []v1.Toleration
+}
 
-func MakeTopologyNodeFilter(p *v1.Pod) TopologyNodeFilter {
+// MakeTopologyNodeFilter constructs the filter for a topology spread constraint of the pod. As in kube-scheduler, an
+// unset nodeAffinityPolicy defaults to Honor and an unset nodeTaintsPolicy defaults to Ignore.
+func MakeTopologyNodeFilter(p *v1.Pod, taintPolicy, affinityPolicy *v1.NodeInclusionPolicy) TopologyNodeFilter {
+	filter := TopologyNodeFilter{
+		AffinityPolicy: lo.FromPtrOr(affinityPolicy, v1.NodeInclusionPolicyHonor),
+		TaintPolicy:    lo.FromPtrOr(taintPolicy, v1.NodeInclusionPolicyIgnore),
+	}
+	if filter.TaintPolicy == v1.NodeInclusionPolicyHonor {
+		filter.Tolerations = p.Spec.Tolerations
+	}
+	if filter.AffinityPolicy != v1.NodeInclusionPolicyHonor {
+		return filter
+	}
 	nodeSelectorRequirements := scheduling.NewLabelRequirements(p.Spec.NodeSelector)
 	// if we only have a label selector, that's the only requirement that must match
 	if p.Spec.Affinity == nil || p.Spec.Affinity.NodeAffinity == nil || p.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
-		return TopologyNodeFilter{nodeSelectorRequirements}
+		filter.Requirements = []scheduling.Requirements{nodeSelectorRequirements}
+		return filter
 	}
 
 	// otherwise, we need to match the combination of label selector and any term of the required node affinities since
 	// those terms are OR'd together
-	var filter TopologyNodeFilter
 	for _, term := range p.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
 		requirements := scheduling.NewRequirements()
 		requirements.Add(nodeSelectorRequirements.Values()...)
 		requirements.Add(scheduling.NewNodeSelectorRequirements(term.MatchExpressions...).Values()...)
-		filter = append(filter, requirements)
+		filter.Requirements = append(filter.Requirements, requirements)
 	}
-
 	return filter
 }
 
 // Matches returns true if the TopologyNodeFilter doesn't prohibit node from the participating in the topology
 func (t TopologyNodeFilter) Matches(node *v1.Node) bool {
-	return t.MatchesRequirements(scheduling.NewLabelRequirements(node.Labels))
+	return t.MatchesRequirements(node.Spec.Taints, scheduling.NewLabelRequirements(node.Labels))
 }
 
-// MatchesRequirements returns true if the TopologyNodeFilter doesn't prohibit a node with the requirements from
-// participating in the topology. This method allows checking the requirements from a scheduling.NodeClaim to see if the
-// node we will soon create participates in this topology.
-func (t TopologyNodeFilter) MatchesRequirements(requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) bool {
+// MatchesRequirements returns true if the TopologyNodeFilter doesn't prohibit a node with the taints and requirements
+// from participating in the topology. This method allows checking the requirements from a scheduling.NodeClaim to see
+// if the node we will soon create participates in this topology.
+func (t TopologyNodeFilter) MatchesRequirements(taints []v1.Taint, requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) bool {
+	return t.matchesTaints(taints) && t.matchesAffinity(requirements, compatabilityOptions...)
+}
+
+func (t TopologyNodeFilter) matchesAffinity(requirements scheduling.Requirements, compatabilityOptions ...option.Function[scheduling.CompatibilityOptions]) bool {
 	// no requirements, so it always matches
-	if len(t) == 0 {
+	if len(t.Requirements) == 0 {
 		return true
 	}
 	// these are an OR, so if any passes the filter passes
-	for _, req := range t {
+	for _, req := range t.Requirements {
 		if err := requirements.Compatible(req, compatabilityOptions...); err == nil {
 			return true
 		}
 	}
 	return false
 }
+
+func (t TopologyNodeFilter) matchesTaints(taints []v1.Taint) bool {
+	if t.TaintPolicy != v1.NodeInclusionPolicyHonor {
+		return true
+	}
+	// kube-scheduler only considers the taints which prevent scheduling when deciding if a node should be counted
+	taints = lo.Filter(taints, func(taint v1.Taint, _ int) bool {
+		return taint.Effect == v1.TaintEffectNoSchedule || taint.Effect == v1.TaintEffectNoExecute
+	})
+	return scheduling.Taints(taints).ToleratedBy(t.Tolerations) == nil
+}
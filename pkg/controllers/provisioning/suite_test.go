@@ -18,7 +18,9 @@ package provisioning_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -41,6 +43,7 @@ import (
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider"
 	"github.com/dcoppa/karpenter/pkg/cloudprovider/fake"
+	nodeclaimlifecycle "github.com/dcoppa/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	"github.com/dcoppa/karpenter/pkg/controllers/provisioning"
 	"github.com/dcoppa/karpenter/pkg/controllers/state"
 	"github.com/dcoppa/karpenter/pkg/controllers/state/informer"
@@ -54,15 +57,16 @@ import (
 )
 
 var (
-	ctx                 context.Context
-	fakeClock           *clock.FakeClock
-	cluster             *state.Cluster
-	nodeController      *informer.NodeController
-	daemonsetController *informer.DaemonSetController
-	cloudProvider       *fake.CloudProvider
-	prov                *provisioning.Provisioner
-	env                 *test.Environment
-	instanceTypeMap     map[string]*cloudprovider.InstanceType
+	ctx                  context.Context
+	fakeClock            *clock.FakeClock
+	cluster              *state.Cluster
+	nodeController       *informer.NodeController
+	daemonsetController  *informer.DaemonSetController
+	cloudProvider        *fake.CloudProvider
+	prov                 *provisioning.Provisioner
+	unavailableOfferings *cloudprovider.UnavailableOfferings
+	env                  *test.Environment
+	instanceTypeMap      map[string]*cloudprovider.InstanceType
 )
 
 func TestAPIs(t *testing.T) {
@@ -78,7 +82,8 @@ var _ = BeforeSuite(func() {
 	fakeClock = clock.NewFakeClock(time.Now())
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	nodeController = informer.NewNodeController(env.Client, cluster)
-	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock)
+	unavailableOfferings = cloudprovider.NewUnavailableOfferings()
+	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, unavailableOfferings)
 	daemonsetController = informer.NewDaemonSetController(env.Client, cluster)
 	instanceTypes, _ := cloudProvider.GetInstanceTypes(ctx, nil)
 	instanceTypeMap = map[string]*cloudprovider.InstanceType{}
@@ -100,6 +105,7 @@ var _ = AfterEach(func() {
 	ExpectCleanedUp(ctx, env.Client)
 	cloudProvider.Reset()
 	cluster.Reset()
+	unavailableOfferings.Flush()
 	pscheduling.IgnoredPodCount.Set(0, nil)
 })
 
@@ -475,6 +481,262 @@ var _ = Describe("Provisioning", func() {
 		}, node.Status.Capacity)
 	})
 
+	Context("Unavailable Offerings", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "small-instance-type",
+					Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "large-instance-type",
+					Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+				}),
+			}
+		})
+		It("should not launch on offerings that are marked as unavailable", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			unavailableOfferings.MarkUnavailable(ctx, "test", cloudprovider.OfferingKey{InstanceType: "small-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand})
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+				corev1.LabelTopologyZone: "test-zone-1",
+				v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("large-instance-type"))
+			// The instance types of the cloud provider must not be modified
+			Expect(cloudProvider.InstanceTypes[0].Offerings.Available()).To(HaveLen(len(cloudProvider.InstanceTypes[0].Offerings)))
+		})
+		It("should launch on offerings that are no longer marked as unavailable", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			key := cloudprovider.OfferingKey{InstanceType: "small-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand}
+			unavailableOfferings.MarkUnavailable(ctx, "test", key)
+			unavailableOfferings.Delete(key)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+				corev1.LabelTopologyZone: "test-zone-1",
+				v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("small-instance-type"))
+		})
+		It("should not launch on an offering that failed to launch with insufficient capacity", func() {
+			nodeClaimController := nodeclaimlifecycle.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}), unavailableOfferings)
+			key := cloudprovider.OfferingKey{InstanceType: "small-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand}
+			cloudProvider.InsufficientCapacityOfferings.Insert(key)
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+				corev1.LabelTopologyZone: "test-zone-1",
+				v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+
+			// The launch of the nodeclaim fails and marks the offering of the InsufficientCapacityError as unavailable
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaims[0])
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaims[0])
+			ExpectNotFound(ctx, env.Client, nodeClaims[0])
+			cluster.DeleteNodeClaim(nodeClaims[0].Name)
+			Expect(unavailableOfferings.IsUnavailable(key)).To(BeTrue())
+
+			// The next scheduling simulation skips the offering
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("large-instance-type"))
+		})
+		It("should not schedule when all of the compatible offerings are marked as unavailable", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			for _, it := range cloudProvider.InstanceTypes {
+				for _, capacityType := range []string{v1.CapacityTypeSpot, v1.CapacityTypeOnDemand} {
+					unavailableOfferings.MarkUnavailable(ctx, "test", cloudprovider.OfferingKey{InstanceType: it.Name, Zone: "test-zone-1", CapacityType: capacityType})
+				}
+			}
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Headroom", func() {
+		It("should launch capacity for the headroom without pending pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				Headroom: &v1.Headroom{Pods: lo.ToPtr(int32(2)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			}}))
+			results, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results.NewNodeClaims).To(HaveLen(1))
+			// The virtual pods aren't reported in the results
+			Expect(results.NewNodeClaims[0].Pods).To(BeEmpty())
+			Expect(results.PodErrors).To(BeEmpty())
+			Expect(results.NewNodeClaims[0].Spec.Resources.Requests.Cpu().Value()).To(BeNumerically(">=", 2))
+		})
+		It("should not launch capacity for the headroom when the existing nodes have room for it", func() {
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			nodePool.Spec.Headroom = &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			results, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results.NewNodeClaims).To(BeEmpty())
+		})
+		It("should size the headroom as a percentage of the usage of the nodepool", func() {
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}}, 4)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			nodePool.Spec.Headroom = &v1.Headroom{Percentage: lo.ToPtr("50%"), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			headroomPods, err := prov.GetHeadroomPods(ctx, cluster.Nodes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(headroomPods).To(HaveLen(2))
+			for _, p := range headroomPods {
+				Expect(provisioning.IsHeadroomPod(p)).To(BeTrue())
+			}
+		})
+		It("should schedule pending pods ahead of the headroom", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				Headroom: &v1.Headroom{Pods: lo.ToPtr(int32(1)), Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			}}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Capacity Floors", func() {
+		// dailyFloor returns a capacity floor that is active for an hour every day, starting at the given time
+		dailyFloor := func(start time.Time, cpu string) v1.CapacityFloor {
+			return v1.CapacityFloor{
+				Schedule:  lo.ToPtr(fmt.Sprintf("%d %d * * *", start.UTC().Minute(), start.UTC().Hour())),
+				Duration:  &metav1.Duration{Duration: time.Hour},
+				Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			}
+		}
+		It("should launch capacity to meet an active capacity floor without pending pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				CapacityFloors: []v1.CapacityFloor{dailyFloor(fakeClock.Now().Add(-30*time.Minute), "4")},
+			}}))
+			results, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results.NewNodeClaims).ToNot(BeEmpty())
+			// The virtual pods aren't reported in the results
+			Expect(results.PodErrors).To(BeEmpty())
+			cpu := lo.SumBy(results.NewNodeClaims, func(n *pscheduling.NodeClaim) int64 { return n.Spec.Resources.Requests.Cpu().MilliValue() })
+			Expect(cpu).To(BeNumerically(">=", 4000))
+		})
+		It("should not launch capacity for a capacity floor outside of its schedule", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				CapacityFloors: []v1.CapacityFloor{dailyFloor(fakeClock.Now().Add(12*time.Hour), "4")},
+			}}))
+			results, err := prov.Schedule(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results.NewNodeClaims).To(BeEmpty())
+		})
+		It("should count the pods on the nodes of the nodepool towards the capacity floor", func() {
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			nodePool.Spec.CapacityFloors = []v1.CapacityFloor{{Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			headroomPods, err := prov.GetHeadroomPods(ctx, cluster.Nodes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(headroomPods).To(BeEmpty())
+		})
+		It("should split the shortfall of the capacity floor into virtual pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				CapacityFloors: []v1.CapacityFloor{{Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3"), corev1.ResourceMemory: resource.MustParse("6Gi")}}},
+			}}))
+			headroomPods, err := prov.GetHeadroomPods(ctx, cluster.Nodes())
+			Expect(err).ToNot(HaveOccurred())
+			// The memory needs the most pods, so each pod requests 1Gi of memory and 500m of cpu
+			Expect(headroomPods).To(HaveLen(6))
+			for _, p := range headroomPods {
+				Expect(provisioning.IsHeadroomPod(p)).To(BeTrue())
+				Expect(p.Spec.Containers[0].Resources.Requests.Cpu().MilliValue()).To(BeNumerically("==", 500))
+				Expect(p.Spec.Containers[0].Resources.Requests.Memory().Value()).To(BeNumerically("==", 1024*1024*1024))
+			}
+		})
+	})
+	Context("Provisionable Condition", func() {
+		It("should explain why a pod can't schedule", func() {
+			nodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			pod = ExpectExists(ctx, env.Client, pod)
+			condition, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool { return c.Type == v1.PodConditionTypeProvisionable })
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(string(pscheduling.UnschedulableReasonTaint)))
+			Expect(condition.Message).To(Equal(pscheduling.Explanations{
+				{NodePool: nodePool.Name, Reason: pscheduling.UnschedulableReasonTaint, Key: "test-taint"},
+			}.String()))
+		})
+		It("should explain the reason of each NodePool that rejected the pod", func() {
+			nodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			limitedNodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{
+				Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}),
+			}})
+			ExpectApplied(ctx, env.Client, nodePool, limitedNodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			pod = ExpectExists(ctx, env.Client, pod)
+			condition, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool { return c.Type == v1.PodConditionTypeProvisionable })
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("Multiple"))
+			explanations := pscheduling.Explanations{}
+			Expect(json.Unmarshal([]byte(condition.Message), &explanations)).To(Succeed())
+			Expect(explanations).To(ConsistOf(
+				pscheduling.Explanation{NodePool: nodePool.Name, Reason: pscheduling.UnschedulableReasonTaint, Key: "test-taint"},
+				pscheduling.Explanation{NodePool: limitedNodePool.Name, Reason: pscheduling.UnschedulableReasonLimits},
+			))
+		})
+		It("should set the condition to true once a pod that couldn't schedule can schedule", func() {
+			nodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{
+				Taints: []corev1.Taint{{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}},
+			}}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+
+			nodePool.Spec.Template.Spec.Taints = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod = ExpectExists(ctx, env.Client, pod)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			pod = ExpectExists(ctx, env.Client, pod)
+			condition, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool { return c.Type == v1.PodConditionTypeProvisionable })
+			Expect(ok).To(BeTrue())
+			Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		})
+		It("should not set the condition on a pod that can schedule", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.Status.Conditions).ToNot(ContainElement(HaveField("Type", v1.PodConditionTypeProvisionable)))
+		})
+	})
 	Context("Resource Limits", func() {
 		It("should not schedule when limits are exceeded", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
@@ -2000,7 +2262,7 @@ func AddInstanceResources(instanceTypes []*cloudprovider.InstanceType, resources
 				corev1.LabelTopologyZone: "test-zone-1",
 			}),
 			Price:     price,
-			Available: true,
+			Available: math.MaxInt,
 		},
 	}
 
@@ -28,11 +28,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/operator/options"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	disruptionutils "github.com/dcoppa/karpenter/pkg/utils/disruption"
 	nodeutils "github.com/dcoppa/karpenter/pkg/utils/node"
 	"github.com/dcoppa/karpenter/pkg/utils/pdb"
 	podutils "github.com/dcoppa/karpenter/pkg/utils/pod"
@@ -47,6 +49,10 @@ func NewPodBlockEvictionError(err error) *PodBlockEvictionError {
 	return &PodBlockEvictionError{error: err}
 }
 
+func (e *PodBlockEvictionError) Unwrap() error {
+	return e.error
+}
+
 func IsPodBlockEvictionError(err error) bool {
 	if err == nil {
 		return false
@@ -55,6 +61,27 @@ func IsPodBlockEvictionError(err error) bool {
 	return errors.As(err, &podBlockEvictionError)
 }
 
+// DoNotDisruptError is returned when a karpenter.sh/do-not-disrupt annotation on the node or on one of its pods blocks
+// disruption until a deadline
+type DoNotDisruptError struct {
+	error
+	deadline time.Time
+}
+
+func NewDoNotDisruptError(err error, deadline time.Time) *DoNotDisruptError {
+	return &DoNotDisruptError{error: err, deadline: deadline}
+}
+
+// DoNotDisruptDeadline returns the time at which the karpenter.sh/do-not-disrupt annotation that blocked disruption
+// stops protecting the node, if the error is a DoNotDisruptError
+func DoNotDisruptDeadline(err error) (time.Time, bool) {
+	var doNotDisruptError *DoNotDisruptError
+	if !errors.As(err, &doNotDisruptError) {
+		return time.Time{}, false
+	}
+	return doNotDisruptError.deadline, true
+}
+
 //go:generate controller-gen object:headerFile="../../../hack/boilerplate.go.txt" paths="."
 
 // StateNodes is a typed version of a list of *Node
@@ -173,7 +200,7 @@ func (in *StateNode) Pods(ctx context.Context, kubeClient client.Client) ([]*cor
 // ValidateNodeDisruptable takes in a recorder to emit events on the nodeclaims when the state node is not a candidate
 //
 //nolint:gocyclo
-func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient client.Client) error {
+func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, clk clock.Clock, kubeClient client.Client) error {
 	if in.NodeClaim == nil {
 		return fmt.Errorf("node is not managed by karpenter")
 	}
@@ -190,7 +217,11 @@ func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient cli
 	if in.Nominated() {
 		return fmt.Errorf("state node is nominated for a pending pod")
 	}
-	if in.Annotations()[v1.DoNotDisruptAnnotationKey] == "true" {
+	// The node is registered at this point, so its annotations are the ones of the node
+	if disruptionutils.IsDoNotDisruptActive(clk, in.Node) {
+		if deadline, _ := disruptionutils.DoNotDisruptDeadline(in.Node); !deadline.IsZero() {
+			return NewDoNotDisruptError(fmt.Errorf("disruption is blocked through the %q annotation until %s", v1.DoNotDisruptAnnotationKey, deadline.Format(time.RFC3339)), deadline)
+		}
 		return fmt.Errorf("disruption is blocked through the %q annotation", v1.DoNotDisruptAnnotationKey)
 	}
 	// check whether the node has the NodePool label
@@ -205,7 +236,7 @@ func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient cli
 // ValidatePodDisruptable takes in a recorder to emit events on the nodeclaims when the state node is not a candidate
 //
 //nolint:gocyclo
-func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, kubeClient client.Client, pdbs pdb.Limits) ([]*corev1.Pod, error) {
+func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, clk clock.Clock, kubeClient client.Client, pdbs pdb.Limits) ([]*corev1.Pod, error) {
 	pods, err := in.Pods(ctx, kubeClient)
 	if err != nil {
 		return nil, fmt.Errorf("getting pods from state node, %w", err)
@@ -213,7 +244,10 @@ func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, kubeClient cli
 	for _, po := range pods {
 		// We only consider pods that are actively running for "karpenter.sh/do-not-disrupt"
 		// This means that we will allow Mirror Pods and DaemonSets to block disruption using this annotation
-		if !podutils.IsDisruptable(po) {
+		if !podutils.IsDisruptable(po, clk) {
+			if deadline, _ := disruptionutils.DoNotDisruptDeadline(po); !deadline.IsZero() {
+				return pods, NewPodBlockEvictionError(NewDoNotDisruptError(fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation until %s`, client.ObjectKeyFromObject(po), deadline.Format(time.RFC3339)), deadline))
+			}
 			return pods, NewPodBlockEvictionError(fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po)))
 		}
 	}
@@ -33,6 +33,8 @@ const (
 	// Reasons for CREATE/DELETE shared metrics
 	ProvisionedReason = "provisioned"
 	ExpiredReason     = "expired"
+	InterruptedReason = "interrupted"
+	StaticReason      = "static"
 )
 
 // DurationBuckets returns a []float64 of default threshold values for duration histograms.
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"time"
 
@@ -30,8 +31,16 @@ import (
 	"github.com/dcoppa/karpenter/pkg/utils/env"
 )
 
+const (
+	// DisruptionApprovalFailurePolicyFail denies disruption commands when the approver can't be reached
+	DisruptionApprovalFailurePolicyFail = "Fail"
+	// DisruptionApprovalFailurePolicyIgnore executes disruption commands when the approver can't be reached
+	DisruptionApprovalFailurePolicyIgnore = "Ignore"
+)
+
 var (
-	validLogLevels = []string{"", "debug", "info", "error"}
+	validLogLevels                       = []string{"", "debug", "info", "error"}
+	validDisruptionApprovalFailurePolicy = []string{DisruptionApprovalFailurePolicyFail, DisruptionApprovalFailurePolicyIgnore}
 
 	Injectables = []Injectable{&Options{}}
 )
@@ -43,26 +52,32 @@ type FeatureGates struct {
 
 	SpotToSpotConsolidation bool
 	NodeRepair              bool
+	CapacityReservations    bool
 }
 
 // Options contains all CLI flags / env vars for karpenter-core. It adheres to the options.Injectable interface.
 type Options struct {
-	ServiceName             string
-	MetricsPort             int
-	HealthProbePort         int
-	KubeClientQPS           int
-	KubeClientBurst         int
-	EnableProfiling         bool
-	DisableLeaderElection   bool
-	LeaderElectionName      string
-	LeaderElectionNamespace string
-	MemoryLimit             int64
-	LogLevel                string
-	LogOutputPaths          string
-	LogErrorOutputPaths     string
-	BatchMaxDuration        time.Duration
-	BatchIdleDuration       time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                     string
+	MetricsPort                     int
+	HealthProbePort                 int
+	KubeClientQPS                   int
+	KubeClientBurst                 int
+	EnableProfiling                 bool
+	DisableLeaderElection           bool
+	LeaderElectionName              string
+	LeaderElectionNamespace         string
+	MemoryLimit                     int64
+	LogLevel                        string
+	LogOutputPaths                  string
+	LogErrorOutputPaths             string
+	BatchMaxDuration                time.Duration
+	BatchIdleDuration               time.Duration
+	SolveTimeout                    time.Duration
+	DisruptionDryRun                bool
+	DisruptionApprovalURL           string
+	DisruptionApprovalTimeout       time.Duration
+	DisruptionApprovalFailurePolicy string
+	FeatureGates                    FeatureGates
 }
 
 type FlagSet struct {
@@ -98,7 +113,12 @@ func (o *Options) AddFlags(fs *FlagSet) {
 	fs.StringVar(&o.LogErrorOutputPaths, "log-error-output-paths", env.WithDefaultString("LOG_ERROR_OUTPUT_PATHS", "stderr"), "Optional comma separated paths for logging error output")
 	fs.DurationVar(&o.BatchMaxDuration, "batch-max-duration", env.WithDefaultDuration("BATCH_MAX_DURATION", 10*time.Second), "The maximum length of a batch window. The longer this is, the more pods we can consider for provisioning at one time which usually results in fewer but larger nodes.")
 	fs.DurationVar(&o.BatchIdleDuration, "batch-idle-duration", env.WithDefaultDuration("BATCH_IDLE_DURATION", time.Second), "The maximum amount of time with no new pending pods that if exceeded ends the current batching window. If pods arrive faster than this time, the batching window will be extended up to the maxDuration. If they arrive slower, the pods will be batched separately.")
-	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation")
+	fs.DurationVar(&o.SolveTimeout, "solve-timeout", env.WithDefaultDuration("SOLVE_TIMEOUT", time.Minute), "The maximum amount of time that a scheduling simulation can take to compute NodeClaims for a batch of pods. When exceeded, the NodeClaims computed so far are launched and the remaining pods are retried in the next batch.")
+	fs.BoolVarWithEnv(&o.DisruptionDryRun, "disruption-dry-run", "DISRUPTION_DRY_RUN", false, "Compute disruption decisions without executing them. Decisions are only recorded through events, logs and metrics.")
+	fs.StringVar(&o.DisruptionApprovalURL, "disruption-approval-url", env.WithDefaultString("DISRUPTION_APPROVAL_URL", ""), "The URL of an HTTP webhook that approves, denies or defers disruption commands before they are executed. Disruption commands are executed without approval when it's empty.")
+	fs.DurationVar(&o.DisruptionApprovalTimeout, "disruption-approval-timeout", env.WithDefaultDuration("DISRUPTION_APPROVAL_TIMEOUT", 10*time.Second), "The maximum amount of time to wait for the disruption approval webhook to respond.")
+	fs.StringVar(&o.DisruptionApprovalFailurePolicy, "disruption-approval-failure-policy", env.WithDefaultString("DISRUPTION_APPROVAL_FAILURE_POLICY", DisruptionApprovalFailurePolicyFail), "How disruption commands are handled when the disruption approval webhook fails or times out. Can be one of 'Fail', which denies the commands, or 'Ignore', which executes them.")
+	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false,CapacityReservations=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation, NodeRepair, CapacityReservations")
 }
 
 func (o *Options) Parse(fs *FlagSet, args ...string) error {
@@ -112,6 +132,20 @@ func (o *Options) Parse(fs *FlagSet, args ...string) error {
 	if !lo.Contains(validLogLevels, o.LogLevel) {
 		return fmt.Errorf("validating cli flags / env vars, invalid LOG_LEVEL %q", o.LogLevel)
 	}
+	if o.SolveTimeout <= 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid SOLVE_TIMEOUT %q, must be positive", o.SolveTimeout)
+	}
+	if o.DisruptionApprovalURL != "" {
+		if _, err := url.ParseRequestURI(o.DisruptionApprovalURL); err != nil {
+			return fmt.Errorf("validating cli flags / env vars, invalid DISRUPTION_APPROVAL_URL %q, %w", o.DisruptionApprovalURL, err)
+		}
+	}
+	if o.DisruptionApprovalTimeout <= 0 {
+		return fmt.Errorf("validating cli flags / env vars, invalid DISRUPTION_APPROVAL_TIMEOUT %q, must be positive", o.DisruptionApprovalTimeout)
+	}
+	if !lo.Contains(validDisruptionApprovalFailurePolicy, o.DisruptionApprovalFailurePolicy) {
+		return fmt.Errorf("validating cli flags / env vars, invalid DISRUPTION_APPROVAL_FAILURE_POLICY %q", o.DisruptionApprovalFailurePolicy)
+	}
 	gates, err := ParseFeatureGates(o.FeatureGates.inputStr)
 	if err != nil {
 		return fmt.Errorf("parsing feature gates, %w", err)
@@ -139,6 +173,9 @@ func ParseFeatureGates(gateStr string) (FeatureGates, error) {
 	if val, ok := gateMap["SpotToSpotConsolidation"]; ok {
 		gates.SpotToSpotConsolidation = val
 	}
+	if val, ok := gateMap["CapacityReservations"]; ok {
+		gates.CapacityReservations = val
+	}
 
 	return gates, nil
 }
@@ -58,6 +58,11 @@ var _ = Describe("Options", func() {
 		"LOG_ERROR_OUTPUT_PATHS",
 		"BATCH_MAX_DURATION",
 		"BATCH_IDLE_DURATION",
+		"SOLVE_TIMEOUT",
+		"DISRUPTION_DRY_RUN",
+		"DISRUPTION_APPROVAL_URL",
+		"DISRUPTION_APPROVAL_TIMEOUT",
+		"DISRUPTION_APPROVAL_FAILURE_POLICY",
 		"FEATURE_GATES",
 	}
 
@@ -95,24 +100,30 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr(""),
-				MetricsPort:             lo.ToPtr(8080),
-				HealthProbePort:         lo.ToPtr(8081),
-				KubeClientQPS:           lo.ToPtr(200),
-				KubeClientBurst:         lo.ToPtr(300),
-				EnableProfiling:         lo.ToPtr(false),
-				DisableLeaderElection:   lo.ToPtr(false),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](-1),
-				LogLevel:                lo.ToPtr("info"),
-				LogOutputPaths:          lo.ToPtr("stdout"),
-				LogErrorOutputPaths:     lo.ToPtr("stderr"),
-				BatchMaxDuration:        lo.ToPtr(10 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(time.Second),
+				ServiceName:                     lo.ToPtr(""),
+				MetricsPort:                     lo.ToPtr(8080),
+				HealthProbePort:                 lo.ToPtr(8081),
+				KubeClientQPS:                   lo.ToPtr(200),
+				KubeClientBurst:                 lo.ToPtr(300),
+				EnableProfiling:                 lo.ToPtr(false),
+				DisableLeaderElection:           lo.ToPtr(false),
+				LeaderElectionName:              lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:         lo.ToPtr(""),
+				MemoryLimit:                     lo.ToPtr[int64](-1),
+				LogLevel:                        lo.ToPtr("info"),
+				LogOutputPaths:                  lo.ToPtr("stdout"),
+				LogErrorOutputPaths:             lo.ToPtr("stderr"),
+				BatchMaxDuration:                lo.ToPtr(10 * time.Second),
+				BatchIdleDuration:               lo.ToPtr(time.Second),
+				SolveTimeout:                    lo.ToPtr(time.Minute),
+				DisruptionDryRun:                lo.ToPtr(false),
+				DisruptionApprovalURL:           lo.ToPtr(""),
+				DisruptionApprovalTimeout:       lo.ToPtr(10 * time.Second),
+				DisruptionApprovalFailurePolicy: lo.ToPtr("Fail"),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(false),
 					SpotToSpotConsolidation: lo.ToPtr(false),
+					CapacityReservations:    lo.ToPtr(false),
 				},
 			}))
 		})
@@ -137,28 +148,39 @@ var _ = Describe("Options", func() {
 				"--log-error-output-paths", "/etc/k8s/testerror",
 				"--batch-max-duration", "5s",
 				"--batch-idle-duration", "5s",
-				"--feature-gates", "SpotToSpotConsolidation=true,NodeRepair=true",
+				"--solve-timeout", "30s",
+				"--disruption-dry-run",
+				"--disruption-approval-url", "https://approver.example.com/cli",
+				"--disruption-approval-timeout", "5s",
+				"--disruption-approval-failure-policy", "Ignore",
+				"--feature-gates", "SpotToSpotConsolidation=true,NodeRepair=true,CapacityReservations=true",
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                     lo.ToPtr("cli"),
+				MetricsPort:                     lo.ToPtr(0),
+				HealthProbePort:                 lo.ToPtr(0),
+				KubeClientQPS:                   lo.ToPtr(0),
+				KubeClientBurst:                 lo.ToPtr(0),
+				EnableProfiling:                 lo.ToPtr(true),
+				DisableLeaderElection:           lo.ToPtr(true),
+				LeaderElectionName:              lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:         lo.ToPtr("karpenter"),
+				MemoryLimit:                     lo.ToPtr[int64](0),
+				LogLevel:                        lo.ToPtr("debug"),
+				LogOutputPaths:                  lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:             lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:                lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:               lo.ToPtr(5 * time.Second),
+				SolveTimeout:                    lo.ToPtr(30 * time.Second),
+				DisruptionDryRun:                lo.ToPtr(true),
+				DisruptionApprovalURL:           lo.ToPtr("https://approver.example.com/cli"),
+				DisruptionApprovalTimeout:       lo.ToPtr(5 * time.Second),
+				DisruptionApprovalFailurePolicy: lo.ToPtr("Ignore"),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
+					CapacityReservations:    lo.ToPtr(true),
 				},
 			}))
 		})
@@ -179,7 +201,12 @@ var _ = Describe("Options", func() {
 			os.Setenv("LOG_ERROR_OUTPUT_PATHS", "/etc/k8s/testerror")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
-			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
+			os.Setenv("SOLVE_TIMEOUT", "30s")
+			os.Setenv("DISRUPTION_DRY_RUN", "true")
+			os.Setenv("DISRUPTION_APPROVAL_URL", "https://approver.example.com/env")
+			os.Setenv("DISRUPTION_APPROVAL_TIMEOUT", "5s")
+			os.Setenv("DISRUPTION_APPROVAL_FAILURE_POLICY", "Ignore")
+			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true,CapacityReservations=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 			}
@@ -187,24 +214,30 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("env"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                     lo.ToPtr("env"),
+				MetricsPort:                     lo.ToPtr(0),
+				HealthProbePort:                 lo.ToPtr(0),
+				KubeClientQPS:                   lo.ToPtr(0),
+				KubeClientBurst:                 lo.ToPtr(0),
+				EnableProfiling:                 lo.ToPtr(true),
+				DisableLeaderElection:           lo.ToPtr(true),
+				LeaderElectionName:              lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:         lo.ToPtr("karpenter"),
+				MemoryLimit:                     lo.ToPtr[int64](0),
+				LogLevel:                        lo.ToPtr("debug"),
+				LogOutputPaths:                  lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:             lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:                lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:               lo.ToPtr(5 * time.Second),
+				SolveTimeout:                    lo.ToPtr(30 * time.Second),
+				DisruptionDryRun:                lo.ToPtr(true),
+				DisruptionApprovalURL:           lo.ToPtr("https://approver.example.com/env"),
+				DisruptionApprovalTimeout:       lo.ToPtr(5 * time.Second),
+				DisruptionApprovalFailurePolicy: lo.ToPtr("Ignore"),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
+					CapacityReservations:    lo.ToPtr(true),
 				},
 			}))
 		})
@@ -220,7 +253,12 @@ var _ = Describe("Options", func() {
 			os.Setenv("LOG_LEVEL", "debug")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
-			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
+			os.Setenv("SOLVE_TIMEOUT", "30s")
+			os.Setenv("DISRUPTION_DRY_RUN", "true")
+			os.Setenv("DISRUPTION_APPROVAL_URL", "https://approver.example.com/env")
+			os.Setenv("DISRUPTION_APPROVAL_TIMEOUT", "5s")
+			os.Setenv("DISRUPTION_APPROVAL_FAILURE_POLICY", "Ignore")
+			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true,CapacityReservations=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 			}
@@ -230,27 +268,34 @@ var _ = Describe("Options", func() {
 				"--karpenter-service", "cli",
 				"--log-output-paths", "/etc/k8s/test",
 				"--log-error-output-paths", "/etc/k8s/testerror",
+				"--disruption-approval-url", "https://approver.example.com/cli",
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                     lo.ToPtr("cli"),
+				MetricsPort:                     lo.ToPtr(0),
+				HealthProbePort:                 lo.ToPtr(0),
+				KubeClientQPS:                   lo.ToPtr(0),
+				KubeClientBurst:                 lo.ToPtr(0),
+				EnableProfiling:                 lo.ToPtr(true),
+				DisableLeaderElection:           lo.ToPtr(true),
+				LeaderElectionName:              lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:         lo.ToPtr(""),
+				MemoryLimit:                     lo.ToPtr[int64](0),
+				LogLevel:                        lo.ToPtr("debug"),
+				LogOutputPaths:                  lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:             lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:                lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:               lo.ToPtr(5 * time.Second),
+				SolveTimeout:                    lo.ToPtr(30 * time.Second),
+				DisruptionDryRun:                lo.ToPtr(true),
+				DisruptionApprovalURL:           lo.ToPtr("https://approver.example.com/cli"),
+				DisruptionApprovalTimeout:       lo.ToPtr(5 * time.Second),
+				DisruptionApprovalFailurePolicy: lo.ToPtr("Ignore"),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
+					CapacityReservations:    lo.ToPtr(true),
 				},
 			}))
 		})
@@ -281,6 +326,27 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--log-level", "hello")
 			Expect(err).ToNot(BeNil())
 		})
+		DescribeTable(
+			"should error with a non-positive solve timeout",
+			func(timeout string) {
+				err := opts.Parse(fs, "--solve-timeout", timeout)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("zero", "0s"),
+			Entry("negative", "-1m"),
+		)
+		It("should error with an invalid disruption approval url", func() {
+			err := opts.Parse(fs, "--disruption-approval-url", "approver")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a non-positive disruption approval timeout", func() {
+			err := opts.Parse(fs, "--disruption-approval-timeout", "0s")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with an invalid disruption approval failure policy", func() {
+			err := opts.Parse(fs, "--disruption-approval-failure-policy", "Retry")
+			Expect(err).ToNot(BeNil())
+		})
 	})
 })
 
@@ -304,5 +370,11 @@ func expectOptionsMatch(optsA, optsB *options.Options) {
 	Expect(optsA.LogErrorOutputPaths).To(Equal(optsB.LogErrorOutputPaths))
 	Expect(optsA.BatchMaxDuration).To(Equal(optsB.BatchMaxDuration))
 	Expect(optsA.BatchIdleDuration).To(Equal(optsB.BatchIdleDuration))
+	Expect(optsA.SolveTimeout).To(Equal(optsB.SolveTimeout))
+	Expect(optsA.DisruptionDryRun).To(Equal(optsB.DisruptionDryRun))
+	Expect(optsA.DisruptionApprovalURL).To(Equal(optsB.DisruptionApprovalURL))
+	Expect(optsA.DisruptionApprovalTimeout).To(Equal(optsB.DisruptionApprovalTimeout))
+	Expect(optsA.DisruptionApprovalFailurePolicy).To(Equal(optsB.DisruptionApprovalFailurePolicy))
 	Expect(optsA.FeatureGates.SpotToSpotConsolidation).To(Equal(optsB.FeatureGates.SpotToSpotConsolidation))
+	Expect(optsA.FeatureGates.CapacityReservations).To(Equal(optsB.FeatureGates.CapacityReservations))
 }
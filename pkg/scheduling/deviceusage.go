@@ -0,0 +1,106 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/dcoppa/karpenter/pkg/utils/resources"
+)
+
+// DeviceClaims are the devices that are requested through the ResourceClaims of pods, keyed by the ResourceClaim that
+// requests them. Devices are counted by DeviceClass name so that they can be compared against the devices of an
+// instance type. ResourceClaims that are shared by multiple pods only count once.
+type DeviceClaims map[string]v1.ResourceList
+
+// Devices returns the total number of devices of each DeviceClass that are requested by the claims
+func (c DeviceClaims) Devices() v1.ResourceList {
+	devices := v1.ResourceList{}
+	for _, claim := range c {
+		devices = resources.MergeInto(devices, claim)
+	}
+	return devices
+}
+
+func (c DeviceClaims) Union(claims DeviceClaims) DeviceClaims {
+	cp := DeviceClaims{}
+	for k, v := range c {
+		cp[k] = v
+	}
+	for k, v := range claims {
+		cp[k] = v
+	}
+	return cp
+}
+
+// ExceedsCapacity returns an error if the devices requested by the claims don't fit in the device capacity
+func (c DeviceClaims) ExceedsCapacity(capacity v1.ResourceList) error {
+	if devices := c.Devices(); !resources.Fits(devices, capacity) {
+		return fmt.Errorf("would exceed device capacity, %s > %s", resources.String(devices), resources.String(capacity))
+	}
+	return nil
+}
+
+// GetDeviceClaims resolves the devices that are requested by the ResourceClaims and ResourceClaimTemplates that are
+// referenced by the pod. Requests for administrative access don't count against the devices of a node since they
+// don't allocate the device to the pod.
+func GetDeviceClaims(ctx context.Context, kubeClient client.Client, pod *v1.Pod) (DeviceClaims, error) {
+	claims := DeviceClaims{}
+	for _, podClaim := range pod.Spec.ResourceClaims {
+		var key string
+		var spec resourcev1alpha3.ResourceClaimSpec
+		switch {
+		case podClaim.ResourceClaimName != nil:
+			claim := &resourcev1alpha3.ResourceClaim{}
+			if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: *podClaim.ResourceClaimName}, claim); err != nil {
+				return nil, fmt.Errorf("getting resource claim %q, %w", *podClaim.ResourceClaimName, err)
+			}
+			key, spec = client.ObjectKeyFromObject(claim).String(), claim.Spec
+		case podClaim.ResourceClaimTemplateName != nil:
+			template := &resourcev1alpha3.ResourceClaimTemplate{}
+			if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: *podClaim.ResourceClaimTemplateName}, template); err != nil {
+				return nil, fmt.Errorf("getting resource claim template %q, %w", *podClaim.ResourceClaimTemplateName, err)
+			}
+			// Every pod gets its own ResourceClaim that is generated from the template
+			key, spec = fmt.Sprintf("%s/%s", client.ObjectKeyFromObject(pod), podClaim.Name), template.Spec.Spec
+		default:
+			continue
+		}
+		devices := v1.ResourceList{}
+		for _, request := range spec.Devices.Requests {
+			if request.AdminAccess {
+				continue
+			}
+			// Requests for all the devices of a DeviceClass need at least one device on the node
+			count := int64(1)
+			if request.AllocationMode != resourcev1alpha3.DeviceAllocationModeAll && request.Count > 0 {
+				count = request.Count
+			}
+			devices = resources.MergeInto(devices, v1.ResourceList{v1.ResourceName(request.DeviceClassName): *resource.NewQuantity(count, resource.DecimalSI)})
+		}
+		if len(devices) > 0 {
+			claims[key] = devices
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakecr "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("DeviceUsage", func() {
+	Context("DeviceClaims", func() {
+		It("should count the devices of every claim", func() {
+			claims := DeviceClaims{
+				"default/a": v1.ResourceList{"gpu.example.com": resource.MustParse("1")},
+				"default/b": v1.ResourceList{"gpu.example.com": resource.MustParse("2"), "nic.example.com": resource.MustParse("1")},
+			}
+			expectDevices(claims.Devices(), v1.ResourceList{"gpu.example.com": resource.MustParse("3"), "nic.example.com": resource.MustParse("1")})
+		})
+		It("should only count shared claims once", func() {
+			lhs := DeviceClaims{"default/shared": v1.ResourceList{"gpu.example.com": resource.MustParse("2")}}
+			rhs := DeviceClaims{
+				"default/shared": v1.ResourceList{"gpu.example.com": resource.MustParse("2")},
+				"default/other":  v1.ResourceList{"gpu.example.com": resource.MustParse("1")},
+			}
+			expectDevices(lhs.Union(rhs).Devices(), v1.ResourceList{"gpu.example.com": resource.MustParse("3")})
+			// union doesn't modify the claims that it's called on
+			Expect(lhs).To(HaveLen(1))
+		})
+		It("should exceed capacity when there aren't enough devices", func() {
+			claims := DeviceClaims{"default/a": v1.ResourceList{"gpu.example.com": resource.MustParse("2")}}
+			Expect(claims.ExceedsCapacity(v1.ResourceList{"gpu.example.com": resource.MustParse("2")})).To(Succeed())
+			Expect(claims.ExceedsCapacity(v1.ResourceList{"gpu.example.com": resource.MustParse("1")})).ToNot(Succeed())
+			Expect(claims.ExceedsCapacity(nil)).ToNot(Succeed())
+			Expect(DeviceClaims{}.ExceedsCapacity(nil)).To(Succeed())
+		})
+	})
+	Context("GetDeviceClaims", func() {
+		var ctx context.Context
+		var kubeClient client.Client
+		var pod *v1.Pod
+		BeforeEach(func() {
+			ctx = context.Background()
+			spec := resourcev1alpha3.ResourceClaimSpec{Devices: resourcev1alpha3.DeviceClaim{Requests: []resourcev1alpha3.DeviceRequest{
+				{Name: "exact", DeviceClassName: "gpu.example.com", AllocationMode: resourcev1alpha3.DeviceAllocationModeExactCount, Count: 2},
+				{Name: "default", DeviceClassName: "gpu.example.com"},
+				{Name: "all", DeviceClassName: "nic.example.com", AllocationMode: resourcev1alpha3.DeviceAllocationModeAll},
+				{Name: "admin", DeviceClassName: "nic.example.com", AdminAccess: true},
+			}}}
+			kubeClient = fakecr.NewClientBuilder().WithObjects(
+				&resourcev1alpha3.ResourceClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shared"}, Spec: spec},
+				&resourcev1alpha3.ResourceClaimTemplate{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "template"}, Spec: resourcev1alpha3.ResourceClaimTemplateSpec{Spec: spec}},
+			).Build()
+			pod = &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"}}
+		})
+		It("should resolve the devices of a resource claim", func() {
+			pod.Spec.ResourceClaims = []v1.PodResourceClaim{{Name: "claim", ResourceClaimName: lo.ToPtr("shared")}}
+			claims, err := GetDeviceClaims(ctx, kubeClient, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims).To(HaveKey("default/shared"))
+			expectDevices(claims["default/shared"], v1.ResourceList{"gpu.example.com": resource.MustParse("3"), "nic.example.com": resource.MustParse("1")})
+		})
+		It("should resolve the devices of a resource claim template for every pod", func() {
+			pod.Spec.ResourceClaims = []v1.PodResourceClaim{{Name: "claim", ResourceClaimTemplateName: lo.ToPtr("template")}}
+			claims, err := GetDeviceClaims(ctx, kubeClient, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims).To(HaveKey("default/pod/claim"))
+			expectDevices(claims["default/pod/claim"], v1.ResourceList{"gpu.example.com": resource.MustParse("3"), "nic.example.com": resource.MustParse("1")})
+		})
+		It("should return an error when the resource claim doesn't exist", func() {
+			pod.Spec.ResourceClaims = []v1.PodResourceClaim{{Name: "claim", ResourceClaimName: lo.ToPtr("missing")}}
+			_, err := GetDeviceClaims(ctx, kubeClient, pod)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should not return device claims for pods without resource claims", func() {
+			claims, err := GetDeviceClaims(ctx, kubeClient, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claims).To(BeEmpty())
+		})
+	})
+})
+
+func expectDevices(actual, expected v1.ResourceList) {
+	GinkgoHelper()
+	Expect(actual).To(HaveLen(len(expected)))
+	for deviceClass, count := range expected {
+		Expect(actual).To(HaveKey(deviceClass))
+		Expect(lo.ToPtr(actual[deviceClass]).Value()).To(Equal(count.Value()))
+	}
+}
@@ -43,11 +43,16 @@ var KnownEphemeralTaints = []corev1.Taint{
 type Taints []corev1.Taint
 
 // Tolerates returns true if the pod tolerates all taints.
-func (ts Taints) Tolerates(pod *corev1.Pod) (errs error) {
+func (ts Taints) Tolerates(pod *corev1.Pod) error {
+	return ts.ToleratedBy(pod.Spec.Tolerations)
+}
+
+// ToleratedBy returns true if the tolerations tolerate all taints.
+func (ts Taints) ToleratedBy(tolerations []corev1.Toleration) (errs error) {
 	for i := range ts {
 		taint := ts[i]
 		tolerates := false
-		for _, t := range pod.Spec.Tolerations {
+		for _, t := range tolerations {
 			tolerates = tolerates || t.ToleratesTaint(&taint)
 		}
 		if !tolerates {
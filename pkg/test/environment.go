@@ -129,6 +129,12 @@ func NewEnvironment(options ...option.Function[EnvironmentOptions]) *Environment
 		// Ref: https://github.com/aws/karpenter-core/pull/330
 		environment.ControlPlane.GetAPIServer().Configure().Set("feature-gates", "MinDomainsInPodTopologySpread=true")
 	}
+	if version.Minor() >= 31 {
+		// DynamicResourceAllocation serves the resource.k8s.io/v1alpha3 APIs so that pods can request devices through
+		// ResourceClaims. If the feature-gate is turned off, the api-server clears out the resource claims of pods.
+		environment.ControlPlane.GetAPIServer().Configure().Append("feature-gates", "DynamicResourceAllocation=true")
+		environment.ControlPlane.GetAPIServer().Configure().Set("runtime-config", "resource.k8s.io/v1alpha3=true")
+	}
 
 	_ = lo.Must(environment.Start())
 
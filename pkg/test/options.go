@@ -28,27 +28,33 @@ import (
 
 type OptionsFields struct {
 	// Vendor Neutral
-	ServiceName             *string
-	MetricsPort             *int
-	HealthProbePort         *int
-	KubeClientQPS           *int
-	KubeClientBurst         *int
-	EnableProfiling         *bool
-	DisableLeaderElection   *bool
-	LeaderElectionName      *string
-	LeaderElectionNamespace *string
-	MemoryLimit             *int64
-	LogLevel                *string
-	LogOutputPaths          *string
-	LogErrorOutputPaths     *string
-	BatchMaxDuration        *time.Duration
-	BatchIdleDuration       *time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                     *string
+	MetricsPort                     *int
+	HealthProbePort                 *int
+	KubeClientQPS                   *int
+	KubeClientBurst                 *int
+	EnableProfiling                 *bool
+	DisableLeaderElection           *bool
+	LeaderElectionName              *string
+	LeaderElectionNamespace         *string
+	MemoryLimit                     *int64
+	LogLevel                        *string
+	LogOutputPaths                  *string
+	LogErrorOutputPaths             *string
+	BatchMaxDuration                *time.Duration
+	BatchIdleDuration               *time.Duration
+	SolveTimeout                    *time.Duration
+	DisruptionDryRun                *bool
+	DisruptionApprovalURL           *string
+	DisruptionApprovalTimeout       *time.Duration
+	DisruptionApprovalFailurePolicy *string
+	FeatureGates                    FeatureGates
 }
 
 type FeatureGates struct {
 	NodeRepair              *bool
 	SpotToSpotConsolidation *bool
+	CapacityReservations    *bool
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -60,22 +66,28 @@ func Options(overrides ...OptionsFields) *options.Options {
 	}
 
 	return &options.Options{
-		ServiceName:           lo.FromPtrOr(opts.ServiceName, ""),
-		MetricsPort:           lo.FromPtrOr(opts.MetricsPort, 8080),
-		HealthProbePort:       lo.FromPtrOr(opts.HealthProbePort, 8081),
-		KubeClientQPS:         lo.FromPtrOr(opts.KubeClientQPS, 200),
-		KubeClientBurst:       lo.FromPtrOr(opts.KubeClientBurst, 300),
-		EnableProfiling:       lo.FromPtrOr(opts.EnableProfiling, false),
-		DisableLeaderElection: lo.FromPtrOr(opts.DisableLeaderElection, false),
-		MemoryLimit:           lo.FromPtrOr(opts.MemoryLimit, -1),
-		LogLevel:              lo.FromPtrOr(opts.LogLevel, ""),
-		LogOutputPaths:        lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
-		LogErrorOutputPaths:   lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
-		BatchMaxDuration:      lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
-		BatchIdleDuration:     lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ServiceName:                     lo.FromPtrOr(opts.ServiceName, ""),
+		MetricsPort:                     lo.FromPtrOr(opts.MetricsPort, 8080),
+		HealthProbePort:                 lo.FromPtrOr(opts.HealthProbePort, 8081),
+		KubeClientQPS:                   lo.FromPtrOr(opts.KubeClientQPS, 200),
+		KubeClientBurst:                 lo.FromPtrOr(opts.KubeClientBurst, 300),
+		EnableProfiling:                 lo.FromPtrOr(opts.EnableProfiling, false),
+		DisableLeaderElection:           lo.FromPtrOr(opts.DisableLeaderElection, false),
+		MemoryLimit:                     lo.FromPtrOr(opts.MemoryLimit, -1),
+		LogLevel:                        lo.FromPtrOr(opts.LogLevel, ""),
+		LogOutputPaths:                  lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
+		LogErrorOutputPaths:             lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
+		BatchMaxDuration:                lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
+		BatchIdleDuration:               lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		SolveTimeout:                    lo.FromPtrOr(opts.SolveTimeout, time.Minute),
+		DisruptionDryRun:                lo.FromPtrOr(opts.DisruptionDryRun, false),
+		DisruptionApprovalURL:           lo.FromPtrOr(opts.DisruptionApprovalURL, ""),
+		DisruptionApprovalTimeout:       lo.FromPtrOr(opts.DisruptionApprovalTimeout, 10*time.Second),
+		DisruptionApprovalFailurePolicy: lo.FromPtrOr(opts.DisruptionApprovalFailurePolicy, options.DisruptionApprovalFailurePolicyFail),
 		FeatureGates: options.FeatureGates{
 			NodeRepair:              lo.FromPtrOr(opts.FeatureGates.NodeRepair, false),
 			SpotToSpotConsolidation: lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			CapacityReservations:    lo.FromPtrOr(opts.FeatureGates.CapacityReservations, false),
 		},
 	}
 }
@@ -49,6 +49,7 @@ type PodOptions struct {
 	Tolerations                   []v1.Toleration
 	PersistentVolumeClaims        []string
 	EphemeralVolumeTemplates      []EphemeralVolumeTemplateOptions
+	ResourceClaims                []v1.PodResourceClaim
 	HostPorts                     []int32
 	Conditions                    []v1.PodCondition
 	Phase                         v1.PodPhase
@@ -173,6 +174,13 @@ func Pod(overrides ...PodOptions) *v1.Pod {
 	if options.Overhead != nil {
 		p.Spec.Overhead = options.Overhead
 	}
+	// The resource claims of the pod are all made available to its container
+	if options.ResourceClaims != nil {
+		p.Spec.ResourceClaims = options.ResourceClaims
+		p.Spec.Containers[0].Resources.Claims = append(p.Spec.Containers[0].Resources.Claims, lo.Map(options.ResourceClaims, func(c v1.PodResourceClaim, _ int) v1.ResourceClaim {
+			return v1.ResourceClaim{Name: c.Name}
+		})...)
+	}
 	if options.InitContainers != nil {
 		for _, init := range options.InitContainers {
 			init.Name = RandomName()
@@ -0,0 +1,77 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+
+	"github.com/imdario/mergo"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ResourceClaimOptions struct {
+	metav1.ObjectMeta
+	// Devices are the number of devices of each DeviceClass that are requested by the claim
+	Devices v1.ResourceList
+}
+
+func ResourceClaim(overrides ...ResourceClaimOptions) *resourcev1alpha3.ResourceClaim {
+	options := ResourceClaimOptions{}
+	for _, opts := range overrides {
+		if err := mergo.Merge(&options, opts, mergo.WithOverride); err != nil {
+			panic(fmt.Sprintf("Failed to merge options: %s", err))
+		}
+	}
+	return &resourcev1alpha3.ResourceClaim{
+		ObjectMeta: NamespacedObjectMeta(options.ObjectMeta),
+		Spec:       resourceClaimSpec(options.Devices),
+	}
+}
+
+func ResourceClaimTemplate(overrides ...ResourceClaimOptions) *resourcev1alpha3.ResourceClaimTemplate {
+	options := ResourceClaimOptions{}
+	for _, opts := range overrides {
+		if err := mergo.Merge(&options, opts, mergo.WithOverride); err != nil {
+			panic(fmt.Sprintf("Failed to merge options: %s", err))
+		}
+	}
+	return &resourcev1alpha3.ResourceClaimTemplate{
+		ObjectMeta: NamespacedObjectMeta(options.ObjectMeta),
+		Spec: resourcev1alpha3.ResourceClaimTemplateSpec{
+			Spec: resourceClaimSpec(options.Devices),
+		},
+	}
+}
+
+func resourceClaimSpec(devices v1.ResourceList) resourcev1alpha3.ResourceClaimSpec {
+	return resourcev1alpha3.ResourceClaimSpec{
+		Devices: resourcev1alpha3.DeviceClaim{
+			Requests: lo.MapToSlice(devices, func(deviceClass v1.ResourceName, count resource.Quantity) resourcev1alpha3.DeviceRequest {
+				return resourcev1alpha3.DeviceRequest{
+					Name:            RandomName(),
+					DeviceClassName: string(deviceClass),
+					AllocationMode:  resourcev1alpha3.DeviceAllocationModeExactCount,
+					Count:           count.Value(),
+				}
+			}),
+		},
+	}
+}
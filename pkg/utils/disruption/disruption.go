@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -76,3 +78,39 @@ func ReschedulingCost(ctx context.Context, pods []*corev1.Pod) float64 {
 	}
 	return cost
 }
+
+// IsExpired returns true if the NodeClaim has lived for its ExpireAfter
+func IsExpired(clk clock.Clock, nodeClaim *v1.NodeClaim) bool {
+	if nodeClaim.Spec.ExpireAfter.Duration == nil {
+		return false
+	}
+	return !clk.Now().Before(nodeClaim.CreationTimestamp.Add(*nodeClaim.Spec.ExpireAfter.Duration))
+}
+
+// DoNotDisruptDeadline returns whether the karpenter.sh/do-not-disrupt annotation of the object protects it from
+// disruption and the time at which the protection ends. The annotation protects the object indefinitely when it's
+// "true", for a duration after the creation of the object when it's a duration (e.g. "6h"), and until a deadline when
+// it's an RFC3339 timestamp. The returned time is zero if the protection doesn't end.
+func DoNotDisruptDeadline(obj metav1.Object) (time.Time, bool) {
+	value, ok := obj.GetAnnotations()[v1.DoNotDisruptAnnotationKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	if value == "true" {
+		return time.Time{}, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return obj.GetCreationTimestamp().Add(d), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// IsDoNotDisruptActive returns true if the karpenter.sh/do-not-disrupt annotation of the object currently protects it
+// from disruption
+func IsDoNotDisruptActive(clk clock.Clock, obj metav1.Object) bool {
+	deadline, ok := DoNotDisruptDeadline(obj)
+	return ok && (deadline.IsZero() || clk.Now().Before(deadline))
+}
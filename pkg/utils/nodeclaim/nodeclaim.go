@@ -18,8 +18,11 @@ package nodeclaim
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
@@ -236,3 +239,39 @@ func UpdateNodeOwnerReferences(nodeClaim *v1.NodeClaim, node *corev1.Node) *core
 	})
 	return node
 }
+
+// TemplateLabelKeys returns the keys of the labels of the NodePool template that were applied to the NodeClaim, either
+// when it was launched or when they were last updated in place. It returns nil if they aren't known.
+func TemplateLabelKeys(nodeClaim *v1.NodeClaim) []string {
+	value, ok := nodeClaim.Annotations[v1.NodeClaimTemplateLabelsAnnotationKey]
+	if !ok || value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// TemplateLabelKeysAnnotationValue returns the value of the annotation that records the keys of the template labels
+func TemplateLabelKeysAnnotationValue(labels map[string]string) string {
+	keys := lo.Keys(labels)
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// TemplateTaints returns the taints of the NodePool template that were applied to the NodeClaim, either when it was
+// launched or when they were last updated in place
+func TemplateTaints(nodeClaim *v1.NodeClaim) ([]corev1.Taint, error) {
+	value, ok := nodeClaim.Annotations[v1.NodeClaimTemplateTaintsAnnotationKey]
+	if !ok {
+		return nodeClaim.Spec.Taints, nil
+	}
+	var taints []corev1.Taint
+	if err := json.Unmarshal([]byte(value), &taints); err != nil {
+		return nil, fmt.Errorf("parsing %s, %w", v1.NodeClaimTemplateTaintsAnnotationKey, err)
+	}
+	return taints, nil
+}
+
+// TemplateTaintsAnnotationValue returns the value of the annotation that records the taints of the template
+func TemplateTaintsAnnotationValue(taints []corev1.Taint) string {
+	return string(lo.Must(json.Marshal(lo.Ternary(taints == nil, []corev1.Taint{}, taints))))
+}
@@ -19,6 +19,7 @@ package pdb
 import (
 	"context"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,62 +31,95 @@ import (
 )
 
 // Limits is used to evaluate if evicting a list of pods is possible.
-type Limits []*pdbItem
+type Limits struct {
+	clk  clock.Clock
+	pdbs []*pdbItem
+}
 
 func NewLimits(ctx context.Context, clk clock.Clock, kubeClient client.Client) (Limits, error) {
 	pdbs := []*pdbItem{}
 
 	var pdbList policyv1.PodDisruptionBudgetList
 	if err := kubeClient.List(ctx, &pdbList); err != nil {
-		return nil, err
+		return Limits{}, err
 	}
 	for _, pdb := range pdbList.Items {
 		pi, err := newPdb(pdb)
 		if err != nil {
-			return nil, err
+			return Limits{}, err
 		}
 		pdbs = append(pdbs, pi)
 	}
 
-	return pdbs, nil
+	return Limits{clk: clk, pdbs: pdbs}, nil
 }
 
 // CanEvictPods returns true if every pod in the list is evictable. They may not all be evictable simultaneously, but
 // for every PDB that controls the pods at least one pod can be evicted.
-// nolint:gocyclo
 func (l Limits) CanEvictPods(pods []*v1.Pod) (client.ObjectKey, bool) {
 	for _, pod := range pods {
 		// If the pod isn't eligible for being evicted, then a fully blocking PDB doesn't matter
 		// This is due to the fact that we won't call the eviction API on these pods when we are disrupting the node
-		if !podutil.IsEvictable(pod) {
+		if !podutil.IsEvictable(pod, l.clk) {
 			continue
 		}
-		for _, pdb := range l {
-			if pdb.key.Namespace == pod.ObjectMeta.Namespace {
-				if pdb.selector.Matches(labels.Set(pod.Labels)) {
-
-					// if the PDB policy is set to allow evicting unhealthy pods, then it won't stop us from
-					// evicting unhealthy pods
-					ignorePod := false
-					if pdb.canAlwaysEvictUnhealthyPods {
-						for _, c := range pod.Status.Conditions {
-							if c.Type == v1.PodReady && c.Status == v1.ConditionFalse {
-								ignorePod = true
-								continue
-							}
-						}
-					}
-
-					if !ignorePod && pdb.disruptionsAllowed == 0 {
-						return pdb.key, false
-					}
-				}
+		for _, pdb := range l.controlling(pod) {
+			if pdb.disruptionsAllowed <= 0 {
+				return pdb.key, false
 			}
 		}
 	}
 	return client.ObjectKey{}, true
 }
 
+// Disrupt uses a disruption of every PDB that controls the pod, so that the pods that are disrupted together don't
+// disrupt more pods than their PDBs allow. If any of the PDBs has no disruptions left, no disruption is used and the
+// key of that PDB is returned.
+func (l Limits) Disrupt(pod *v1.Pod) (client.ObjectKey, bool) {
+	if !podutil.IsEvictable(pod, l.clk) {
+		return client.ObjectKey{}, true
+	}
+	pdbs := l.controlling(pod)
+	for _, pdb := range pdbs {
+		if pdb.disruptionsAllowed <= 0 {
+			return pdb.key, false
+		}
+	}
+	for _, pdb := range pdbs {
+		pdb.disruptionsAllowed--
+	}
+	return client.ObjectKey{}, true
+}
+
+// Release gives back the disruptions that were used by Disrupt for the pod
+func (l Limits) Release(pod *v1.Pod) {
+	if !podutil.IsEvictable(pod, l.clk) {
+		return
+	}
+	for _, pdb := range l.controlling(pod) {
+		pdb.disruptionsAllowed++
+	}
+}
+
+// controlling returns the PDBs that limit the eviction of the pod
+func (l Limits) controlling(pod *v1.Pod) []*pdbItem {
+	var pdbs []*pdbItem
+	for _, pdb := range l.pdbs {
+		if pdb.key.Namespace != pod.ObjectMeta.Namespace || !pdb.selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		// if the PDB policy is set to allow evicting unhealthy pods, then it won't stop us from
+		// evicting unhealthy pods
+		if pdb.canAlwaysEvictUnhealthyPods && lo.ContainsBy(pod.Status.Conditions, func(c v1.PodCondition) bool {
+			return c.Type == v1.PodReady && c.Status == v1.ConditionFalse
+		}) {
+			continue
+		}
+		pdbs = append(pdbs, pdb)
+	}
+	return pdbs
+}
+
 type pdbItem struct {
 	key                         client.ObjectKey
 	selector                    labels.Selector
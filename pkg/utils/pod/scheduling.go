@@ -19,12 +19,14 @@ package pod
 import (
 	"time"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
 
 	v1 "github.com/dcoppa/karpenter/pkg/apis/v1"
 	"github.com/dcoppa/karpenter/pkg/scheduling"
+	disruptionutils "github.com/dcoppa/karpenter/pkg/utils/disruption"
 )
 
 // IsActive checks if Karpenter should consider this pod as running by ensuring that the pod:
@@ -52,12 +54,12 @@ func IsReschedulable(pod *corev1.Pod) bool {
 // - Is an active pod (isn't terminal or actively terminating)
 // - Doesn't tolerate the "karpenter.sh/disruption=disrupting" taint
 // - Isn't a mirror pod (https://kubernetes.io/docs/tasks/configure-pod-container/static-pod/)
-// - Does not have the "karpenter.sh/do-not-disrupt=true" annotation (https://karpenter.sh/docs/concepts/disruption/#pod-level-controls)
-func IsEvictable(pod *corev1.Pod) bool {
+// - Isn't protected by the "karpenter.sh/do-not-disrupt" annotation (https://karpenter.sh/docs/concepts/disruption/#pod-level-controls)
+func IsEvictable(pod *corev1.Pod, clk clock.Clock) bool {
 	return IsActive(pod) &&
 		!ToleratesDisruptedNoScheduleTaint(pod) &&
 		!IsOwnedByNode(pod) &&
-		!HasDoNotDisrupt(pod)
+		!HasDoNotDisrupt(pod, clk)
 }
 
 // IsWaitingEviction checks if this is a pod that we are waiting to be removed from the node by ensuring that the pod:
@@ -98,10 +100,10 @@ func IsProvisionable(pod *corev1.Pod) bool {
 
 // IsDisruptable checks if a pod can be disrupted based on validating the `karpenter.sh/do-not-disrupt` annotation on the pod.
 // It checks whether the following is true for the pod:
-// - Has the `karpenter.sh/do-not-disrupt` annotation
+// - Is protected by the `karpenter.sh/do-not-disrupt` annotation
 // - Is an actively running pod
-func IsDisruptable(pod *corev1.Pod) bool {
-	return !(IsActive(pod) && HasDoNotDisrupt(pod))
+func IsDisruptable(pod *corev1.Pod, clk clock.Clock) bool {
+	return !(IsActive(pod) && HasDoNotDisrupt(pod, clk))
 }
 
 // FailedToSchedule ensures that the kube-scheduler has seen this pod and has intentionally
@@ -127,6 +129,18 @@ func IsPreempting(pod *corev1.Pod) bool {
 	return pod.Status.NominatedNodeName != ""
 }
 
+// Priority returns the priority of the pod that was resolved from its PriorityClass, pods without a priority are
+// treated as having the default priority of zero
+func Priority(pod *corev1.Pod) int32 {
+	return lo.FromPtr(pod.Spec.Priority)
+}
+
+// CanPreempt returns true if the pod is allowed to preempt the victim pod. This follows kube-scheduler where a pod can
+// only preempt pods with a strictly lower priority, and only if its preemption policy allows it.
+func CanPreempt(pod, victim *corev1.Pod) bool {
+	return lo.FromPtr(pod.Spec.PreemptionPolicy) != corev1.PreemptNever && Priority(victim) < Priority(pod)
+}
+
 func IsTerminal(pod *corev1.Pod) bool {
 	return pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded
 }
@@ -173,11 +187,10 @@ func IsOwnedBy(pod *corev1.Pod, gvks []schema.GroupVersionKind) bool {
 	return false
 }
 
-func HasDoNotDisrupt(pod *corev1.Pod) bool {
-	if pod.Annotations == nil {
-		return false
-	}
-	return pod.Annotations[v1.DoNotDisruptAnnotationKey] == "true"
+// HasDoNotDisrupt returns true if the pod is currently protected by the `karpenter.sh/do-not-disrupt` annotation. The
+// annotation can protect the pod indefinitely, for a duration after its creation, or until an RFC3339 deadline.
+func HasDoNotDisrupt(pod *corev1.Pod, clk clock.Clock) bool {
+	return disruptionutils.IsDoNotDisruptActive(clk, pod)
 }
 
 // ToleratesDisruptedNoScheduleTaint returns true if the pod tolerates karpenter.sh/disrupted:NoSchedule taint